@@ -0,0 +1,306 @@
+package devops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+
+	DefaultAPIKeyHeader                     = "X-API-Key"
+	DefaultOAuth2ExpiryDelta                = 10 * time.Second
+	DefaultOAuth2ClientCredentialsGrantType = "client_credentials"
+)
+
+// Authenticator applies credentials to outgoing HTTP requests made by
+// SendHTTPRequest and DownloadFile
+type Authenticator interface {
+	// Authenticate adds credentials to the request, it is called for
+	// every attempt made to send a request
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorInvalidator can be implemented by an Authenticator
+// which caches credentials, Invalidate is called when a request is
+// rejected with 401 Unauthorized and the request is attempted once
+// more with fresh credentials
+type AuthenticatorInvalidator interface {
+	Invalidate()
+}
+
+// Authenticate sets the Authorization header of the request to use
+// basic access authentication
+func (b BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
+
+// BearerToken provides a static token for bearer authentication
+type BearerToken struct {
+	// Token is sent in the Authorization header as "Bearer <Token>"
+	Token string
+}
+
+// Authenticate sets the Authorization header of the request to use
+// the bearer token
+func (b BearerToken) Authenticate(req *http.Request) error {
+	if b.Token == "" {
+		return fmt.Errorf("%w: missing bearer token", ErrAuthenticationFailed)
+	}
+	req.Header.Set("Authorization", "Bearer "+b.Token)
+	return nil
+}
+
+// APIKey provides an API key sent as a header or a query parameter
+type APIKey struct {
+	// In defines where the key is sent, one of APIKeyInHeader or
+	// APIKeyInQuery
+	//
+	// Defaults to APIKeyInHeader if not specified
+	In string
+
+	// Name defines the name of the header or query parameter
+	//
+	// Defaults to DefaultAPIKeyHeader if not specified and .In is
+	// APIKeyInHeader
+	Name string
+
+	// Value is the API key
+	Value string
+}
+
+// Authenticate adds the API key to the request
+func (k APIKey) Authenticate(req *http.Request) error {
+	if k.Value == "" {
+		return fmt.Errorf("%w: missing api key", ErrAuthenticationFailed)
+	}
+	switch k.In {
+	case "", APIKeyInHeader:
+		name := k.Name
+		if name == "" {
+			name = DefaultAPIKeyHeader
+		}
+		req.Header.Set(name, k.Value)
+	case APIKeyInQuery:
+		if k.Name == "" {
+			return fmt.Errorf("%w: missing name of api key query parameter", ErrAuthenticationFailed)
+		}
+		query := req.URL.Query()
+		query.Set(k.Name, k.Value)
+		req.URL.RawQuery = query.Encode()
+	default:
+		return fmt.Errorf("%w: unknown api key location '%s'", ErrAuthenticationFailed, k.In)
+	}
+	return nil
+}
+
+// removeFrom removes the API key from the request so that it is not
+// forwarded when the request is redirected to another host, net/http
+// only does this for the Authorization and Cookie headers
+func (k APIKey) removeFrom(req *http.Request) {
+	switch k.In {
+	case "", APIKeyInHeader:
+		name := k.Name
+		if name == "" {
+			name = DefaultAPIKeyHeader
+		}
+		req.Header.Del(name)
+	case APIKeyInQuery:
+		query := req.URL.Query()
+		if query.Has(k.Name) {
+			query.Del(k.Name)
+			req.URL.RawQuery = query.Encode()
+		}
+	}
+}
+
+// NewOAuth2ClientCredentialsOpts presents configuration for the
+// NewOAuth2ClientCredentials method
+type NewOAuth2ClientCredentialsOpts struct {
+	// ClientID is the OAuth2 client ID
+	ClientID string
+
+	// ClientSecret is the OAuth2 client secret
+	ClientSecret string
+
+	// Client defines the HTTP client used to request tokens. If left
+	// nil, defaults to http.DefaultClient
+	Client *http.Client
+
+	// ExpiryDelta defines how long before its expiry a token is
+	// refreshed
+	//
+	// Defaults to DefaultOAuth2ExpiryDelta if not specified
+	ExpiryDelta time.Duration
+
+	// Parameters can optionally be specified to send additional
+	// parameters (eg. "audience") to the token endpoint
+	Parameters url.Values
+
+	// Scopes defines the scopes to request
+	Scopes []string
+
+	// SendCredentialsInBody when set to true sends the client ID and
+	// secret as form parameters instead of using basic access
+	// authentication
+	SendCredentialsInBody bool
+
+	// TokenURL defines the token endpoint of the authorization server
+	TokenURL *url.URL
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *NewOAuth2ClientCredentialsOpts) SetDefaults() {
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.ExpiryDelta == 0 {
+		o.ExpiryDelta = DefaultOAuth2ExpiryDelta
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the NewOAuth2ClientCredentials method
+func (o NewOAuth2ClientCredentialsOpts) Validate() error {
+	errors := []string{}
+
+	if o.ClientID == "" {
+		errors = append(errors, "missing client id")
+	}
+	if o.ClientSecret == "" {
+		errors = append(errors, "missing client secret")
+	}
+	if o.ExpiryDelta < 0 {
+		errors = append(errors, "expiry delta cannot be negative")
+	}
+	if o.TokenURL == nil {
+		errors = append(errors, "missing token url")
+	} else if o.TokenURL.Host == "" {
+		errors = append(errors, "missing host in token url")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// OAuth2ClientCredentials is an Authenticator which uses access tokens
+// obtained with the OAuth2 client credentials grant. Tokens are cached
+// and refreshed before they expire, it is safe for concurrent use
+type OAuth2ClientCredentials struct {
+	opts      NewOAuth2ClientCredentialsOpts
+	mutex     sync.Mutex
+	token     string
+	tokenType string
+	expiresAt time.Time
+}
+
+// NewOAuth2ClientCredentials returns an Authenticator which uses the
+// OAuth2 client credentials grant as configured by the options object
+// instance `opts`
+func NewOAuth2ClientCredentials(opts NewOAuth2ClientCredentialsOpts) (*OAuth2ClientCredentials, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to create oauth2 client credentials: %w", err)
+	}
+	return &OAuth2ClientCredentials{opts: opts}, nil
+}
+
+// oauth2TokenResponse is the response of a token endpoint
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// Authenticate sets the Authorization header of the request to use
+// a cached access token, a new token is requested if there is no
+// token or if the token is about to expire
+func (c *OAuth2ClientCredentials) Authenticate(req *http.Request) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token == "" || (!c.expiresAt.IsZero() && time.Now().After(c.expiresAt.Add(-c.opts.ExpiryDelta))) {
+		if err := c.refresh(req); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", c.tokenType+" "+c.token)
+	return nil
+}
+
+// Invalidate discards the cached access token so that a new one is
+// requested for the next request
+func (c *OAuth2ClientCredentials) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.token = ""
+}
+
+// refresh requests a new access token from the token endpoint using
+// the context of the request being authenticated
+func (c *OAuth2ClientCredentials) refresh(req *http.Request) error {
+	form := url.Values{}
+	for key, values := range c.opts.Parameters {
+		form[key] = values
+	}
+	form.Set("grant_type", DefaultOAuth2ClientCredentialsGrantType)
+	if len(c.opts.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.opts.Scopes, " "))
+	}
+	var auth Authenticator = BasicAuth{
+		Username: url.QueryEscape(c.opts.ClientID),
+		Password: url.QueryEscape(c.opts.ClientSecret),
+	}
+	if c.opts.SendCredentialsInBody {
+		form.Set("client_id", c.opts.ClientID)
+		form.Set("client_secret", c.opts.ClientSecret)
+		auth = nil
+	}
+	tokenURL := *c.opts.TokenURL
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		Auth:    auth,
+		Body:    []byte(form.Encode()),
+		Client:  c.opts.Client,
+		Context: req.Context(),
+		Headers: map[string][]string{
+			"Accept":       {DefaultJSONContentType},
+			"Content-Type": {"application/x-www-form-urlencoded"},
+		},
+		Method: http.MethodPost,
+		URL:    &tokenURL,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to request token: %w", ErrAuthenticationFailed, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %w", ErrAuthenticationFailed, newHTTPError(res, DefaultHTTPErrorBodySize))
+	}
+	var token oauth2TokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return fmt.Errorf("%w: failed to decode token response: %w", ErrAuthenticationFailed, err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("%w: token response from '%s' did not contain an access token", ErrAuthenticationFailed, tokenURL.Redacted())
+	}
+	c.token = token.AccessToken
+	c.tokenType = "Bearer"
+	// token types are case-insensitive but some servers only accept
+	// the capitalised "Bearer"
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		c.tokenType = token.TokenType
+	}
+	c.expiresAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		c.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return nil
+}
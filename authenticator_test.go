@@ -0,0 +1,204 @@
+package devops
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthenticatorTests struct {
+	suite.Suite
+}
+
+func TestAuthenticator(t *testing.T) {
+	suite.Run(t, &AuthenticatorTests{})
+}
+
+func (s AuthenticatorTests) TestBasicAuth() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		s.True(ok)
+		s.Equal("user", username)
+		s.Equal("password", password)
+	})
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		BasicAuth: &BasicAuth{Username: "user", Password: "password"},
+		URL:       serverURL,
+	})
+	s.Nil(err)
+	res.Body.Close()
+	s.Nil(serverURL.User, "the provided url should not be modified")
+}
+
+func (s AuthenticatorTests) TestBearerToken() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("Bearer token", r.Header.Get("Authorization"))
+	})
+	headers := map[string][]string{}
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		Auth:    BearerToken{Token: "token"},
+		Headers: headers,
+		URL:     serverURL,
+	})
+	s.Nil(err)
+	res.Body.Close()
+	s.Empty(headers, "the provided headers should not be modified")
+
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{Auth: BearerToken{}, URL: serverURL})
+	s.True(errors.Is(err, ErrAuthenticationFailed))
+}
+
+func (s AuthenticatorTests) TestAPIKey() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s|%s", r.Header.Get("X-API-Key"), r.URL.Query().Get("api_key"), r.URL.Query().Get("other"))
+	})
+	serverURL.RawQuery = "other=value"
+	for _, testCase := range []struct {
+		auth     APIKey
+		expected string
+	}{
+		{auth: APIKey{Value: "key"}, expected: "key||value"},
+		{auth: APIKey{In: APIKeyInQuery, Name: "api_key", Value: "key"}, expected: "|key|value"},
+	} {
+		res, err := SendHTTPRequest(SendHTTPRequestOpts{Auth: testCase.auth, URL: serverURL})
+		s.Nil(err)
+		body := make([]byte, 64)
+		n, _ := res.Body.Read(body)
+		res.Body.Close()
+		s.Equal(testCase.expected, string(body[:n]))
+	}
+	s.Equal("other=value", serverURL.RawQuery, "the provided url should not be modified")
+}
+
+func (s AuthenticatorTests) TestAPIKey_redirect() {
+	otherURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-API-Key"), r.URL.Query().Get("api_key"))
+	})
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/other" {
+			http.Redirect(w, r, otherURL.String()+"/?api_key="+r.URL.Query().Get("api_key"), http.StatusFound)
+			return
+		}
+		if r.URL.Path == "/same" {
+			http.Redirect(w, r, "/echo?api_key="+r.URL.Query().Get("api_key"), http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-API-Key"), r.URL.Query().Get("api_key"))
+	})
+	for _, testCase := range []struct {
+		auth     Authenticator
+		path     string
+		expected string
+	}{
+		{auth: APIKey{Value: "key"}, path: "/other", expected: "|"},
+		{auth: &APIKey{In: APIKeyInQuery, Name: "api_key", Value: "key"}, path: "/other", expected: "|"},
+		{auth: APIKey{Value: "key"}, path: "/same", expected: "key|"},
+		{auth: APIKey{In: APIKeyInQuery, Name: "api_key", Value: "key"}, path: "/same", expected: "|key"},
+	} {
+		res, err := SendHTTPRequest(SendHTTPRequestOpts{Auth: testCase.auth, URL: serverURL.JoinPath(testCase.path)})
+		s.Nil(err)
+		body := make([]byte, 64)
+		n, _ := res.Body.Read(body)
+		res.Body.Close()
+		s.Equal(testCase.expected, string(body[:n]), "api keys should only be sent to the same host")
+	}
+}
+
+func (s AuthenticatorTests) TestOAuth2ClientCredentials() {
+	var tokensIssued int32
+	tokenURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		s.True(ok)
+		s.Equal("client", clientID)
+		s.Equal("secret", clientSecret)
+		s.Nil(r.ParseForm())
+		s.Equal("client_credentials", r.PostForm.Get("grant_type"))
+		s.Equal("read write", r.PostForm.Get("scope"))
+		s.Equal("api", r.PostForm.Get("audience"))
+		issued := atomic.AddInt32(&tokensIssued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%v","token_type":"bearer","expires_in":3600}`, issued)
+	})
+	var requests int32
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		// the first token is rejected to simulate a revoked token
+		if atomic.AddInt32(&requests, 1) == 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	})
+	auth, err := NewOAuth2ClientCredentials(NewOAuth2ClientCredentialsOpts{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Parameters:   url.Values{"audience": {"api"}},
+		Scopes:       []string{"read", "write"},
+		TokenURL:     tokenURL,
+	})
+	s.Nil(err)
+
+	getAuthorization := func() string {
+		res, err := SendHTTPRequest(SendHTTPRequestOpts{Auth: auth, URL: serverURL})
+		s.Nil(err)
+		defer res.Body.Close()
+		s.Equal(http.StatusOK, res.StatusCode)
+		body := make([]byte, 64)
+		n, _ := res.Body.Read(body)
+		return string(body[:n])
+	}
+	s.Equal("Bearer token-1", getAuthorization())
+	s.Equal("Bearer token-2", getAuthorization(), "a new token should be requested after a 401 response")
+	s.Equal("Bearer token-2", getAuthorization(), "the token should be cached")
+	s.EqualValues(2, atomic.LoadInt32(&tokensIssued))
+}
+
+func (s AuthenticatorTests) TestOAuth2ClientCredentials_expiry() {
+	var tokensIssued int32
+	tokenURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		s.Nil(r.ParseForm())
+		s.Equal("client", r.PostForm.Get("client_id"))
+		s.Equal("secret", r.PostForm.Get("client_secret"))
+		issued := atomic.AddInt32(&tokensIssued, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%v","expires_in":5}`, issued)
+	})
+	auth, err := NewOAuth2ClientCredentials(NewOAuth2ClientCredentialsOpts{
+		ClientID:              "client",
+		ClientSecret:          "secret",
+		ExpiryDelta:           time.Minute,
+		SendCredentialsInBody: true,
+		TokenURL:              tokenURL,
+	})
+	s.Nil(err)
+	for i := 1; i <= 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		s.Nil(auth.Authenticate(req))
+		s.Equal(fmt.Sprintf("Bearer token-%v", i), req.Header.Get("Authorization"), "tokens expiring within the expiry delta should be refreshed")
+	}
+}
+
+func (s AuthenticatorTests) TestOAuth2ClientCredentials_tokenError() {
+	tokenURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid_client"}`)
+	})
+	auth, err := NewOAuth2ClientCredentials(NewOAuth2ClientCredentialsOpts{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		TokenURL:     tokenURL,
+	})
+	s.Nil(err)
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{Auth: auth, URL: tokenURL})
+	s.True(errors.Is(err, ErrAuthenticationFailed))
+	s.True(errors.Is(err, ErrUnexpectedStatusCode))
+	s.Contains(err.Error(), "invalid_client")
+
+	_, err = NewOAuth2ClientCredentials(NewOAuth2ClientCredentialsOpts{})
+	s.True(errors.Is(err, ErrInvalidOptions))
+}
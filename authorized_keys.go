@@ -0,0 +1,258 @@
+package devops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	DefaultAuthorizedKeysPath                 = "~/.ssh/authorized_keys"
+	DefaultAuthorizedKeysFileMode os.FileMode = 0600
+)
+
+// authorizedKeyExpiryFormats are the formats of the expiry-time option
+// accepted by sshd
+var authorizedKeyExpiryFormats = []string{"200601021504", "20060102150405", "20060102"}
+
+// AuthorizedKey is an entry of an authorized_keys file
+type AuthorizedKey struct {
+	// Comment is the comment after the key, usually in the form
+	// user@host
+	Comment string
+
+	// Key is the public key
+	Key ssh.PublicKey
+
+	// Options are the options before the key as they appear in the
+	// file (eg. `no-pty` or `command="uptime"`)
+	Options []string
+}
+
+// ParseAuthorizedKey parses a single authorized_keys line such as the
+// .Public of a SSHKeypair
+func ParseAuthorizedKey(line []byte) (*AuthorizedKey, error) {
+	publicKey, comment, options, rest, err := ssh.ParseAuthorizedKey(line)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse authorized key: %w", err)
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return nil, fmt.Errorf("failed to parse authorized key: found more than one key")
+	}
+	return &AuthorizedKey{
+		Comment: comment,
+		Key:     publicKey,
+		Options: options,
+	}, nil
+}
+
+// GetFingerprint returns the fingerprints of the key
+func (k AuthorizedKey) GetFingerprint() SshKeyFingerprint {
+	return getSSHPublicKeyFingerprint(k.Key)
+}
+
+// GetOption returns the unquoted value of the option `name` (eg.
+// "command" or "from") and whether the option is set, flags such as
+// `no-pty` have an empty value
+func (k AuthorizedKey) GetOption(name string) (string, bool) {
+	for _, option := range k.Options {
+		optionName, value, hasValue := strings.Cut(option, "=")
+		if !strings.EqualFold(optionName, name) {
+			continue
+		}
+		if !hasValue {
+			return "", true
+		}
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// GetExpiryTime returns the time in the expiry-time option in the
+// local time zone as interpreted by sshd, the zero time is returned if
+// the option is not set
+func (k AuthorizedKey) GetExpiryTime() (time.Time, error) {
+	value, ok := k.GetOption("expiry-time")
+	if !ok {
+		return time.Time{}, nil
+	}
+	for _, format := range authorizedKeyExpiryFormats {
+		if expiryTime, err := time.ParseInLocation(format, value, time.Local); err == nil {
+			return expiryTime, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry-time '%s'", value)
+}
+
+// String returns the key as an authorized_keys line without a line
+// break
+func (k AuthorizedKey) String() string {
+	line := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(k.Key)), "\n")
+	if len(k.Options) > 0 {
+		line = strings.Join(k.Options, ",") + " " + line
+	}
+	if k.Comment != "" {
+		line += " " + k.Comment
+	}
+	return line
+}
+
+// AuthorizedKeys is the content of an authorized_keys file, lines
+// which are not keys (comments, blank lines and lines sshd cannot
+// parse) are kept as they are
+type AuthorizedKeys struct {
+	lines []authorizedKeysLine
+}
+
+// authorizedKeysLine is a line of an authorized_keys file, .key is
+// nil for lines which are not keys
+type authorizedKeysLine struct {
+	raw string
+	key *AuthorizedKey
+}
+
+// ParseAuthorizedKeys parses the content of an authorized_keys file
+func ParseAuthorizedKeys(content []byte) *AuthorizedKeys {
+	authorizedKeys := &AuthorizedKeys{}
+	content = bytes.TrimSuffix(content, []byte("\n"))
+	if len(content) == 0 {
+		return authorizedKeys
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		entry := authorizedKeysLine{raw: strings.TrimSuffix(line, "\r")}
+		trimmed := strings.TrimSpace(entry.raw)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			if key, err := ParseAuthorizedKey([]byte(trimmed)); err == nil {
+				entry.key = key
+			}
+		}
+		authorizedKeys.lines = append(authorizedKeys.lines, entry)
+	}
+	return authorizedKeys
+}
+
+// LoadAuthorizedKeys reads the authorized_keys file at `filePath`, an
+// empty AuthorizedKeys is returned if the file does not exist
+//
+// Defaults to DefaultAuthorizedKeysPath if `filePath` is empty
+func LoadAuthorizedKeys(filePath string) (*AuthorizedKeys, error) {
+	if filePath == "" {
+		filePath = DefaultAuthorizedKeysPath
+	}
+	normalizedPath, err := NormalizeLocalPath(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize path '%s': %w", filePath, err)
+	}
+	/* #nosec - this is required to read the authorized keys */
+	content, err := os.ReadFile(normalizedPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &AuthorizedKeys{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read authorized keys at '%s': %w", normalizedPath, err)
+	}
+	return ParseAuthorizedKeys(content), nil
+}
+
+// GetKeys returns the keys in the order they appear
+func (a *AuthorizedKeys) GetKeys() []AuthorizedKey {
+	keys := []AuthorizedKey{}
+	for _, line := range a.lines {
+		if line.key != nil {
+			keys = append(keys, *line.key)
+		}
+	}
+	return keys
+}
+
+// Add appends `key` if a key with the same fingerprint does not exist
+// and returns true if it was added, existing keys are left unchanged
+// including their options and comment
+func (a *AuthorizedKeys) Add(key AuthorizedKey) (bool, error) {
+	if key.Key == nil {
+		return false, fmt.Errorf("%w: ['missing key']", ErrInvalidOptions)
+	}
+	line := key.String()
+	parsedKey, err := ParseAuthorizedKey([]byte(line))
+	if err != nil || strings.Join(parsedKey.Options, ",") != strings.Join(key.Options, ",") || parsedKey.Comment != key.Comment {
+		return false, fmt.Errorf("%w: ['invalid options or comment']", ErrInvalidOptions)
+	}
+	fingerprint := ssh.FingerprintSHA256(key.Key)
+	for _, existing := range a.lines {
+		if existing.key != nil && ssh.FingerprintSHA256(existing.key.Key) == fingerprint {
+			return false, nil
+		}
+	}
+	a.lines = append(a.lines, authorizedKeysLine{raw: line, key: parsedKey})
+	return true, nil
+}
+
+// RemoveByFingerprint removes keys with the SHA256 (eg. 'SHA256:aBcD...')
+// or MD5 (eg. 'aa:bb:cc:...') fingerprint `fingerprint` and returns
+// the number of keys removed
+func (a *AuthorizedKeys) RemoveByFingerprint(fingerprint string) int {
+	return a.remove(func(key *AuthorizedKey) bool {
+		keyFingerprint := key.GetFingerprint()
+		return keyFingerprint.GetSHA256() == fingerprint || keyFingerprint.GetMD5() == fingerprint
+	})
+}
+
+// RemoveByComment removes keys with the comment `comment` and returns
+// the number of keys removed
+func (a *AuthorizedKeys) RemoveByComment(comment string) int {
+	return a.remove(func(key *AuthorizedKey) bool {
+		return key.Comment == comment
+	})
+}
+
+// remove removes keys which `isMatch` returns true for
+func (a *AuthorizedKeys) remove(isMatch func(key *AuthorizedKey) bool) int {
+	lines := []authorizedKeysLine{}
+	removed := 0
+	for _, line := range a.lines {
+		if line.key != nil && isMatch(line.key) {
+			removed++
+			continue
+		}
+		lines = append(lines, line)
+	}
+	a.lines = lines
+	return removed
+}
+
+// Bytes returns the content of the authorized_keys file
+func (a *AuthorizedKeys) Bytes() []byte {
+	var content bytes.Buffer
+	for _, line := range a.lines {
+		content.WriteString(line.raw)
+		content.WriteString("\n")
+	}
+	return content.Bytes()
+}
+
+// Save atomically writes the authorized_keys file to `filePath` with
+// DefaultAuthorizedKeysFileMode, creating its parent directory with
+// DefaultSSHDirectoryMode if it does not exist
+//
+// Defaults to DefaultAuthorizedKeysPath if `filePath` is empty
+func (a *AuthorizedKeys) Save(filePath string) error {
+	if filePath == "" {
+		filePath = DefaultAuthorizedKeysPath
+	}
+	normalizedPath, err := NormalizeLocalPath(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to normalize path '%s': %w", filePath, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(normalizedPath), DefaultSSHDirectoryMode); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", normalizedPath, err)
+	}
+	return writeFileAtomically(normalizedPath, a.Bytes(), DefaultAuthorizedKeysFileMode)
+}
@@ -0,0 +1,146 @@
+package devops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthorizedKeysTests struct {
+	suite.Suite
+}
+
+func TestAuthorizedKeys(t *testing.T) {
+	suite.Run(t, &AuthorizedKeysTests{})
+}
+
+func (s AuthorizedKeysTests) readPublicKey(name string) string {
+	content, err := os.ReadFile("./tests/sshkeys/" + name + ".pub")
+	s.Nil(err)
+	return strings.TrimSpace(string(content))
+}
+
+func (s AuthorizedKeysTests) getContent() string {
+	firstKey := strings.TrimSuffix(s.readPublicKey("id_rsa_1024"), " z@z")
+	secondKey := s.readPublicKey("id_rsa_1024-w-password")
+	return strings.Join([]string{
+		"# managed by provisioning",
+		`command="echo \"hi\"",from="10.0.0.0/8",no-pty,expiry-time="20300102" ` + firstKey + " deploy@ci",
+		"",
+		"ssh-rsa not-a-key broken@host",
+		secondKey,
+	}, "\n") + "\n"
+}
+
+func (s AuthorizedKeysTests) TestParseAuthorizedKeys() {
+	authorizedKeys := ParseAuthorizedKeys([]byte(s.getContent()))
+	keys := authorizedKeys.GetKeys()
+	s.Len(keys, 2)
+
+	s.Equal("deploy@ci", keys[0].Comment)
+	s.Equal(TestSshKeysFingerprint["id_rsa_1024"].sha256, keys[0].GetFingerprint().GetSHA256())
+	s.Equal(TestSshKeysFingerprint["id_rsa_1024"].md5, keys[0].GetFingerprint().GetMD5())
+	command, ok := keys[0].GetOption("command")
+	s.True(ok)
+	s.Equal(`echo "hi"`, command)
+	from, ok := keys[0].GetOption("from")
+	s.True(ok)
+	s.Equal("10.0.0.0/8", from)
+	value, ok := keys[0].GetOption("no-pty")
+	s.True(ok)
+	s.Equal("", value)
+	_, ok = keys[0].GetOption("permitopen")
+	s.False(ok)
+	expiryTime, err := keys[0].GetExpiryTime()
+	s.Nil(err)
+	s.Equal(time.Date(2030, 1, 2, 0, 0, 0, 0, time.Local), expiryTime)
+
+	s.Equal("z@z", keys[1].Comment)
+	s.Empty(keys[1].Options)
+	expiryTime, err = keys[1].GetExpiryTime()
+	s.Nil(err)
+	s.True(expiryTime.IsZero())
+
+	s.Equal(s.getContent(), string(authorizedKeys.Bytes()), "unmodified files should be written back as they were")
+	s.Empty(ParseAuthorizedKeys(nil).Bytes())
+}
+
+func (s AuthorizedKeysTests) TestAuthorizedKeys_Add() {
+	authorizedKeys := ParseAuthorizedKeys([]byte(s.getContent()))
+	existingKey, err := ParseAuthorizedKey([]byte(s.readPublicKey("id_rsa_1024")))
+	s.Nil(err)
+	isAdded, err := authorizedKeys.Add(*existingKey)
+	s.Nil(err)
+	s.False(isAdded, "keys with the same fingerprint should not be added again")
+	s.Equal(s.getContent(), string(authorizedKeys.Bytes()))
+
+	keypair, err := NewSSHKeypair(NewSSHKeypairOpts{Type: SSHKeyTypeEd25519, Comment: "new@host"})
+	s.Nil(err)
+	newKey, err := ParseAuthorizedKey(keypair.Public)
+	s.Nil(err)
+	newKey.Options = []string{"no-pty", `command="uptime"`}
+	isAdded, err = authorizedKeys.Add(*newKey)
+	s.Nil(err)
+	s.True(isAdded)
+	isAdded, err = authorizedKeys.Add(*newKey)
+	s.Nil(err)
+	s.False(isAdded)
+	keys := authorizedKeys.GetKeys()
+	s.Len(keys, 3)
+	s.Equal(newKey.Options, keys[2].Options)
+	s.Equal("new@host", keys[2].Comment)
+	s.True(strings.HasSuffix(string(authorizedKeys.Bytes()), `no-pty,command="uptime" `+strings.TrimSpace(string(keypair.Public))+"\n"))
+
+	newKey.Options = []string{"command=echo hi"}
+	_, err = authorizedKeys.Add(*newKey)
+	s.True(errors.Is(err, ErrInvalidOptions))
+	_, err = authorizedKeys.Add(AuthorizedKey{})
+	s.True(errors.Is(err, ErrInvalidOptions))
+}
+
+func (s AuthorizedKeysTests) TestAuthorizedKeys_Remove() {
+	authorizedKeys := ParseAuthorizedKeys([]byte(s.getContent()))
+	s.Equal(1, authorizedKeys.RemoveByFingerprint(TestSshKeysFingerprint["id_rsa_1024"].sha256))
+	s.Equal(0, authorizedKeys.RemoveByFingerprint(TestSshKeysFingerprint["id_rsa_1024"].sha256))
+	s.Equal(1, authorizedKeys.RemoveByComment("z@z"))
+	s.Empty(authorizedKeys.GetKeys())
+	s.Equal("# managed by provisioning\n\nssh-rsa not-a-key broken@host\n", string(authorizedKeys.Bytes()))
+
+	authorizedKeys = ParseAuthorizedKeys([]byte(s.getContent()))
+	s.Equal(1, authorizedKeys.RemoveByFingerprint(TestSshKeysFingerprint["id_rsa_1024-w-password"].md5))
+	s.Len(authorizedKeys.GetKeys(), 1)
+}
+
+func (s AuthorizedKeysTests) TestLoadAuthorizedKeys() {
+	filePath := filepath.Join(s.T().TempDir(), "ssh", "authorized_keys")
+	authorizedKeys, err := LoadAuthorizedKeys(filePath)
+	s.Nil(err)
+	s.Empty(authorizedKeys.GetKeys())
+
+	authorizedKeys = ParseAuthorizedKeys([]byte(s.getContent()))
+	s.Nil(authorizedKeys.Save(filePath))
+	fileInfo, err := os.Stat(filePath)
+	s.Nil(err)
+	s.Equal(DefaultAuthorizedKeysFileMode, fileInfo.Mode().Perm())
+	fileInfo, err = os.Stat(filepath.Dir(filePath))
+	s.Nil(err)
+	s.Equal(DefaultSSHDirectoryMode, fileInfo.Mode().Perm())
+
+	authorizedKeys, err = LoadAuthorizedKeys(filePath)
+	s.Nil(err)
+	s.Len(authorizedKeys.GetKeys(), 2)
+	s.Equal(s.getContent(), string(authorizedKeys.Bytes()))
+}
+
+func (s AuthorizedKeysTests) TestParseAuthorizedKey() {
+	_, err := ParseAuthorizedKey([]byte("not a key"))
+	s.NotNil(err)
+	_, err = ParseAuthorizedKey([]byte(s.readPublicKey("id_rsa_1024") + "\n" + s.readPublicKey("id_rsa_1024-w-password")))
+	s.NotNil(err)
+	s.Contains(err.Error(), "more than one key")
+}
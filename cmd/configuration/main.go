@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 	"strings"
@@ -23,7 +24,14 @@ func main() {
 	c := configuration{}
 	if err := devops.LoadConfiguration(&c); err != nil {
 		log.Println(err)
-		os.Exit(err.(devops.LoadConfigurationError).Code)
+		if errors.Is(err, devops.ErrConfigNotFound) {
+			log.Println("some required configuration was not found in the environment")
+		}
+		var errs devops.LoadConfigurationErrors
+		if errors.As(err, &errs) {
+			os.Exit(errs.GetCode())
+		}
+		os.Exit(1)
 	}
 	log.Printf("RequiredBool:   '%v'", c.RequiredBool)
 	log.Printf("OptionalBool:   '%v' (ptr)", c.OptionalBool)
@@ -16,6 +16,7 @@ func main() {
 	now := time.Now().UTC().UnixMicro()
 	if err := devops.DownloadFile(devops.DownloadFileOpts{
 		DestinationPath: fmt.Sprintf("./tests/downloads/%v.txt", now),
+		Progress:        devops.NewProgressBar(devops.NewProgressBarOpts{Label: targetURL.String()}),
 		URL:             targetURL,
 	}); err != nil {
 		panic(err)
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+
+	"gitlab.com/zephinzer/go-devops"
+)
+
+func main() {
+	name, err := devops.Prompt(devops.PromptOpts{
+		Question: "project name?",
+		Default:  "my-project",
+		Validator: func(input string) error {
+			if !regexp.MustCompile("^[a-z-]+$").MatchString(input) {
+				return fmt.Errorf("only lowercase letters and dashes are allowed")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		log.Fatalf("failed to get user input: %s", err)
+	}
+	log.Printf("project name: %s\n", name)
+
+	password, err := devops.PromptPassword(devops.PromptPasswordOpts{
+		Question:            "ssh key password?",
+		Confirm:             true,
+		MinLength:           8,
+		MinCharacterClasses: 3,
+	})
+	if err != nil {
+		log.Fatalf("failed to get user input: %s", err)
+	}
+	log.Printf("password length: %v\n", len(password))
+}
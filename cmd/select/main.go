@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"gitlab.com/zephinzer/go-devops"
+)
+
+func main() {
+	environment, err := devops.Select(devops.SelectOpts{
+		Question: "which environment?",
+		Options:  []string{"dev", "staging", "prod"},
+		Default:  "dev",
+	})
+	if err != nil {
+		log.Fatalf("failed to get user input: %s", err)
+	}
+	log.Printf("selected environment: %s\n", environment)
+
+	services, err := devops.MultiSelect(devops.MultiSelectOpts{
+		Question: "which services?",
+		Options:  []string{"api", "web", "worker"},
+		Defaults: []string{"api"},
+	})
+	if err != nil {
+		log.Fatalf("failed to get user input: %s", err)
+	}
+	log.Printf("selected services: ['%s']\n", strings.Join(services, "', '"))
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+
+	"gitlab.com/zephinzer/go-devops"
+)
+
+type configuration struct {
+	ProjectName string   `prompt:"project name?"`
+	Environment string   `enum:"dev,staging,prod" default:"dev"`
+	Services    []string `enum:"api,web,worker" default:"api"`
+	Replicas    int      `default:"1"`
+	ApiToken    string   `secret:"true"`
+	Description *string
+}
+
+func main() {
+	c := configuration{}
+	if err := devops.PromptConfiguration(devops.PromptConfigurationOpts{
+		Config:     &c,
+		DotenvPath: "./.env",
+		SaveDotenv: true,
+	}); err != nil {
+		log.Fatalf("failed to set up configuration: %s", err)
+	}
+	log.Printf("ProjectName: '%s'", c.ProjectName)
+	log.Printf("Environment: '%s'", c.Environment)
+	log.Printf("Services:    '%v'", c.Services)
+	log.Printf("Replicas:    '%v'", c.Replicas)
+	log.Printf("ApiToken:    (%v characters)", len(c.ApiToken))
+}
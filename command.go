@@ -33,6 +33,12 @@ type InputHook struct {
 // CommandFlagSet defines a set of boolean configuration flags for the
 // Command class
 type CommandFlagset struct {
+	// AllowRelativePath allows binaries to be found through relative
+	// entries in $PATH (eg. `./bin`) which exec.LookPath rejects with
+	// exec.ErrDot since these can run binaries from an untrusted
+	// working directory
+	AllowRelativePath bool
+
 	// HideStdout indicates whether STDOUT should be printed to the terminal
 	HideStdout bool
 
@@ -118,7 +124,7 @@ func (nco NewCommandOpts) Validate() error {
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to validate NewCommandOpts: ['%s']", strings.Join(errors, "', '"))
+		return fmt.Errorf("%w for NewCommandOpts: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
 	}
 	return nil
 }
@@ -126,12 +132,12 @@ func (nco NewCommandOpts) Validate() error {
 // NewCommand initialises a new Command interface and returns it
 func NewCommand(opts NewCommandOpts) (Command, error) {
 	if err := opts.Validate(); err != nil {
-		return nil, fmt.Errorf("failed to create Command: %s", err)
+		return nil, fmt.Errorf("failed to create Command: %w", err)
 	}
 
 	currentDirectory, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get working directory: %s", err)
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
 	cmd := exec.Cmd{}
@@ -157,11 +163,12 @@ func NewCommand(opts NewCommandOpts) (Command, error) {
 
 	// do the lookup and set the exec.Cmd's Path property
 	invocation, err := exec.LookPath(opts.Command)
+	if errors.Is(err, exec.ErrDot) && opts.Flag.AllowRelativePath {
+		// the relative path is resolved to an absolute path below
+		err = nil
+	}
 	if err != nil {
-		if errors.Is(err, exec.ErrNotFound) {
-			return nil, fmt.Errorf("failed to find binary '%s' in $PATH: %s", opts.Command, err)
-		}
-		return nil, fmt.Errorf("failed to find binary '%s' in $PATH: %s", opts.Command, err)
+		return nil, fmt.Errorf("%w '%s' in $PATH: %w", ErrBinaryNotFound, opts.Command, err)
 	}
 	if strings.Contains(invocation, "/") {
 		if !path.IsAbs(invocation) {
@@ -185,7 +192,7 @@ func NewCommand(opts NewCommandOpts) (Command, error) {
 		}
 		fileInfo, err := os.Lstat(workingDir)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get information about path '%s': %s", workingDir, err)
+			return nil, fmt.Errorf("failed to get information about path '%s': %w", workingDir, err)
 		}
 		if !fileInfo.IsDir() {
 			return nil, fmt.Errorf("failed to find a directory at path '%s'", workingDir)
@@ -247,7 +254,7 @@ func NewCommand(opts NewCommandOpts) (Command, error) {
 		} else {
 			stdin, err = cmd.StdinPipe()
 			if err != nil {
-				return nil, fmt.Errorf("failed to provision a tty: %s", err)
+				return nil, fmt.Errorf("failed to provision a tty: %w", err)
 			}
 		}
 	}
@@ -1,8 +1,10 @@
 package devops
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path"
 	"strings"
 	"testing"
@@ -218,6 +220,7 @@ func (s CommandTests) Test_NewCommand_pathNotFound() {
 		Command: "thisbinarydoesnotexist",
 	})
 	s.NotNil(err)
+	s.True(errors.Is(err, ErrBinaryNotFound))
 	s.Nil(command)
 	currentPath := os.Getenv("PATH")
 	command, err = NewCommand(NewCommandOpts{
@@ -226,12 +229,25 @@ func (s CommandTests) Test_NewCommand_pathNotFound() {
 			"PATH": currentPath + ":./tests/command",
 		},
 	})
+	s.True(errors.Is(err, ErrBinaryNotFound))
+	s.True(errors.Is(err, exec.ErrDot), "binaries in relative $PATH entries should not be used by default")
+	s.Nil(command)
+	command, err = NewCommand(NewCommandOpts{
+		Command: "thisbinarydoesnotexist",
+		Environment: map[string]string{
+			"PATH": currentPath + ":./tests/command",
+		},
+		Flag: CommandFlagset{AllowRelativePath: true},
+	})
 	s.Nil(err)
+	s.NotNil(command)
 }
 
 func (s CommandTests) Test_NewCommandOpts_Validate() {
 	opts := NewCommandOpts{}
 	err := opts.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "NewCommandOpts")
 	s.Contains(err.Error(), ".Command")
 	opts.Command = "test"
 
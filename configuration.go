@@ -1,7 +1,10 @@
 package devops
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"unsafe"
 
 	"github.com/zephinzer/go-strcase"
@@ -73,6 +76,36 @@ func (c configurationField) GetDefaultValue() *string {
 	return nil
 }
 
+func (c configurationField) GetDelimiter() string {
+	if v, ok := c.Tag.Lookup("delimiter"); ok {
+		return v
+	}
+	return DefaultStringSliceDelimiter
+}
+
+func (c configurationField) GetEnum() []string {
+	if v, ok := c.Tag.Lookup("enum"); ok && v != "" {
+		return strings.Split(v, ",")
+	}
+	return nil
+}
+
+func (c configurationField) GetPrompt() string {
+	if v, ok := c.Tag.Lookup("prompt"); ok {
+		return v
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, c.GetEnvironmentKey())
+}
+
+func (c configurationField) IsOptional() bool {
+	return c.Type.Kind() == reflect.Ptr
+}
+
+func (c configurationField) IsSecret() bool {
+	isSecret, _ := strconv.ParseBool(c.Tag.Get("secret"))
+	return isSecret
+}
+
 func (c configurationField) GetEnvironmentKey() string {
 	if v, ok := c.Tag.Lookup("env"); ok {
 		return v
@@ -100,3 +100,26 @@ func (s ConfigurationTest) Test_configurationField_Setters() {
 	config.Fields[7].SetStringSlice([]string{"hola", "para", "ti"})
 	s.EqualValues([]string{"hola", "para", "ti"}, testStructInstance.RequiredStringSlice)
 }
+
+func (s ConfigurationTest) Test_configurationField_promptTags() {
+	type testStruct struct {
+		Plain    string
+		Tagged   *[]string `enum:"a,b" prompt:"pick:" secret:"true" delimiter:";"`
+		NotEnum  string    `enum:""`
+		NoSecret string    `secret:"nope"`
+	}
+	config := newConfiguration(&testStruct{})
+	plain, tagged, notEnum, noSecret := config.Fields[0], config.Fields[1], config.Fields[2], config.Fields[3]
+	s.Nil(plain.GetEnum())
+	s.Equal("Plain (PLAIN)", plain.GetPrompt())
+	s.Equal(DefaultStringSliceDelimiter, plain.GetDelimiter())
+	s.False(plain.IsOptional())
+	s.False(plain.IsSecret())
+	s.Equal([]string{"a", "b"}, tagged.GetEnum())
+	s.Equal("pick:", tagged.GetPrompt())
+	s.Equal(";", tagged.GetDelimiter())
+	s.True(tagged.IsOptional())
+	s.True(tagged.IsSecret())
+	s.Nil(notEnum.GetEnum())
+	s.False(noSecret.IsSecret())
+}
@@ -1,19 +1,53 @@
 package devops
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	DefaultConfirmInputHint = " (only '%s' will be accepted) "
+	DefaultConfirmAssumeYesEnv = "ASSUME_YES"
+	DefaultConfirmInputHint    = " (only '%s' will be accepted) "
+)
+
+// ConfirmPolicy defines how .Confirm behaves in a non-interactive
+// session
+type ConfirmPolicy string
+
+const (
+	// ConfirmPolicyPrompt reads from the input regardless of whether
+	// the session is interactive
+	ConfirmPolicyPrompt ConfirmPolicy = ""
+
+	// ConfirmPolicyAccept confirms without reading from the input
+	ConfirmPolicyAccept ConfirmPolicy = "accept"
+
+	// ConfirmPolicyReject rejects without reading from the input
+	ConfirmPolicyReject ConfirmPolicy = "reject"
+
+	// ConfirmPolicyError returns ErrNonInteractive without reading
+	// from the input
+	ConfirmPolicyError ConfirmPolicy = "error"
 )
 
 type ConfirmOpts struct {
+	// AssumeYesEnv can optionally be specified to define an
+	// environment variable which when set to a truthy value (see
+	// strconv.ParseBool) causes .Confirm to return true without
+	// prompting, set this to DefaultConfirmAssumeYesEnv to use the
+	// conventional $ASSUME_YES. The environment is not checked if this
+	// is not specified
+	AssumeYesEnv string
+
+	// Default defines the answer returned when .Timeout is reached
+	// before the user provides an input
+	Default bool
+
 	// Question can optionally be specified for the .Confirm method to
 	// print a string before requesting for confirmation. A space will
 	// be added at the end of the provided .Question before the
@@ -46,10 +80,27 @@ type ConfirmOpts struct {
 	// When this is defined, MatchExact CANNOT be defined
 	MatchRegexp *regexp.Regexp
 
+	// NonInteractivePolicy defines the behaviour when the session is
+	// non-interactive, which is when .Input is a file that is not a
+	// terminal or when the $CI environment variable is true
+	//
+	// Defaults to ConfirmPolicyPrompt if not specified
+	NonInteractivePolicy ConfirmPolicy
+
 	// Output defines the output stream to write output to
 	//
-	// Defaults to os.Stdin if not specified
+	// Defaults to os.Stdout if not specified
 	Output io.Writer
+
+	// Timeout defines the duration to wait for user input before
+	// returning .Default. The input is not read unless input arrives
+	// before the timeout so that a late answer is left for the next
+	// reader. This is only supported when .Input is a file (such as
+	// os.Stdin) on Unix-like systems since reads of other inputs cannot
+	// be cancelled
+	//
+	// No timeout is applied if not specified
+	Timeout time.Duration
 }
 
 // SetDefaults checks for unspecified properties which have defaults
@@ -76,24 +127,55 @@ func (o ConfirmOpts) Validate() error {
 	if o.MatchExact != "" && o.MatchRegexp != nil {
 		errors = append(errors, "only one Match* should be defined")
 	}
+	switch o.NonInteractivePolicy {
+	case ConfirmPolicyPrompt, ConfirmPolicyAccept, ConfirmPolicyReject, ConfirmPolicyError:
+	default:
+		errors = append(errors, fmt.Sprintf("unknown non-interactive policy '%s'", o.NonInteractivePolicy))
+	}
+	if o.Timeout < 0 {
+		errors = append(errors, "timeout cannot be negative")
+	} else if o.Timeout > 0 {
+		if _, isFile := o.Input.(*os.File); (o.Input != nil && !isFile) || !canWaitForInput {
+			errors = append(errors, "timeout is only supported when input is a file on this platform")
+		}
+	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to validate options: ['%s']", strings.Join(errors, "', '"))
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
 	}
 	return nil
 }
 
 // Confirm performs a user-terminal-input based confirmation. This
 // can be used in situations where it could be useful for a user to
-// manually verify a string such as a command to be run
+// manually verify a string such as a command to be run.
+//
+// An error matching ErrNoInput is returned if the input ends before
+// the user provides an answer so that this can be differentiated from
+// a rejected confirmation
 func Confirm(opts ConfirmOpts) (bool, error) {
 	opts.SetDefaults()
 	if err := opts.Validate(); err != nil {
-		return false, fmt.Errorf("failed to trigger confirmation: %s", err)
+		return false, fmt.Errorf("failed to trigger confirmation: %w", err)
+	}
+	if opts.AssumeYesEnv != "" {
+		if assumeYes, err := strconv.ParseBool(os.Getenv(opts.AssumeYesEnv)); err == nil && assumeYes {
+			return true, nil
+		}
+	}
+	if opts.NonInteractivePolicy != ConfirmPolicyPrompt && isNonInteractive(opts.Input) {
+		switch opts.NonInteractivePolicy {
+		case ConfirmPolicyAccept:
+			return true, nil
+		case ConfirmPolicyReject:
+			return false, nil
+		default:
+			return false, fmt.Errorf("failed to trigger confirmation: %w", ErrNonInteractive)
+		}
 	}
 	if opts.Question != "" {
 		if _, err := opts.Output.Write([]byte(opts.Question)); err != nil {
-			return false, fmt.Errorf("failed to write to output: %s", err)
+			return false, fmt.Errorf("failed to write to output: %w", err)
 		}
 	}
 	isUsingRegexp := opts.MatchRegexp != nil
@@ -102,17 +184,28 @@ func Confirm(opts ConfirmOpts) (bool, error) {
 		acceptedText = opts.MatchRegexp.String()
 	}
 	if _, err := opts.Output.Write([]byte(fmt.Sprintf(opts.InputHint, acceptedText))); err != nil {
-		return false, fmt.Errorf("failed to write to output: %s", err)
+		return false, fmt.Errorf("failed to write to output: %w", err)
 	}
-	scanner := bufio.NewScanner(opts.Input)
-	if scanner.Scan() {
-		input := strings.Trim(scanner.Text(), " \n\t\r")
-		if isUsingRegexp {
-			return opts.MatchRegexp.Match([]byte(input)), nil
+
+	if opts.Timeout > 0 {
+		isReady, err := waitForInput(opts.Input.(*os.File), opts.Timeout)
+		if err != nil {
+			return false, fmt.Errorf("failed to get user input: %w", err)
+		}
+		if !isReady {
+			if _, err := opts.Output.Write([]byte("\n")); err != nil {
+				return false, fmt.Errorf("failed to write to output: %w", err)
+			}
+			return opts.Default, nil
 		}
-		return opts.MatchExact == input, nil
-	} else if err := scanner.Err(); err != nil {
-		return false, fmt.Errorf("failed to get user input: %s", err)
 	}
-	return false, nil
+	input, err := readLine(opts.Input)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user input: %w", err)
+	}
+	input = strings.Trim(input, " \n\t\r")
+	if isUsingRegexp {
+		return opts.MatchRegexp.Match([]byte(input)), nil
+	}
+	return opts.MatchExact == input, nil
 }
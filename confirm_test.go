@@ -2,9 +2,13 @@ package devops
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -59,4 +63,129 @@ func (s ConfirmTests) TestConfirm_Validation() {
 	result, err = Confirm(options)
 	s.False(result)
 	s.NotNil(err, "should fail if both matchers are not defined")
+
+	options = ConfirmOpts{MatchExact: "yes", NonInteractivePolicy: "maybe"}
+	result, err = Confirm(options)
+	s.False(result)
+	s.True(errors.Is(err, ErrInvalidOptions), "should fail if the policy is unknown")
+}
+
+func (s ConfirmTests) TestConfirm_noInput() {
+	var input bytes.Buffer
+	result, err := Confirm(ConfirmOpts{Input: &input, Output: &bytes.Buffer{}, MatchExact: "yes"})
+	s.False(result)
+	s.True(errors.Is(err, ErrNoInput), "an empty input should be differentiated from a rejection")
+
+	input.Write([]byte("no\n"))
+	result, err = Confirm(ConfirmOpts{Input: &input, Output: &bytes.Buffer{}, MatchExact: "yes"})
+	s.False(result)
+	s.Nil(err)
+}
+
+func (s ConfirmTests) TestConfirm_AssumeYesEnv() {
+	s.T().Setenv(DefaultConfirmAssumeYesEnv, "true")
+	var input bytes.Buffer
+	result, err := Confirm(ConfirmOpts{Input: &input, Output: &bytes.Buffer{}, MatchExact: "yes"})
+	s.False(result)
+	s.True(errors.Is(err, ErrNoInput), "the environment should only be checked when .AssumeYesEnv is set")
+
+	result, err = Confirm(ConfirmOpts{AssumeYesEnv: DefaultConfirmAssumeYesEnv, Input: &input, Output: &bytes.Buffer{}, MatchExact: "yes"})
+	s.True(result)
+	s.Nil(err)
+
+	s.T().Setenv(DefaultConfirmAssumeYesEnv, "false")
+	result, err = Confirm(ConfirmOpts{AssumeYesEnv: DefaultConfirmAssumeYesEnv, Input: &input, Output: &bytes.Buffer{}, MatchExact: "yes"})
+	s.False(result)
+	s.True(errors.Is(err, ErrNoInput), "a falsy value should not skip the prompt")
+}
+
+func (s ConfirmTests) TestConfirm_NonInteractivePolicy() {
+	reader, writer, err := os.Pipe()
+	s.Nil(err)
+	defer reader.Close()
+	defer writer.Close()
+	expectations := map[ConfirmPolicy]bool{
+		ConfirmPolicyAccept: true,
+		ConfirmPolicyReject: false,
+	}
+	for policy, expected := range expectations {
+		result, err := Confirm(ConfirmOpts{
+			Input:                reader,
+			Output:               &bytes.Buffer{},
+			MatchExact:           "yes",
+			NonInteractivePolicy: policy,
+		})
+		s.Nil(err)
+		s.Equal(expected, result)
+	}
+	result, err := Confirm(ConfirmOpts{
+		Input:                reader,
+		Output:               &bytes.Buffer{},
+		MatchExact:           "yes",
+		NonInteractivePolicy: ConfirmPolicyError,
+	})
+	s.False(result)
+	s.True(errors.Is(err, ErrNonInteractive))
+}
+
+func (s ConfirmTests) TestConfirm_NonInteractivePolicy_ci() {
+	s.T().Setenv("CI", "true")
+	var input bytes.Buffer
+	input.Write([]byte("no\n"))
+	result, err := Confirm(ConfirmOpts{
+		Input:                &input,
+		Output:               &bytes.Buffer{},
+		MatchExact:           "yes",
+		NonInteractivePolicy: ConfirmPolicyAccept,
+	})
+	s.True(result)
+	s.Nil(err)
+}
+
+func (s ConfirmTests) TestConfirm_Timeout() {
+	if !canWaitForInput {
+		s.T().Skip("timeouts are not supported on this platform")
+	}
+	reader, writer, err := os.Pipe()
+	s.Nil(err)
+	defer reader.Close()
+	defer writer.Close()
+	for _, expected := range []bool{true, false} {
+		result, err := Confirm(ConfirmOpts{
+			Default:    expected,
+			Input:      reader,
+			Output:     &bytes.Buffer{},
+			MatchExact: "yes",
+			Timeout:    10 * time.Millisecond,
+		})
+		s.Nil(err)
+		s.Equal(expected, result)
+	}
+
+	_, err = writer.Write([]byte("no\nyes\n"))
+	s.Nil(err)
+	for _, expected := range []bool{false, true} {
+		result, err := Confirm(ConfirmOpts{
+			Default:    true,
+			Input:      reader,
+			Output:     &bytes.Buffer{},
+			MatchExact: "yes",
+			Timeout:    time.Second,
+		})
+		s.Nil(err)
+		s.Equal(expected, result, "lines should be read in order after a timeout")
+	}
+}
+
+func (s ConfirmTests) TestConfirm_Timeout_unsupportedInput() {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	_, err := Confirm(ConfirmOpts{
+		Input:      reader,
+		Output:     &bytes.Buffer{},
+		MatchExact: "yes",
+		Timeout:    10 * time.Millisecond,
+	})
+	s.True(errors.Is(err, ErrInvalidOptions), "reads of inputs which are not files cannot be cancelled")
+	s.Contains(err.Error(), "timeout is only supported when input is a file")
 }
@@ -0,0 +1,598 @@
+package devops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultRedactedValue     = "REDACTED"
+	DefaultCurlRedactedValue = DefaultRedactedValue
+)
+
+// sensitiveNames are substrings of header, query parameter and form
+// field names whose values are redacted by GetCurlCommand and
+// HTTPRecorder
+var sensitiveNames = []string{
+	"apikey",
+	"api-key",
+	"api_key",
+	"authorization",
+	"cookie",
+	"credential",
+	"passwd",
+	"password",
+	"secret",
+	"session",
+	"signature",
+	"token",
+}
+
+// curlSafeArgument matches arguments which do not need to be quoted
+var curlSafeArgument = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// GetCurlCommand returns a curl command which sends the same request
+// as this object instance for debugging. Credentials and the values of
+// headers, query parameters and form fields with sensitive names (eg.
+// "Authorization" or "client_secret") are redacted
+func (o SendHTTPRequestOpts) GetCurlCommand() string {
+	o.SetDefaults()
+	arguments := []string{"curl"}
+	hasBody := o.Body != nil || o.BodyFile != "" || o.BodyReader != nil || o.Form != nil || o.Multipart != nil
+	if o.Method != http.MethodGet || hasBody {
+		arguments = append(arguments, "-X", o.Method)
+	}
+
+	headerNames := []string{}
+	for headerName := range o.Headers {
+		headerNames = append(headerNames, headerName)
+	}
+	sort.Strings(headerNames)
+	for _, headerName := range headerNames {
+		for _, value := range o.Headers[headerName] {
+			if isSensitiveName(headerName) {
+				value = DefaultCurlRedactedValue
+			}
+			arguments = append(arguments, "-H", fmt.Sprintf("%s: %s", headerName, value))
+		}
+	}
+
+	var requestURL url.URL
+	if o.URL != nil {
+		requestURL = *o.URL
+		if requestURL.User != nil {
+			requestURL.User = url.UserPassword(requestURL.User.Username(), DefaultCurlRedactedValue)
+		}
+		requestURL.RawQuery = redactSensitiveValues(requestURL.Query()).Encode()
+	}
+	auth := o.Auth
+	if o.BasicAuth != nil {
+		auth = *o.BasicAuth
+	}
+	switch authenticator := auth.(type) {
+	case nil:
+	case BasicAuth:
+		arguments = append(arguments, "-u", authenticator.Username+":"+DefaultCurlRedactedValue)
+	case *BasicAuth:
+		arguments = append(arguments, "-u", authenticator.Username+":"+DefaultCurlRedactedValue)
+	case APIKey:
+		if authenticator.In == APIKeyInQuery {
+			query := requestURL.Query()
+			query.Set(authenticator.Name, DefaultCurlRedactedValue)
+			requestURL.RawQuery = query.Encode()
+		} else {
+			name := authenticator.Name
+			if name == "" {
+				name = DefaultAPIKeyHeader
+			}
+			arguments = append(arguments, "-H", name+": "+DefaultCurlRedactedValue)
+		}
+	case BearerToken, *OAuth2ClientCredentials:
+		arguments = append(arguments, "-H", "Authorization: Bearer "+DefaultCurlRedactedValue)
+	default:
+		arguments = append(arguments, "-H", "Authorization: "+DefaultCurlRedactedValue)
+	}
+
+	switch {
+	case o.Body != nil:
+		if strings.HasPrefix(http.Header(o.Headers).Get("Content-Type"), DefaultFormContentType) {
+			if form, err := url.ParseQuery(string(o.Body)); err == nil {
+				arguments = append(arguments, "--data-binary", redactSensitiveValues(form).Encode())
+				break
+			}
+		}
+		if body, ok := redactSensitiveJSON(o.Body); ok {
+			arguments = append(arguments, "--data-binary", string(body))
+		} else {
+			// bodies which cannot be redacted are left out
+			arguments = append(arguments, "--data-binary", fmt.Sprintf("<%v bytes>", len(o.Body)))
+		}
+	case o.BodyFile != "":
+		arguments = append(arguments, "--data-binary", "@"+o.BodyFile)
+	case o.BodyReader != nil:
+		arguments = append(arguments, "--data-binary", "@-")
+	case o.Form != nil:
+		form := redactSensitiveValues(o.Form)
+		fieldNames := []string{}
+		for fieldName := range form {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+		for _, fieldName := range fieldNames {
+			for _, value := range form[fieldName] {
+				arguments = append(arguments, "--data-urlencode", fieldName+"="+value)
+			}
+		}
+	case o.Multipart != nil:
+		fields := redactSensitiveValues(o.Multipart.Fields)
+		fieldNames := []string{}
+		for fieldName := range fields {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+		for _, fieldName := range fieldNames {
+			for _, value := range fields[fieldName] {
+				arguments = append(arguments, "--form-string", fieldName+"="+value)
+			}
+		}
+		for _, file := range o.Multipart.Files {
+			argument := file.FieldName + "=@-"
+			if file.Path != "" {
+				argument = file.FieldName + "=@" + file.Path
+			}
+			if file.FileName != "" {
+				argument += ";filename=" + file.FileName
+			}
+			if file.ContentType != "" {
+				argument += ";type=" + file.ContentType
+			}
+			arguments = append(arguments, "-F", argument)
+		}
+	}
+
+	if !o.DisableRedirects {
+		arguments = append(arguments, "-L")
+	}
+	if transport, ok := o.Client.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil && transport.TLSClientConfig.InsecureSkipVerify {
+		arguments = append(arguments, "-k")
+	}
+	if o.Timeout > 0 {
+		arguments = append(arguments, "--max-time", strconv.FormatFloat(o.Timeout.Seconds(), 'f', -1, 64))
+	}
+	if o.Retry != nil {
+		retry := *o.Retry
+		retry.SetDefaults()
+		arguments = append(arguments, "--retry", strconv.Itoa(retry.MaxAttempts-1))
+	}
+	arguments = append(arguments, requestURL.String())
+
+	for index, argument := range arguments {
+		arguments[index] = quoteShellArgument(argument)
+	}
+	return strings.Join(arguments, " ")
+}
+
+// isSensitiveName returns true if values with the provided name
+// should be redacted
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitiveName := range sensitiveNames {
+		if strings.Contains(name, sensitiveName) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSensitiveValues returns a copy of `values` with the values of
+// sensitive names redacted
+func redactSensitiveValues(values map[string][]string) url.Values {
+	redacted := url.Values{}
+	for name, nameValues := range values {
+		for _, value := range nameValues {
+			if isSensitiveName(name) {
+				value = DefaultRedactedValue
+			}
+			redacted.Add(name, value)
+		}
+	}
+	return redacted
+}
+
+// redactSensitiveJSON returns `body` with the values of object keys with
+// sensitive names redacted and false if `body` is not JSON
+func redactSensitiveJSON(body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return nil, false
+	}
+	var redacted bytes.Buffer
+	encoder := json.NewEncoder(&redacted)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactSensitiveJSONValue(value)); err != nil {
+		return nil, false
+	}
+	return bytes.TrimSuffix(redacted.Bytes(), []byte("\n")), true
+}
+
+// redactSensitiveJSONValue redacts the values of object keys with
+// sensitive names in `value` and any objects nested in it
+func redactSensitiveJSONValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, keyValue := range typedValue {
+			if isSensitiveName(key) {
+				typedValue[key] = DefaultRedactedValue
+			} else {
+				typedValue[key] = redactSensitiveJSONValue(keyValue)
+			}
+		}
+	case []interface{}:
+		for index, item := range typedValue {
+			typedValue[index] = redactSensitiveJSONValue(item)
+		}
+	}
+	return value
+}
+
+// quoteShellArgument quotes the argument for use in a POSIX shell
+func quoteShellArgument(argument string) string {
+	if curlSafeArgument.MatchString(argument) {
+		return argument
+	}
+	return "'" + strings.ReplaceAll(argument, "'", `'\''`) + "'"
+}
+
+// ParseCurlCommand returns options for SendHTTPRequest which send the
+// same request as the provided curl command. The following flags are
+// supported:
+//
+//	-X, --request           -H, --header            -d, --data
+//	--data-ascii            --data-binary           --data-raw
+//	--data-urlencode        -F, --form              --form-string
+//	-u, --user              -A, --user-agent        -b, --cookie
+//	-k, --insecure          --cacert                -L, --location
+//	--max-time              --retry                 --url
+//
+// The flags -s, -S, -v, -i and --compressed and their long forms are
+// ignored, ErrUnsupportedCurlOption is returned for other flags
+func ParseCurlCommand(command string) (SendHTTPRequestOpts, error) {
+	opts := SendHTTPRequestOpts{}
+	arguments, err := splitShellArguments(command)
+	if err != nil {
+		return opts, fmt.Errorf("failed to parse curl command: %w", err)
+	}
+	if len(arguments) > 0 && (arguments[0] == "curl" || strings.HasSuffix(arguments[0], "/curl")) {
+		arguments = arguments[1:]
+	}
+
+	var caCertificatePaths []string
+	var data []string
+	var rawURL string
+	headers := http.Header{}
+	isInsecure := false
+	followRedirects := false
+	for index := 0; index < len(arguments); index++ {
+		flag := arguments[index]
+		if !strings.HasPrefix(flag, "-") || flag == "-" {
+			if rawURL != "" {
+				return opts, fmt.Errorf("failed to parse curl command: %w: multiple urls ('%s' and '%s')", ErrUnsupportedCurlOption, rawURL, flag)
+			}
+			rawURL = flag
+			continue
+		}
+
+		// short flags can be combined (eg. -sSL) or have their values
+		// attached (eg. -XPOST)
+		value, hasValue := "", false
+		if !strings.HasPrefix(flag, "--") && len(flag) > 2 {
+			if strings.Trim(flag[1:], "sSvikL") == "" {
+				expanded := []string{}
+				for _, shortFlag := range flag[1:] {
+					expanded = append(expanded, "-"+string(shortFlag))
+				}
+				arguments = append(arguments[:index], append(expanded, arguments[index+1:]...)...)
+				index--
+				continue
+			}
+			if strings.ContainsRune("sSvikL", rune(flag[1])) {
+				return opts, fmt.Errorf("failed to parse curl command: %w: '%s'", ErrUnsupportedCurlOption, flag)
+			}
+			flag, value, hasValue = flag[:2], flag[2:], true
+		}
+		getValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if index+1 >= len(arguments) {
+				return "", fmt.Errorf("failed to parse curl command: missing value for '%s'", flag)
+			}
+			index++
+			return arguments[index], nil
+		}
+
+		switch flag {
+		case "-s", "--silent", "-S", "--show-error", "-v", "--verbose", "-i", "--include", "--compressed":
+		case "-k", "--insecure":
+			isInsecure = true
+		case "-L", "--location":
+			followRedirects = true
+		case "-X", "--request":
+			if opts.Method, err = getValue(); err != nil {
+				return opts, err
+			}
+		case "-H", "--header":
+			header, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			name, headerValue, ok := strings.Cut(header, ":")
+			if !ok {
+				return opts, fmt.Errorf("failed to parse curl command: %w: header '%s' has no value", ErrUnsupportedCurlOption, header)
+			}
+			headers.Add(strings.TrimSpace(name), strings.TrimSpace(headerValue))
+		case "-A", "--user-agent":
+			userAgent, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			headers.Set("User-Agent", userAgent)
+		case "-b", "--cookie":
+			cookie, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			if !strings.Contains(cookie, "=") {
+				return opts, fmt.Errorf("failed to parse curl command: %w: reading cookies from files is not supported", ErrUnsupportedCurlOption)
+			}
+			headers.Add("Cookie", cookie)
+		case "-u", "--user":
+			user, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			username, password, _ := strings.Cut(user, ":")
+			opts.BasicAuth = &BasicAuth{Username: username, Password: password}
+		case "--cacert":
+			caCertificatePath, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			caCertificatePaths = append(caCertificatePaths, caCertificatePath)
+		case "--max-time":
+			maxTime, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			seconds, err := strconv.ParseFloat(maxTime, 64)
+			if err != nil {
+				return opts, fmt.Errorf("failed to parse curl command: invalid max time '%s': %w", maxTime, err)
+			}
+			opts.Timeout = time.Duration(seconds * float64(time.Second))
+		case "--retry":
+			retries, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			count, err := strconv.Atoi(retries)
+			if err != nil {
+				return opts, fmt.Errorf("failed to parse curl command: invalid retry count '%s': %w", retries, err)
+			}
+			opts.Retry = &RetryPolicy{MaxAttempts: count + 1}
+		case "--url":
+			if rawURL, err = getValue(); err != nil {
+				return opts, err
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw", "--data-urlencode":
+			datum, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			if datum, err = parseCurlData(flag, datum); err != nil {
+				return opts, err
+			}
+			if datum == "@-" {
+				return opts, fmt.Errorf("failed to parse curl command: %w: reading data from stdin", ErrUnsupportedCurlOption)
+			}
+			if strings.HasPrefix(datum, "@") {
+				if opts.BodyFile != "" || len(data) > 0 {
+					return opts, fmt.Errorf("failed to parse curl command: %w: files cannot be combined with other data", ErrUnsupportedCurlOption)
+				}
+				opts.BodyFile = datum[1:]
+				continue
+			}
+			if opts.BodyFile != "" {
+				return opts, fmt.Errorf("failed to parse curl command: %w: files cannot be combined with other data", ErrUnsupportedCurlOption)
+			}
+			data = append(data, datum)
+		case "-F", "--form", "--form-string":
+			field, err := getValue()
+			if err != nil {
+				return opts, err
+			}
+			if opts.Multipart == nil {
+				opts.Multipart = &MultipartForm{Fields: map[string][]string{}}
+			}
+			if err := parseCurlFormField(opts.Multipart, field, flag == "--form-string"); err != nil {
+				return opts, err
+			}
+		default:
+			return opts, fmt.Errorf("failed to parse curl command: %w: '%s'", ErrUnsupportedCurlOption, flag)
+		}
+	}
+
+	if rawURL == "" {
+		return opts, fmt.Errorf("failed to parse curl command: missing url")
+	}
+	if !strings.Contains(rawURL, "://") {
+		// curl defaults to http when the scheme is not specified
+		rawURL = "http://" + rawURL
+	}
+	if opts.URL, err = url.Parse(rawURL); err != nil {
+		return opts, fmt.Errorf("failed to parse curl command: invalid url '%s': %w", rawURL, err)
+	}
+	if len(data) > 0 {
+		if opts.Multipart != nil {
+			return opts, fmt.Errorf("failed to parse curl command: %w: data cannot be combined with forms", ErrUnsupportedCurlOption)
+		}
+		opts.Body = []byte(strings.Join(data, "&"))
+		if headers.Get("Content-Type") == "" {
+			headers.Set("Content-Type", DefaultFormContentType)
+		}
+	}
+	if opts.Method == "" && (len(data) > 0 || opts.BodyFile != "" || opts.Multipart != nil) {
+		opts.Method = http.MethodPost
+	}
+	if len(headers) > 0 {
+		opts.Headers = headers
+	}
+	opts.DisableRedirects = !followRedirects
+	if isInsecure || len(caCertificatePaths) > 0 {
+		if opts.Client, err = NewHTTPClient(NewHTTPClientOpts{
+			CACertificatePaths: caCertificatePaths,
+			InsecureSkipVerify: isInsecure,
+		}); err != nil {
+			return opts, fmt.Errorf("failed to parse curl command: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// parseCurlData returns the data of a --data flag in the form used by
+// the body, data from files are returned as "@<path>" for
+// --data-binary and are read otherwise
+func parseCurlData(flag, datum string) (string, error) {
+	switch flag {
+	case "--data-raw":
+		return datum, nil
+	case "--data-urlencode":
+		name, content, hasName := strings.Cut(datum, "=")
+		if !hasName {
+			return url.QueryEscape(datum), nil
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	case "--data-binary":
+		return datum, nil
+	}
+	if !strings.HasPrefix(datum, "@") {
+		return datum, nil
+	}
+	// curl removes carriage returns and newlines from files read by
+	// --data and --data-ascii
+	filePath := datum[1:]
+	/* #nosec - this is required to read the data file */
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read data file at '%s': %w", filePath, err)
+	}
+	return strings.NewReplacer("\r", "", "\n", "").Replace(string(content)), nil
+}
+
+// parseCurlFormField adds the value of a --form flag to `form`
+func parseCurlFormField(form *MultipartForm, field string, isString bool) error {
+	name, value, ok := strings.Cut(field, "=")
+	if !ok {
+		return fmt.Errorf("failed to parse curl command: %w: form field '%s' has no value", ErrUnsupportedCurlOption, field)
+	}
+	if isString || !strings.HasPrefix(value, "@") {
+		if strings.HasPrefix(value, "<") && !isString {
+			return fmt.Errorf("failed to parse curl command: %w: reading form fields from files is not supported", ErrUnsupportedCurlOption)
+		}
+		form.Fields[name] = append(form.Fields[name], value)
+		return nil
+	}
+	attributes := strings.Split(value[1:], ";")
+	file := MultipartFile{FieldName: name, Path: attributes[0]}
+	for _, attribute := range attributes[1:] {
+		key, attributeValue, _ := strings.Cut(attribute, "=")
+		switch key {
+		case "filename":
+			file.FileName = attributeValue
+		case "type":
+			file.ContentType = attributeValue
+		default:
+			return fmt.Errorf("failed to parse curl command: %w: form attribute '%s'", ErrUnsupportedCurlOption, key)
+		}
+	}
+	if file.Path == "-" {
+		return fmt.Errorf("failed to parse curl command: %w: reading form files from stdin", ErrUnsupportedCurlOption)
+	}
+	form.Files = append(form.Files, file)
+	return nil
+}
+
+// splitShellArguments splits a command into arguments in the same way
+// as a POSIX shell without expanding variables, escaped newlines are
+// treated as whitespace so that multi-line commands can be used
+func splitShellArguments(command string) ([]string, error) {
+	arguments := []string{}
+	var current strings.Builder
+	inArgument := false
+	runes := []rune(command)
+	for index := 0; index < len(runes); index++ {
+		character := runes[index]
+		switch {
+		case character == '\\':
+			if index+1 >= len(runes) {
+				return nil, fmt.Errorf("unterminated escape at the end of the command")
+			}
+			index++
+			if runes[index] == '\n' {
+				continue
+			}
+			current.WriteRune(runes[index])
+			inArgument = true
+		case character == '\'':
+			end := strings.IndexRune(string(runes[index+1:]), '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			quoted := []rune(string(runes[index+1:])[:end])
+			current.WriteString(string(quoted))
+			index += len(quoted) + 1
+			inArgument = true
+		case character == '"':
+			index++
+			for ; index < len(runes) && runes[index] != '"'; index++ {
+				if runes[index] == '\\' && index+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[index+1]) {
+					index++
+					if runes[index] == '\n' {
+						continue
+					}
+				}
+				current.WriteRune(runes[index])
+			}
+			if index >= len(runes) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inArgument = true
+		case character == ' ' || character == '\t' || character == '\n' || character == '\r':
+			if inArgument {
+				arguments = append(arguments, current.String())
+				current.Reset()
+				inArgument = false
+			}
+		default:
+			current.WriteRune(character)
+			inArgument = true
+		}
+	}
+	if inArgument {
+		arguments = append(arguments, current.String())
+	}
+	return arguments, nil
+}
@@ -0,0 +1,338 @@
+package devops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultDownloadCacheDirectoryName = "go-devops/downloads"
+	DefaultDownloadCacheMetaExtension = ".json"
+)
+
+// DownloadCacheOpts presents configuration for caching downloads made
+// by the DownloadFile method
+type DownloadCacheOpts struct {
+	// Directory defines the directory to store cached files in
+	//
+	// Defaults to DefaultDownloadCacheDirectoryName in the user's cache
+	// directory (see os.UserCacheDir) if not specified
+	Directory string
+
+	// MaxAge defines the duration for which a cached file is used
+	// without revalidating it with the server. If left as 0, cached
+	// files are always revalidated using the If-None-Match and
+	// If-Modified-Since headers
+	MaxAge time.Duration
+
+	// Offline when set to true uses cached files without contacting
+	// the server regardless of their age, ErrCacheMiss is returned if
+	// the file is not in the cache
+	Offline bool
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *DownloadCacheOpts) SetDefaults() {
+	if o.Directory == "" {
+		o.Directory = getDefaultDownloadCacheDirectory()
+	}
+}
+
+// Validate verifies that this object instance is usable
+func (o DownloadCacheOpts) Validate() error {
+	errors := []string{}
+
+	if o.Directory == "" {
+		errors = append(errors, "missing cache directory")
+	}
+	if o.MaxAge < 0 {
+		errors = append(errors, "max age cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// getDefaultDownloadCacheDirectory returns the default cache directory
+// or an empty string if the user's cache directory cannot be found
+func getDefaultDownloadCacheDirectory() string {
+	userCacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(userCacheDirectory, DefaultDownloadCacheDirectoryName)
+}
+
+// downloadCacheEntry holds the metadata of a cached file
+type downloadCacheEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	LastUsedAt   time.Time `json:"lastUsedAt"`
+	Size         int64     `json:"size"`
+	StoredAt     time.Time `json:"storedAt"`
+
+	// URL is the URL the file was downloaded from with sensitive
+	// values redacted, entries are keyed by a hash of the full URL
+	URL string `json:"url"`
+
+	// contentPath is the path to the cached file
+	contentPath string
+}
+
+// getDownloadCachePaths returns the paths of the cached file and its
+// metadata for the provided URL
+func getDownloadCachePaths(directory, fromURL string) (string, string) {
+	hash := sha256.Sum256([]byte(fromURL))
+	contentPath := filepath.Join(directory, hex.EncodeToString(hash[:]))
+	return contentPath, contentPath + DefaultDownloadCacheMetaExtension
+}
+
+// readDownloadCacheEntry returns the cache entry at the provided
+// metadata path, nil is returned if the entry is missing or incomplete
+func readDownloadCacheEntry(metaPath string) *downloadCacheEntry {
+	/* #nosec - this is required to read the cache */
+	content, err := ioutil.ReadFile(metaPath)
+	if err != nil {
+		return nil
+	}
+	var entry downloadCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil
+	}
+	entry.contentPath = strings.TrimSuffix(metaPath, DefaultDownloadCacheMetaExtension)
+	if fileInfo, err := os.Stat(entry.contentPath); err != nil || fileInfo.Size() != entry.Size {
+		return nil
+	}
+	return &entry
+}
+
+// Write writes the metadata of the cache entry atomically
+func (e downloadCacheEntry) Write() error {
+	content, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to serialise cache metadata: %w", err)
+	}
+	return writeFileAtomically(e.contentPath+DefaultDownloadCacheMetaExtension, content, 0600)
+}
+
+// Remove removes the cached file and its metadata
+func (e downloadCacheEntry) Remove() error {
+	for _, filePath := range []string{e.contentPath + DefaultDownloadCacheMetaExtension, e.contentPath} {
+		if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove '%s': %w", filePath, err)
+		}
+	}
+	return nil
+}
+
+// downloadFileCached serves the download from the cache directory if
+// the cached file is fresh or if the server responds with 304 Not
+// Modified, otherwise the file is downloaded into the cache and then
+// copied to the destination
+func downloadFileCached(opts DownloadFileOpts, fileDestination string) error {
+	cache := *opts.Cache
+	cache.SetDefaults()
+	if err := os.MkdirAll(cache.Directory, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory at '%s': %w", cache.Directory, err)
+	}
+	contentPath, metaPath := getDownloadCachePaths(cache.Directory, opts.URL.String())
+	entry := readDownloadCacheEntry(metaPath)
+
+	if entry != nil && (cache.Offline || time.Since(entry.StoredAt) < cache.MaxAge) {
+		return copyFromDownloadCache(opts, *entry, fileDestination)
+	}
+	if cache.Offline {
+		return fmt.Errorf("%w for '%s' in '%s'", ErrCacheMiss, opts.URL.String(), cache.Directory)
+	}
+
+	headers := opts.getHeaders()
+	if entry != nil {
+		if entry.ETag != "" {
+			headers.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			headers.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	res, err := sendDownloadRequest(opts, headers)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && entry != nil {
+		entry.StoredAt = time.Now()
+		if err := entry.Write(); err != nil {
+			return err
+		}
+		return copyFromDownloadCache(opts, *entry, fileDestination)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to download from '%s': %w (%s)", opts.URL.String(), ErrUnexpectedStatusCode, res.Status)
+	}
+	if strings.Contains(strings.ToLower(res.Header.Get("Cache-Control")), "no-store") {
+		return opts.writeFile(fileDestination, opts.getBody(res, 0), res.Header.Get("Last-Modified"))
+	}
+
+	// a unique temporary file is used since the same URL may be
+	// downloaded concurrently
+	temporaryPath, err := writeTemporaryFile(contentPath, opts.getBody(res, 0), 0600)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := os.Stat(temporaryPath)
+	if err != nil {
+		os.Remove(temporaryPath)
+		return fmt.Errorf("failed to access '%s': %w", temporaryPath, err)
+	}
+	if err := os.Rename(temporaryPath, contentPath); err != nil {
+		os.Remove(temporaryPath)
+		return fmt.Errorf("failed to move '%s' to '%s': %w", temporaryPath, contentPath, err)
+	}
+	now := time.Now()
+	entry = &downloadCacheEntry{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Size:         fileInfo.Size(),
+		StoredAt:     now,
+		URL:          redactURL(opts.URL),
+		contentPath:  contentPath,
+	}
+	if err := entry.Write(); err != nil {
+		return err
+	}
+	return copyFromDownloadCache(opts, *entry, fileDestination)
+}
+
+// copyFromDownloadCache copies the cached file to the destination and
+// updates the time it was last used
+func copyFromDownloadCache(opts DownloadFileOpts, entry downloadCacheEntry, fileDestination string) error {
+	/* #nosec - this is required to read the cache */
+	fileHandle, err := os.Open(entry.contentPath)
+	if err != nil {
+		return fmt.Errorf("failed to open cached file at '%s': %w", entry.contentPath, err)
+	}
+	defer fileHandle.Close()
+	if err := opts.writeFile(fileDestination, fileHandle, entry.LastModified); err != nil {
+		return err
+	}
+	entry.LastUsedAt = time.Now()
+	return entry.Write()
+}
+
+// removeFromDownloadCache removes the cached file for the provided URL
+func removeFromDownloadCache(cache DownloadCacheOpts, fromURL string) error {
+	cache.SetDefaults()
+	contentPath, _ := getDownloadCachePaths(cache.Directory, fromURL)
+	return downloadCacheEntry{contentPath: contentPath}.Remove()
+}
+
+// PruneDownloadCacheOpts presents configuration for the
+// PruneDownloadCache method
+type PruneDownloadCacheOpts struct {
+	// Directory defines the cache directory to prune
+	//
+	// Defaults to DefaultDownloadCacheDirectoryName in the user's cache
+	// directory (see os.UserCacheDir) if not specified
+	Directory string
+
+	// MaxAge when specified removes cached files which have not been
+	// used for longer than this duration
+	MaxAge time.Duration
+
+	// MaxSize when specified removes the least recently used cached
+	// files until the total size of the cache in bytes is at most
+	// this value
+	MaxSize int64
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *PruneDownloadCacheOpts) SetDefaults() {
+	if o.Directory == "" {
+		o.Directory = getDefaultDownloadCacheDirectory()
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the PruneDownloadCache method
+func (o PruneDownloadCacheOpts) Validate() error {
+	errors := []string{}
+
+	if o.Directory == "" {
+		errors = append(errors, "missing cache directory")
+	}
+	if o.MaxAge < 0 {
+		errors = append(errors, "max age cannot be negative")
+	}
+	if o.MaxSize < 0 {
+		errors = append(errors, "max size cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// PruneDownloadCache removes cached files from a cache directory used
+// by DownloadFile as directed by the configuration set in the options
+// object `opts` and returns the URLs of the removed files
+func PruneDownloadCache(opts PruneDownloadCacheOpts) ([]string, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to prune download cache: %w", err)
+	}
+	metaPaths, err := filepath.Glob(filepath.Join(opts.Directory, "*"+DefaultDownloadCacheMetaExtension))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache directory at '%s': %w", opts.Directory, err)
+	}
+	entries := []downloadCacheEntry{}
+	for _, metaPath := range metaPaths {
+		if entry := readDownloadCacheEntry(metaPath); entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	// least recently used entries are pruned first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].getLastUsedAt().Before(entries[j].getLastUsedAt())
+	})
+	var totalSize int64
+	for _, entry := range entries {
+		totalSize += entry.Size
+	}
+
+	pruned := []string{}
+	for _, entry := range entries {
+		isExpired := opts.MaxAge > 0 && time.Since(entry.getLastUsedAt()) > opts.MaxAge
+		isOversized := opts.MaxSize > 0 && totalSize > opts.MaxSize
+		if !isExpired && !isOversized {
+			continue
+		}
+		if err := entry.Remove(); err != nil {
+			return pruned, fmt.Errorf("failed to prune download cache: %w", err)
+		}
+		totalSize -= entry.Size
+		pruned = append(pruned, entry.URL)
+	}
+	return pruned, nil
+}
+
+// getLastUsedAt returns the time the entry was last used or stored
+func (e downloadCacheEntry) getLastUsedAt() time.Time {
+	if e.LastUsedAt.After(e.StoredAt) {
+		return e.LastUsedAt
+	}
+	return e.StoredAt
+}
@@ -0,0 +1,183 @@
+package devops
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DownloadCacheTests struct {
+	suite.Suite
+}
+
+func TestDownloadCache(t *testing.T) {
+	suite.Run(t, &DownloadCacheTests{})
+}
+
+func (s DownloadCacheTests) TestDownloadFile_Cache() {
+	content := "cached content"
+	statusCodes := []int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			statusCodes = append(statusCodes, http.StatusNotModified)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		statusCodes = append(statusCodes, http.StatusOK)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL + "/file")
+	s.Nil(err)
+	cache := &DownloadCacheOpts{Directory: s.T().TempDir()}
+	destinationDirectory := s.T().TempDir()
+
+	for _, name := range []string{"first", "second"} {
+		s.Nil(DownloadFile(DownloadFileOpts{
+			Cache:           cache,
+			DestinationPath: path.Join(destinationDirectory, name),
+			URL:             serverURL,
+		}))
+		fileContent, err := ioutil.ReadFile(path.Join(destinationDirectory, name))
+		s.Nil(err)
+		s.Equal(content, string(fileContent))
+	}
+	s.Equal([]int{http.StatusOK, http.StatusNotModified}, statusCodes)
+
+	cache.MaxAge = time.Hour
+	s.Nil(DownloadFile(DownloadFileOpts{
+		Cache:           cache,
+		DestinationPath: path.Join(destinationDirectory, "third"),
+		URL:             serverURL,
+	}))
+	s.Len(statusCodes, 2, "fresh cached files should not be revalidated")
+}
+
+func (s DownloadCacheTests) TestDownloadFile_Cache_concurrent() {
+	content := strings.Repeat("concurrently cached content\n", 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		for i := 0; i < 4; i++ {
+			w.Write([]byte(content[i*len(content)/4 : (i+1)*len(content)/4]))
+			w.(http.Flusher).Flush()
+			time.Sleep(5 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL + "/file")
+	s.Nil(err)
+	cache := &DownloadCacheOpts{Directory: s.T().TempDir()}
+	destinationDirectory := s.T().TempDir()
+
+	var waiter sync.WaitGroup
+	downloadErrors := make([]error, 8)
+	for index := range downloadErrors {
+		waiter.Add(1)
+		go func(index int) {
+			defer waiter.Done()
+			downloadErrors[index] = DownloadFile(DownloadFileOpts{
+				Cache:           cache,
+				DestinationPath: path.Join(destinationDirectory, fmt.Sprint(index)),
+				URL:             serverURL,
+			})
+		}(index)
+	}
+	waiter.Wait()
+	for index, err := range downloadErrors {
+		s.Nil(err)
+		fileContent, err := ioutil.ReadFile(path.Join(destinationDirectory, fmt.Sprint(index)))
+		s.Nil(err)
+		s.Equal(content, string(fileContent))
+	}
+	entries, err := ioutil.ReadDir(cache.Directory)
+	s.Nil(err)
+	s.Len(entries, 2, "only the cached file and its metadata should remain")
+}
+
+func (s DownloadCacheTests) TestDownloadFile_Cache_redactedURL() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Query().Get("token")))
+	}))
+	defer server.Close()
+	cache := &DownloadCacheOpts{Directory: s.T().TempDir(), MaxAge: time.Hour}
+	destinationPath := path.Join(s.T().TempDir(), "file")
+
+	for _, token := range []string{"first-secret", "second-secret"} {
+		serverURL, err := url.Parse(server.URL + "/file?token=" + token)
+		s.Nil(err)
+		s.Nil(DownloadFile(DownloadFileOpts{
+			Cache:           cache,
+			DestinationPath: destinationPath,
+			Overwrite:       true,
+			URL:             serverURL,
+		}))
+		fileContent, err := ioutil.ReadFile(destinationPath)
+		s.Nil(err)
+		s.Equal(token, string(fileContent), "entries should be keyed by the full url")
+	}
+	metaPaths, err := filepath.Glob(filepath.Join(cache.Directory, "*"+DefaultDownloadCacheMetaExtension))
+	s.Nil(err)
+	s.Len(metaPaths, 2)
+	for _, metaPath := range metaPaths {
+		meta, err := ioutil.ReadFile(metaPath)
+		s.Nil(err)
+		s.NotContains(string(meta), "secret")
+	}
+}
+
+func (s DownloadCacheTests) TestDownloadFile_Cache_Offline() {
+	serverURL, err := url.Parse("http://127.0.0.1:1/file")
+	s.Nil(err)
+	err = DownloadFile(DownloadFileOpts{
+		Cache:           &DownloadCacheOpts{Directory: s.T().TempDir(), Offline: true},
+		DestinationPath: path.Join(s.T().TempDir(), "file"),
+		URL:             serverURL,
+	})
+	s.True(errors.Is(err, ErrCacheMiss))
+}
+
+func (s DownloadCacheTests) TestPruneDownloadCache() {
+	directory := s.T().TempDir()
+	now := time.Now()
+	for _, entry := range []downloadCacheEntry{
+		{URL: "https://example.com/old", Size: 10, StoredAt: now.Add(-48 * time.Hour)},
+		{URL: "https://example.com/used", Size: 10, StoredAt: now.Add(-48 * time.Hour), LastUsedAt: now.Add(-2 * time.Hour)},
+		{URL: "https://example.com/new", Size: 10, StoredAt: now.Add(-1 * time.Hour)},
+	} {
+		entry.contentPath, _ = getDownloadCachePaths(directory, entry.URL)
+		s.Nil(ioutil.WriteFile(entry.contentPath, make([]byte, entry.Size), 0600))
+		s.Nil(entry.Write())
+	}
+
+	pruned, err := PruneDownloadCache(PruneDownloadCacheOpts{
+		Directory: directory,
+		MaxAge:    24 * time.Hour,
+	})
+	s.Nil(err)
+	s.Equal([]string{"https://example.com/old"}, pruned)
+
+	pruned, err = PruneDownloadCache(PruneDownloadCacheOpts{
+		Directory: directory,
+		MaxSize:   10,
+	})
+	s.Nil(err)
+	s.Equal([]string{"https://example.com/used"}, pruned, "least recently used files should be pruned first")
+	contentPath, metaPath := getDownloadCachePaths(directory, "https://example.com/used")
+	for _, filePath := range []string{contentPath, metaPath} {
+		_, err = os.Lstat(filePath)
+		s.True(errors.Is(err, os.ErrNotExist))
+	}
+}
@@ -1,24 +1,130 @@
 package devops
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 )
 
+const (
+	DefaultDownloadFileMode          os.FileMode = 0644
+	DefaultDownloadPartExtension                 = ".part"
+	DefaultDownloadPartMetaExtension             = ".part.meta"
+	DefaultDownloadTemporaryPattern              = temporaryFilePattern
+)
+
 // DownloadFileOpts presents configuration for the
 // DownloadFile method
 type DownloadFileOpts struct {
-	BasicAuth       *BasicAuth
-	Client          *http.Client
-	Headers         map[string][]string
+	// Auth can optionally be specified to add credentials to the
+	// requests, see BasicAuth, BearerToken, APIKey and
+	// NewOAuth2ClientCredentials. If left nil, no credentials will
+	// be sent
+	Auth Authenticator
+
+	// BasicAuth defines user credentials for use with the
+	// request. If left nil, basic auth will not be used. Cannot be
+	// used with .Auth
+	BasicAuth *BasicAuth
+
+	// Cache can optionally be specified to store downloaded files in a
+	// cache directory keyed by .URL. Cached files are revalidated with
+	// the server using the If-None-Match and If-Modified-Since headers
+	// and are copied to .DestinationPath if the server responds with
+	// 304 Not Modified. Cannot be used with .Resume or .Segments
+	Cache *DownloadCacheOpts
+
+	// Client defines the HTTP client to use. If left nil,
+	// a new http.Client is used
+	Client *http.Client
+
+	// Context can optionally be specified to cancel the download or
+	// set a deadline for it
+	//
+	// Defaults to context.Background() if not specified
+	Context context.Context
+
+	// CreateParentDirs when set to true creates the parent directories
+	// of .DestinationPath if they do not exist
+	CreateParentDirs bool
+
+	// Headers defines the headers to be sent along with the
+	// request. If left nil, no headers will be sent
+	Headers map[string][]string
+
+	// DestinationPath defines the path to write the file to. The
+	// download is written to a temporary file in the same directory
+	// which is renamed to .DestinationPath only when the download
+	// completes so that a failed download never leaves a partial file
+	// at .DestinationPath
 	DestinationPath string
-	Overwrite       bool
-	URL             *url.URL
+
+	// FileMode defines the permissions of the downloaded file
+	//
+	// Defaults to DefaultDownloadFileMode if not specified
+	FileMode os.FileMode
+
+	// Progress can optionally be specified to receive progress
+	// updates for the download, use .NewProgressBar for a ready-made
+	// progress bar. If left nil, progress will not be reported
+	Progress ProgressReporter
+
+	// Overwrite when set to true allows an existing file at
+	// .DestinationPath to be replaced
+	Overwrite bool
+
+	// PreserveModTime when set to true sets the modification time of
+	// the downloaded file to the Last-Modified header of the response
+	// if it is present
+	PreserveModTime bool
+
+	// Resume when set to true writes the download into a file with
+	// the DefaultDownloadPartExtension extension alongside
+	// .DestinationPath which is renamed to .DestinationPath only when
+	// the download completes. If a previous download was interrupted,
+	// only the remaining bytes are requested if the server supports
+	// range requests and the file has not changed since (verified
+	// using the ETag or Last-Modified headers), otherwise the full
+	// file is downloaded again
+	Resume bool
+
+	// RateLimiter can optionally be specified to limit the rate and
+	// the number of requests in flight to the host of .URL, see
+	// NewRateLimiter. If left nil, requests are not limited
+	RateLimiter *RateLimiter
+
+	// Retry can optionally be specified to retry requests that fail
+	// with connection errors, 429 Too Many Requests or 5xx responses,
+	// see .SendHTTPRequest. If left nil, requests are only attempted
+	// once
+	Retry *RetryPolicy
+
+	// Segments when set to more than 1 downloads the file using this
+	// number of concurrent range requests which are reassembled into
+	// the file at .DestinationPath. A normal download is done instead
+	// if the server does not support range requests or does not
+	// provide an ETag or Last-Modified header. Cannot be used with
+	// .Resume
+	Segments int
+
+	// URL defines the endpoint to download from
+	URL *url.URL
+
+	// Verify can optionally be specified to verify the downloaded
+	// file against a checksum and/or signature using .VerifyFile. The
+	// download is verified before it is moved to .DestinationPath, if
+	// verification fails the download is removed, an existing file at
+	// .DestinationPath is left as it was and a VerificationError is
+	// returned. .ChecksumFileName defaults to the base name of .URL and
+	// .Client defaults to .Client of this object
+	Verify *VerifyFileOpts
 }
 
 // SetDefaults sets defaults for this object instance
@@ -26,6 +132,9 @@ func (o *DownloadFileOpts) SetDefaults() {
 	if o.Client == nil {
 		o.Client = &http.Client{}
 	}
+	if o.FileMode == 0 {
+		o.FileMode = DefaultDownloadFileMode
+	}
 }
 
 // Validate verifies that this object instance is usable
@@ -37,6 +146,24 @@ func (o DownloadFileOpts) Validate() error {
 		errors = append(errors, "missing destination file path")
 	}
 
+	if o.Auth != nil && o.BasicAuth != nil {
+		errors = append(errors, "basic auth cannot be used with auth")
+	}
+
+	if o.FileMode&^os.ModePerm != 0 {
+		errors = append(errors, "file mode can only contain permission bits")
+	}
+
+	if o.Segments < 0 {
+		errors = append(errors, "segments cannot be negative")
+	} else if o.Segments > 1 && o.Resume {
+		errors = append(errors, "segments cannot be used with resume")
+	}
+
+	if o.Cache != nil && (o.Resume || o.Segments > 1) {
+		errors = append(errors, "cache cannot be used with resume or segments")
+	}
+
 	if o.URL == nil {
 		errors = append(errors, "missing url")
 	} else if o.URL.Host == "" {
@@ -44,7 +171,7 @@ func (o DownloadFileOpts) Validate() error {
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to validate options: ['%s']", strings.Join(errors, "', '"))
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
 	}
 
 	return nil
@@ -55,58 +182,271 @@ func (o DownloadFileOpts) Validate() error {
 func DownloadFile(opts DownloadFileOpts) (err error) {
 	opts.SetDefaults()
 	if err := opts.Validate(); err != nil {
-		return fmt.Errorf("failed to download file: %s", err)
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+
+	if opts.Verify != nil {
+		if err := opts.getVerifyFileOpts(opts.DestinationPath).Validate(); err != nil {
+			return fmt.Errorf("failed to download file: %w", err)
+		}
+	}
+
+	if opts.Cache != nil {
+		cache := *opts.Cache
+		cache.SetDefaults()
+		if err := cache.Validate(); err != nil {
+			return fmt.Errorf("failed to download file: %w", err)
+		}
 	}
 
 	fileDestination, err := NormalizeLocalPath(opts.DestinationPath)
 	if err != nil {
-		return fmt.Errorf("failed to normalize path '%s': %s", opts.DestinationPath, err)
+		return fmt.Errorf("failed to normalize path '%s': %w", opts.DestinationPath, err)
+	}
+
+	if opts.CreateParentDirs {
+		if err := os.MkdirAll(filepath.Dir(fileDestination), 0755); err != nil {
+			return fmt.Errorf("failed to create directory at '%s': %w", filepath.Dir(fileDestination), err)
+		}
 	}
 
 	fileInfo, err := os.Lstat(fileDestination)
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("failed to access path '%s': %s", fileDestination, err)
+			return fmt.Errorf("failed to access path '%s': %w", fileDestination, err)
 		}
 	}
 	if err == nil {
 		if fileInfo.IsDir() {
-			return fmt.Errorf("failed to get a file at '%s': it's a directory", fileDestination)
+			return fmt.Errorf("failed to get a file at '%s': %w", fileDestination, ErrIsDirectory)
 		}
 		if !opts.Overwrite {
-			return fmt.Errorf("refusing to overwrite file at '%s' (set .Overwrite to true)", fileDestination)
+			return fmt.Errorf("%w at '%s' (set .Overwrite to true)", ErrRefuseOverwrite, fileDestination)
+		}
+	}
+
+	if opts.Cache != nil {
+		err = downloadFileCached(opts, fileDestination)
+	} else if opts.Resume {
+		err = downloadFileResumable(opts, fileDestination)
+	} else if opts.Segments > 1 {
+		err = downloadFileSegmented(opts, fileDestination)
+	} else {
+		err = downloadFile(opts, fileDestination)
+	}
+	if errors.Is(err, ErrVerificationFailed) && opts.Cache != nil {
+		if removeError := removeFromDownloadCache(*opts.Cache, opts.URL.String()); removeError != nil {
+			return fmt.Errorf("%w (failed to remove cached file: %w)", err, removeError)
+		}
+	}
+	return err
+}
+
+// getVerifyFileOpts returns the options for verifying the downloaded
+// file at `filePath` with defaults taken from this object instance
+func (o DownloadFileOpts) getVerifyFileOpts(filePath string) *VerifyFileOpts {
+	verifyOpts := *o.Verify
+	verifyOpts.Path = filePath
+	if verifyOpts.ChecksumFileName == "" {
+		verifyOpts.ChecksumFileName = path.Base(o.URL.Path)
+	}
+	if verifyOpts.Client == nil {
+		verifyOpts.Client = o.Client
+	}
+	return &verifyOpts
+}
+
+// downloadFile downloads into a temporary file which is renamed to the
+// destination on completion
+func downloadFile(opts DownloadFileOpts, fileDestination string) error {
+	res, err := sendDownloadRequest(opts, opts.Headers)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to download from '%s': %w (%s)", opts.URL.String(), ErrUnexpectedStatusCode, res.Status)
+	}
+
+	return opts.writeFile(fileDestination, opts.getBody(res, 0), res.Header.Get("Last-Modified"))
+}
+
+// writeFile writes the body into a temporary file alongside the
+// destination and renames it to the destination on completion, the
+// temporary file is removed if the write fails
+func (o DownloadFileOpts) writeFile(fileDestination string, body io.Reader, lastModified string) error {
+	temporaryPath, err := writeTemporaryFile(fileDestination, body, 0600)
+	if err != nil {
+		return err
+	}
+	if err := o.finalizeFile(temporaryPath, fileDestination, lastModified); err != nil {
+		os.Remove(temporaryPath)
+		return err
+	}
+	return nil
+}
+
+// finalizeFile verifies the completed download at `completedPath` if
+// .Verify is set, applies .FileMode and .PreserveModTime to it and
+// renames it to the destination
+func (o DownloadFileOpts) finalizeFile(completedPath, fileDestination, lastModified string) error {
+	if o.Verify != nil {
+		if err := VerifyFile(*o.getVerifyFileOpts(completedPath)); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(completedPath, o.FileMode); err != nil {
+		return fmt.Errorf("failed to set permissions of '%s': %w", completedPath, err)
+	}
+	if o.PreserveModTime && lastModified != "" {
+		if modTime, err := http.ParseTime(lastModified); err == nil {
+			if err := os.Chtimes(completedPath, modTime, modTime); err != nil {
+				return fmt.Errorf("failed to set modification time of '%s': %w", completedPath, err)
+			}
+		}
+	}
+	if err := os.Rename(completedPath, fileDestination); err != nil {
+		return fmt.Errorf("failed to move '%s' to '%s': %w", completedPath, fileDestination, err)
+	}
+	return nil
+}
+
+// getHeaders returns a copy of .Headers that can be modified
+func (o DownloadFileOpts) getHeaders() http.Header {
+	headers := http.Header{}
+	for key, values := range o.Headers {
+		headers[key] = append([]string{}, values...)
+	}
+	return headers
+}
+
+// getBody returns the response body wrapped with a progress reader if
+// .Progress is specified
+func (o DownloadFileOpts) getBody(res *http.Response, offset int64) io.Reader {
+	if o.Progress == nil {
+		return res.Body
+	}
+	totalBytes := res.ContentLength
+	if totalBytes >= 0 {
+		totalBytes += offset
+	}
+	return newProgressReader(res.Body, offset, totalBytes, o.Progress)
+}
+
+// downloadFileResumable downloads into a partial file alongside the
+// destination and renames it to the destination on completion
+func downloadFileResumable(opts DownloadFileOpts, fileDestination string) error {
+	partPath := fileDestination + DefaultDownloadPartExtension
+	metaPath := fileDestination + DefaultDownloadPartMetaExtension
+
+	var offset int64
+	if partInfo, err := os.Lstat(partPath); err == nil {
+		offset = partInfo.Size()
+	}
+	headers := opts.getHeaders()
+	meta, _ := readDownloadMeta(metaPath)
+	if offset > 0 && meta != nil && meta.IsResumable(opts.URL) {
+		headers.Set("Range", fmt.Sprintf("bytes=%v-", offset))
+		headers.Set("If-Range", meta.GetValidator())
+	} else {
+		offset = 0
+	}
+
+	res, err := sendDownloadRequest(opts, headers)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	flag := os.O_TRUNC
+	initialBytes := int64(0)
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		if start := parseContentRangeStart(res.Header.Get("Content-Range")); start != offset {
+			return fmt.Errorf("failed to resume download from '%s': requested offset %v but received offset %v", opts.URL.String(), offset, start)
+		}
+		flag = os.O_APPEND
+		initialBytes = offset
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file cannot be resumed, start over
+		if err := os.Remove(partPath); err != nil {
+			return fmt.Errorf("failed to remove partial file at '%s': %w", partPath, err)
 		}
+		return downloadFileResumable(opts, fileDestination)
+	default:
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("failed to download from '%s': %w (%s)", opts.URL.String(), ErrUnexpectedStatusCode, res.Status)
+		}
+		if err := newDownloadMeta(opts.URL, res).Write(metaPath); err != nil {
+			return err
+		}
+	}
+
+	if err := writeDownload(partPath, flag, opts.getBody(res, initialBytes)); err != nil {
+		return fmt.Errorf("%w (partial file at '%s' will be resumed)", err, partPath)
+	}
+	lastModified := res.Header.Get("Last-Modified")
+	if lastModified == "" && meta != nil {
+		lastModified = meta.LastModified
+	}
+	if err := opts.finalizeFile(partPath, fileDestination, lastModified); err != nil {
+		if errors.Is(err, ErrVerificationFailed) {
+			// a partial file which fails verification cannot be resumed
+			os.Remove(partPath)
+			os.Remove(metaPath)
+		}
+		return err
+	}
+	if err := os.Remove(metaPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove '%s': %w", metaPath, err)
 	}
+	return nil
+}
 
+// sendDownloadRequest sends the GET request for the download, a copy
+// of .URL is used since requests may be sent concurrently
+func sendDownloadRequest(opts DownloadFileOpts, headers map[string][]string) (*http.Response, error) {
+	requestURL := *opts.URL
 	res, err := SendHTTPRequest(SendHTTPRequestOpts{
-		BasicAuth: opts.BasicAuth,
-		Headers:   opts.Headers,
-		Method:    http.MethodGet,
-		URL:       opts.URL,
+		Auth:        opts.Auth,
+		BasicAuth:   opts.BasicAuth,
+		Client:      opts.Client,
+		Context:     opts.Context,
+		Headers:     headers,
+		Method:      http.MethodGet,
+		RateLimiter: opts.RateLimiter,
+		Retry:       opts.Retry,
+		URL:         &requestURL,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to make request: %s", err)
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
-	defer res.Body.Close()
+	return res, nil
+}
 
+// writeDownload copies the response body into the file at the provided
+// path which is opened with the provided flag in addition to
+// os.O_WRONLY|os.O_CREATE. New files are only accessible by the current
+// user until they are finalized
+func writeDownload(filePath string, flag int, body io.Reader) (err error) {
 	/* #nosec - this is required to write the file */
-	fileHandle, err := os.OpenFile(fileDestination, os.O_RDWR|os.O_CREATE, os.ModePerm)
+	fileHandle, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|flag, 0600)
 	if err != nil {
-		return fmt.Errorf("failed to open file at '%s': %s", fileDestination, err)
+		return fmt.Errorf("failed to open file at '%s': %w", filePath, err)
 	}
 	defer func() {
 		if e := fileHandle.Close(); e != nil {
-			closeError := fmt.Errorf("failed to close file at '%s': %s", fileDestination, e)
+			closeError := fmt.Errorf("failed to close file at '%s': %w", filePath, e)
 			if err != nil {
-				err = fmt.Errorf("%s (previous error: %s)", closeError.Error(), err.Error())
+				err = fmt.Errorf("%w (previous error: %w)", closeError, err)
 			} else {
 				err = closeError
 			}
 		}
 	}()
-	_, err = io.Copy(fileHandle, res.Body)
+	_, err = io.Copy(fileHandle, body)
 	if err != nil {
-		return fmt.Errorf("failed to write to file at '%s': %s", fileDestination, err)
+		return fmt.Errorf("failed to write to file at '%s': %w", filePath, err)
 	}
 
 	return nil
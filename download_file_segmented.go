@@ -0,0 +1,134 @@
+package devops
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// downloadFileSegmented downloads the file using .Segments concurrent
+// range requests into a temporary file alongside the destination which
+// is renamed to the destination once all segments complete. A normal
+// download is done instead if the server does not support range
+// requests or does not provide a validator to ensure that all segments
+// come from the same version of the file
+func downloadFileSegmented(opts DownloadFileOpts, fileDestination string) error {
+	headers := opts.getHeaders()
+	headers.Set("Range", "bytes=0-0")
+	res, err := sendDownloadRequest(opts, headers)
+	if err != nil {
+		return err
+	}
+	totalBytes := parseContentRangeTotal(res.Header.Get("Content-Range"))
+	lastModified := res.Header.Get("Last-Modified")
+	validator := newDownloadMeta(opts.URL, res).GetValidator()
+	if res.StatusCode == http.StatusRequestedRangeNotSatisfiable && totalBytes == 0 {
+		// empty files have no byte range to probe
+		res.Body.Close()
+		return downloadFile(opts, fileDestination)
+	}
+	if res.StatusCode != http.StatusPartialContent {
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("failed to download from '%s': %w (%s)", opts.URL.String(), ErrUnexpectedStatusCode, res.Status)
+		}
+		// the server ignored the range and is sending the full file
+		return opts.writeFile(fileDestination, opts.getBody(res, 0), res.Header.Get("Last-Modified"))
+	}
+	res.Body.Close()
+	if totalBytes < 0 || validator == "" {
+		return downloadFile(opts, fileDestination)
+	}
+
+	// a unique temporary file is used so that a partial file left by
+	// .Resume is not overwritten
+	fileHandle, err := os.CreateTemp(filepath.Dir(fileDestination), "."+filepath.Base(fileDestination)+DefaultDownloadTemporaryPattern)
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary file for '%s': %w", fileDestination, err)
+	}
+	partPath := fileHandle.Name()
+	if err := fileHandle.Truncate(totalBytes); err != nil {
+		fileHandle.Close()
+		os.Remove(partPath)
+		return fmt.Errorf("failed to allocate %v bytes for '%s': %w", totalBytes, partPath, err)
+	}
+
+	var tracker *progressTracker
+	if opts.Progress != nil {
+		tracker = newProgressTracker(0, totalBytes, opts.Progress)
+	}
+	segmentSize := (totalBytes + int64(opts.Segments) - 1) / int64(opts.Segments)
+	var waiter sync.WaitGroup
+	segmentErrors := make([]error, opts.Segments)
+	for index := 0; index < opts.Segments; index++ {
+		start := int64(index) * segmentSize
+		end := start + segmentSize - 1
+		if end >= totalBytes {
+			end = totalBytes - 1
+		}
+		if start > end {
+			break
+		}
+		waiter.Add(1)
+		go func(index int, start, end int64) {
+			defer waiter.Done()
+			if err := downloadSegment(opts, fileHandle, start, end, validator, tracker); err != nil {
+				segmentErrors[index] = fmt.Errorf("failed to download segment %v (bytes %v-%v): %w", index, start, end, err)
+			}
+		}(index, start, end)
+	}
+	waiter.Wait()
+
+	err = errors.Join(segmentErrors...)
+	if closeError := fileHandle.Close(); closeError != nil && err == nil {
+		err = fmt.Errorf("failed to close file at '%s': %w", partPath, closeError)
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	if tracker != nil {
+		tracker.Done()
+	}
+	if err := opts.finalizeFile(partPath, fileDestination, lastModified); err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	return nil
+}
+
+// downloadSegment downloads the inclusive byte range from `start` to
+// `end` into the same offsets of `fileHandle`
+func downloadSegment(opts DownloadFileOpts, fileHandle *os.File, start, end int64, validator string, tracker *progressTracker) error {
+	headers := opts.getHeaders()
+	headers.Set("Range", fmt.Sprintf("bytes=%v-%v", start, end))
+	headers.Set("If-Range", validator)
+	res, err := sendDownloadRequest(opts, headers)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("file at '%s' changed during the download: %w (%s)", opts.URL.String(), ErrUnexpectedStatusCode, res.Status)
+	}
+	if offset := parseContentRangeStart(res.Header.Get("Content-Range")); offset != start {
+		return fmt.Errorf("requested offset %v but received offset %v", start, offset)
+	}
+	var body io.Reader = res.Body
+	if tracker != nil {
+		body = &progressReader{reader: res.Body, tracker: tracker}
+	}
+	expectedBytes := end - start + 1
+	written, err := io.Copy(io.NewOffsetWriter(fileHandle, start), io.LimitReader(body, expectedBytes))
+	if err != nil {
+		return fmt.Errorf("failed to write to file at '%s': %w", fileHandle.Name(), err)
+	}
+	if written != expectedBytes {
+		return fmt.Errorf("expected %v bytes but received %v bytes", expectedBytes, written)
+	}
+	return nil
+}
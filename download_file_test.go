@@ -2,11 +2,17 @@ package devops
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -103,6 +109,8 @@ func (s DownloadFileTests) TestDownloadFile_badUrlError() {
 	err = DownloadFile(options)
 	s.NotNil(err)
 	s.Contains(err.Error(), "failed to start download")
+	var urlError *url.Error
+	s.True(errors.As(err, &urlError), "underlying *url.Error should be available")
 }
 
 func (s DownloadFileTests) TestDownloadFile_directoryError() {
@@ -114,6 +122,7 @@ func (s DownloadFileTests) TestDownloadFile_directoryError() {
 	}
 	err = DownloadFile(options)
 	s.NotNil(err)
+	s.True(errors.Is(err, ErrIsDirectory))
 	s.Contains(err.Error(), "it's a directory")
 }
 
@@ -126,12 +135,14 @@ func (s DownloadFileTests) TestDownloadFile_overwriteError() {
 	}
 	err = DownloadFile(options)
 	s.NotNil(err)
+	s.True(errors.Is(err, ErrRefuseOverwrite))
 	s.Contains(err.Error(), "refusing to overwrite")
 }
 
 func (s DownloadFileTests) TestDownloadFile_invalidOptions() {
 	options := DownloadFileOpts{}
 	err := DownloadFile(options)
+	s.True(errors.Is(err, ErrInvalidOptions))
 	s.Contains(err.Error(), "missing destination file path")
 	s.Contains(err.Error(), "missing url")
 
@@ -139,3 +150,293 @@ func (s DownloadFileTests) TestDownloadFile_invalidOptions() {
 	err = DownloadFile(options)
 	s.Contains(err.Error(), "missing host")
 }
+
+func (s DownloadFileTests) TestDownloadFile_overwriteTruncates() {
+	content := "short"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	testFilePath := path.Join(s.T().TempDir(), "file")
+	s.Nil(ioutil.WriteFile(testFilePath, []byte("this is a much longer existing file"), 0644))
+
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		Overwrite:       true,
+		URL:             serverURL,
+	})
+	s.Nil(err)
+	fileContent, err := ioutil.ReadFile(testFilePath)
+	s.Nil(err)
+	s.Equal(content, string(fileContent))
+}
+
+func (s DownloadFileTests) TestDownloadFile_unexpectedStatusCode() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: path.Join(s.T().TempDir(), "file"),
+		URL:             serverURL,
+	})
+	s.True(errors.Is(err, ErrUnexpectedStatusCode))
+	s.Contains(err.Error(), "404")
+}
+
+func (s DownloadFileTests) TestDownloadFile_Resume() {
+	content := strings.Repeat("0123456789", 100)
+	etag := `"v1"`
+	failAfter := 300
+	observedRanges := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		observedRanges = append(observedRanges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		if failAfter > 0 {
+			// simulate an interrupted download
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write([]byte(content[:failAfter]))
+			failAfter = 0
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	testFilePath := path.Join(s.T().TempDir(), "file")
+	options := DownloadFileOpts{
+		DestinationPath: testFilePath,
+		Resume:          true,
+		URL:             serverURL,
+	}
+
+	err = DownloadFile(options)
+	s.NotNil(err, "the first download should be interrupted")
+	partContent, err := ioutil.ReadFile(testFilePath + DefaultDownloadPartExtension)
+	s.Nil(err)
+	s.Equal(content[:300], string(partContent))
+	_, err = os.Lstat(testFilePath)
+	s.True(errors.Is(err, os.ErrNotExist), "destination should not exist until the download completes")
+
+	err = DownloadFile(options)
+	s.Nil(err)
+	s.Equal([]string{"", "bytes=300-"}, observedRanges)
+	fileContent, err := ioutil.ReadFile(testFilePath)
+	s.Nil(err)
+	s.Equal(content, string(fileContent))
+	_, err = os.Lstat(testFilePath + DefaultDownloadPartExtension)
+	s.True(errors.Is(err, os.ErrNotExist))
+	_, err = os.Lstat(testFilePath + DefaultDownloadPartMetaExtension)
+	s.True(errors.Is(err, os.ErrNotExist))
+}
+
+func (s DownloadFileTests) TestDownloadFile_Resume_changedFile() {
+	content := strings.Repeat("abcdefghij", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	testFilePath := path.Join(s.T().TempDir(), "file")
+	s.Nil(ioutil.WriteFile(testFilePath+DefaultDownloadPartExtension, []byte("stale content from v1"), 0644))
+	s.Nil(downloadMeta{AcceptRanges: "bytes", ETag: `"v1"`, URL: serverURL.String()}.Write(testFilePath + DefaultDownloadPartMetaExtension))
+
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		Resume:          true,
+		URL:             serverURL,
+	})
+	s.Nil(err)
+	fileContent, err := ioutil.ReadFile(testFilePath)
+	s.Nil(err)
+	s.Equal(content, string(fileContent), "the full file should be downloaded if it changed")
+}
+
+func (s DownloadFileTests) TestDownloadFile_Progress() {
+	content := strings.Repeat("0123456789", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	reports := []Progress{}
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: path.Join(s.T().TempDir(), "file"),
+		Progress: ProgressReporterFunc(func(progress Progress) {
+			reports = append(reports, progress)
+		}),
+		URL: serverURL,
+	})
+	s.Nil(err)
+	s.NotEmpty(reports)
+	last := reports[len(reports)-1]
+	s.True(last.Done)
+	s.Equal(int64(len(content)), last.BytesTransferred)
+	s.Equal(int64(len(content)), last.TotalBytes)
+}
+
+func (s DownloadFileTests) TestDownloadFile_Segments() {
+	content := strings.Repeat("0123456789", 1000)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	testDirectory := s.T().TempDir()
+	testFilePath := path.Join(testDirectory, "file")
+	partPath := testFilePath + DefaultDownloadPartExtension
+	s.Nil(ioutil.WriteFile(partPath, []byte("partial"), 0600))
+	reports := []Progress{}
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		Progress: ProgressReporterFunc(func(progress Progress) {
+			reports = append(reports, progress)
+		}),
+		Segments: 3,
+		URL:      serverURL,
+	})
+	s.Nil(err)
+	s.Equal(int32(4), atomic.LoadInt32(&requests), "1 probe and 3 segments should be requested")
+	fileContent, err := ioutil.ReadFile(testFilePath)
+	s.Nil(err)
+	s.Equal(content, string(fileContent))
+	partContent, err := ioutil.ReadFile(partPath)
+	s.Nil(err)
+	s.Equal("partial", string(partContent), "partial files of resumable downloads should not be modified")
+	entries, err := ioutil.ReadDir(testDirectory)
+	s.Nil(err)
+	s.Len(entries, 2, "no temporary files should remain")
+	last := reports[len(reports)-1]
+	s.True(last.Done)
+	s.Equal(int64(len(content)), last.BytesTransferred)
+}
+
+func (s DownloadFileTests) TestDownloadFile_Segments_noRangeSupport() {
+	content := strings.Repeat("0123456789", 1000)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	testFilePath := path.Join(s.T().TempDir(), "file")
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		Segments:        3,
+		URL:             serverURL,
+	})
+	s.Nil(err)
+	s.Equal(int32(1), atomic.LoadInt32(&requests), "the full response to the probe should be used")
+	fileContent, err := ioutil.ReadFile(testFilePath)
+	s.Nil(err)
+	s.Equal(content, string(fileContent))
+}
+
+func (s DownloadFileTests) TestDownloadFile_Segments_emptyFile() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") != "" {
+			// no range of an empty file is satisfiable
+			w.Header().Set("Content-Range", "bytes */0")
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	testFilePath := path.Join(s.T().TempDir(), "file")
+	s.Nil(DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		Segments:        3,
+		URL:             serverURL,
+	}))
+	fileInfo, err := os.Stat(testFilePath)
+	s.Nil(err)
+	s.Equal(int64(0), fileInfo.Size())
+}
+
+func (s DownloadFileTests) TestDownloadFile_fileSemantics() {
+	lastModified := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	testFilePath := path.Join(s.T().TempDir(), "parent", "directory", "file")
+
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		URL:             serverURL,
+	})
+	s.NotNil(err, "parent directories should not be created by default")
+
+	err = DownloadFile(DownloadFileOpts{
+		CreateParentDirs: true,
+		DestinationPath:  testFilePath,
+		PreserveModTime:  true,
+		URL:              serverURL,
+	})
+	s.Nil(err)
+	fileInfo, err := os.Stat(testFilePath)
+	s.Nil(err)
+	s.Equal(DefaultDownloadFileMode, fileInfo.Mode().Perm())
+	s.True(lastModified.Equal(fileInfo.ModTime()))
+
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		FileMode:        0755,
+		Overwrite:       true,
+		URL:             serverURL,
+	})
+	s.Nil(err)
+	fileInfo, err = os.Stat(testFilePath)
+	s.Nil(err)
+	s.Equal(os.FileMode(0755), fileInfo.Mode().Perm())
+}
+
+func (s DownloadFileTests) TestDownloadFile_failureKeepsExistingFile() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// simulate an interrupted download
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("partial"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	directory := s.T().TempDir()
+	testFilePath := path.Join(directory, "file")
+	s.Nil(ioutil.WriteFile(testFilePath, []byte("existing"), 0644))
+
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: testFilePath,
+		Overwrite:       true,
+		URL:             serverURL,
+	})
+	s.NotNil(err)
+	fileContent, err := ioutil.ReadFile(testFilePath)
+	s.Nil(err)
+	s.Equal("existing", string(fileContent), "a failed download should not replace the existing file")
+	entries, err := os.ReadDir(directory)
+	s.Nil(err)
+	s.Len(entries, 1, "temporary files should be removed")
+}
@@ -0,0 +1,188 @@
+package devops
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultDownloadFilesConcurrency = 4
+	DefaultDownloadFilesMaxPerHost  = 2
+)
+
+// DownloadFilesErrors is returned by DownloadFiles when one or more
+// downloads fail
+type DownloadFilesErrors []DownloadFilesError
+
+// Error implements the error interface
+func (e DownloadFilesErrors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("failed to download %v file(s): ['%s']", len(e), strings.Join(messages, "', '"))
+}
+
+// Unwrap returns the individual DownloadFilesError instances so
+// that `errors.Is` and `errors.As` can be used on the collection
+func (e DownloadFilesErrors) Unwrap() []error {
+	errs := []error{}
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// DownloadFilesError describes the failure of a single download
+type DownloadFilesError struct {
+	// DestinationPath is the .DestinationPath of the failed download
+	DestinationPath string
+
+	// Index is the index of the failed download in .Downloads
+	Index int
+
+	// URL is the .URL of the failed download
+	URL string
+
+	// Err is the error returned by DownloadFile
+	Err error
+}
+
+// Error implements the error interface
+func (e DownloadFilesError) Error() string {
+	return fmt.Sprintf("failed to download '%s' to '%s': %s", e.URL, e.DestinationPath, e.Err)
+}
+
+// Unwrap returns the error returned by DownloadFile
+func (e DownloadFilesError) Unwrap() error {
+	return e.Err
+}
+
+// DownloadFilesOpts presents configuration for the
+// DownloadFiles method
+type DownloadFilesOpts struct {
+	// Client defines the HTTP client to use for downloads which do
+	// not specify their own .Client. If left nil, a new http.Client
+	// is used
+	Client *http.Client
+
+	// Concurrency defines the maximum number of files to download
+	// at the same time
+	//
+	// Defaults to DefaultDownloadFilesConcurrency if not specified
+	Concurrency int
+
+	// Downloads defines the files to download
+	Downloads []DownloadFileOpts
+
+	// MaxPerHost defines the maximum number of files to download
+	// from the same host at the same time. Note that each download
+	// with .Segments uses up to .Segments connections
+	//
+	// Defaults to DefaultDownloadFilesMaxPerHost if not specified
+	MaxPerHost int
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *DownloadFilesOpts) SetDefaults() {
+	if o.Client == nil {
+		o.Client = &http.Client{}
+	}
+	if o.Concurrency == 0 {
+		o.Concurrency = DefaultDownloadFilesConcurrency
+	}
+	if o.MaxPerHost == 0 {
+		o.MaxPerHost = DefaultDownloadFilesMaxPerHost
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the DownloadFiles method
+func (o DownloadFilesOpts) Validate() error {
+	errors := []string{}
+
+	if o.Concurrency < 0 {
+		errors = append(errors, "concurrency cannot be negative")
+	}
+	if o.MaxPerHost < 0 {
+		errors = append(errors, "max per host cannot be negative")
+	}
+
+	destinations := map[string]int{}
+	for index, download := range o.Downloads {
+		if err := download.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("download %v is invalid (%s)", index, err))
+			continue
+		}
+		if previousIndex, ok := destinations[download.DestinationPath]; ok {
+			errors = append(errors, fmt.Sprintf("downloads %v and %v have the same destination '%s'", previousIndex, index, download.DestinationPath))
+		}
+		destinations[download.DestinationPath] = index
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// DownloadFiles downloads all files in .Downloads using DownloadFile
+// with at most .Concurrency downloads in progress at any time and at
+// most .MaxPerHost downloads from the same host. All downloads are
+// attempted even if some fail, failed downloads are returned as
+// DownloadFilesErrors
+func DownloadFiles(opts DownloadFilesOpts) error {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("failed to download files: %w", err)
+	}
+
+	concurrencyLimit := make(chan struct{}, opts.Concurrency)
+	hostLimits := map[string]chan struct{}{}
+	for _, download := range opts.Downloads {
+		if _, ok := hostLimits[download.URL.Host]; !ok {
+			hostLimits[download.URL.Host] = make(chan struct{}, opts.MaxPerHost)
+		}
+	}
+
+	var waiter sync.WaitGroup
+	var mutex sync.Mutex
+	errors := DownloadFilesErrors{}
+	for index, download := range opts.Downloads {
+		if download.Client == nil {
+			download.Client = opts.Client
+		}
+		waiter.Add(1)
+		go func(index int, download DownloadFileOpts) {
+			defer waiter.Done()
+			// the host limit is acquired first so that downloads waiting
+			// on a busy host do not hold on to a concurrency slot
+			hostLimit := hostLimits[download.URL.Host]
+			hostLimit <- struct{}{}
+			defer func() { <-hostLimit }()
+			concurrencyLimit <- struct{}{}
+			defer func() { <-concurrencyLimit }()
+
+			if err := DownloadFile(download); err != nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+				errors = append(errors, DownloadFilesError{
+					DestinationPath: download.DestinationPath,
+					Index:           index,
+					URL:             download.URL.String(),
+					Err:             err,
+				})
+			}
+		}(index, download)
+	}
+	waiter.Wait()
+
+	if len(errors) > 0 {
+		sort.Slice(errors, func(i, j int) bool { return errors[i].Index < errors[j].Index })
+		return errors
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package devops
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DownloadFilesTests struct {
+	suite.Suite
+}
+
+func TestDownloadFiles(t *testing.T) {
+	suite.Run(t, &DownloadFilesTests{})
+}
+
+func (s DownloadFilesTests) TestDownloadFiles() {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+	directory := s.T().TempDir()
+	downloads := []DownloadFileOpts{}
+	for _, name := range []string{"a", "b", "missing", "c", "d", "e"} {
+		fileURL, err := url.Parse(server.URL + "/" + name)
+		s.Nil(err)
+		downloads = append(downloads, DownloadFileOpts{
+			DestinationPath: path.Join(directory, name),
+			URL:             fileURL,
+		})
+	}
+
+	err := DownloadFiles(DownloadFilesOpts{
+		Concurrency: 4,
+		Downloads:   downloads,
+		MaxPerHost:  2,
+	})
+	var downloadErrors DownloadFilesErrors
+	s.True(errors.As(err, &downloadErrors))
+	s.Len(downloadErrors, 1)
+	s.Equal(2, downloadErrors[0].Index)
+	s.True(errors.Is(err, ErrUnexpectedStatusCode))
+	s.LessOrEqual(atomic.LoadInt32(&maxInFlight), int32(2), "downloads from the same host should be capped")
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		content, err := ioutil.ReadFile(path.Join(directory, name))
+		s.Nil(err)
+		s.Equal("/"+name, string(content))
+	}
+}
+
+func (s DownloadFilesTests) TestDownloadFilesOpts_Validate() {
+	fileURL, err := url.Parse("https://example.com/file")
+	s.Nil(err)
+	err = DownloadFilesOpts{
+		Concurrency: -1,
+		Downloads: []DownloadFileOpts{
+			{DestinationPath: "./file", URL: fileURL},
+			{DestinationPath: "./file", URL: fileURL},
+			{URL: fileURL},
+		},
+	}.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "concurrency cannot be negative")
+	s.Contains(err.Error(), "downloads 0 and 1 have the same destination")
+	s.Contains(err.Error(), fmt.Sprintf("download %v is invalid", 2))
+}
@@ -0,0 +1,103 @@
+package devops
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// downloadMeta holds the response metadata required to resume or
+// revalidate a download
+type downloadMeta struct {
+	AcceptRanges string `json:"acceptRanges,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+
+	// URL is the URL the file was downloaded from with sensitive
+	// values redacted
+	URL string `json:"url"`
+}
+
+func newDownloadMeta(from *url.URL, res *http.Response) downloadMeta {
+	return downloadMeta{
+		AcceptRanges: res.Header.Get("Accept-Ranges"),
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		URL:          redactURL(from),
+	}
+}
+
+func readDownloadMeta(filePath string) (*downloadMeta, error) {
+	/* #nosec - this is required to read the metadata */
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download metadata at '%s': %w", filePath, err)
+	}
+	var meta downloadMeta
+	if err := json.Unmarshal(content, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse download metadata at '%s': %w", filePath, err)
+	}
+	return &meta, nil
+}
+
+// GetValidator returns the value to use in an If-Range header, strong
+// ETags are preferred over Last-Modified dates
+func (m downloadMeta) GetValidator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// IsResumable returns true if the server advertised support for byte
+// range requests for the same URL and provided a validator
+func (m downloadMeta) IsResumable(from *url.URL) bool {
+	return m.URL == redactURL(from) && m.AcceptRanges == "bytes" && m.GetValidator() != ""
+}
+
+func (m downloadMeta) Write(filePath string) error {
+	content, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to serialise download metadata: %w", err)
+	}
+	if err := ioutil.WriteFile(filePath, content, 0600); err != nil {
+		return fmt.Errorf("failed to write download metadata at '%s': %w", filePath, err)
+	}
+	return nil
+}
+
+// parseContentRangeStart returns the starting offset of a Content-Range
+// header value like 'bytes 100-199/200', returns -1 if it's invalid
+func parseContentRangeStart(contentRange string) int64 {
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return -1
+	}
+	byteRange := strings.SplitN(strings.TrimPrefix(contentRange, "bytes "), "-", 2)
+	start, err := strconv.ParseInt(byteRange[0], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
+
+// parseContentRangeTotal returns the total size of a Content-Range
+// header value like 'bytes 0-0/200', returns -1 if it's invalid or
+// unknown
+func parseContentRangeTotal(contentRange string) int64 {
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return -1
+	}
+	byteRange := strings.SplitN(contentRange, "/", 2)
+	if len(byteRange) != 2 {
+		return -1
+	}
+	total, err := strconv.ParseInt(byteRange[1], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
@@ -0,0 +1,42 @@
+package devops
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DownloadMetaTests struct {
+	suite.Suite
+}
+
+func TestDownloadMeta(t *testing.T) {
+	suite.Run(t, &DownloadMetaTests{})
+}
+
+func (s DownloadMetaTests) Test_downloadMeta_IsResumable() {
+	from, err := url.Parse("https://example.com/file")
+	s.Nil(err)
+	other, err := url.Parse("https://example.com/other")
+	s.Nil(err)
+	s.True(downloadMeta{AcceptRanges: "bytes", ETag: `"a"`, URL: from.String()}.IsResumable(from))
+	s.True(downloadMeta{AcceptRanges: "bytes", LastModified: "Mon, 02 Jan 2006 15:04:05 GMT", URL: from.String()}.IsResumable(from))
+	s.False(downloadMeta{AcceptRanges: "bytes", ETag: `"a"`, URL: from.String()}.IsResumable(other))
+	s.False(downloadMeta{AcceptRanges: "none", ETag: `"a"`, URL: from.String()}.IsResumable(from))
+	s.False(downloadMeta{AcceptRanges: "bytes", ETag: `W/"a"`, URL: from.String()}.IsResumable(from), "weak etags cannot be used with If-Range")
+
+	withToken, err := url.Parse("https://example.com/file?token=secret")
+	s.Nil(err)
+	meta := newDownloadMeta(withToken, &http.Response{Header: http.Header{"Accept-Ranges": {"bytes"}, "Etag": {`"a"`}}})
+	s.NotContains(meta.URL, "secret")
+	s.True(meta.IsResumable(withToken))
+	s.False(meta.IsResumable(from))
+}
+
+func (s DownloadMetaTests) Test_parseContentRangeStart() {
+	s.Equal(int64(100), parseContentRangeStart("bytes 100-199/200"))
+	s.Equal(int64(-1), parseContentRangeStart("bytes */200"))
+	s.Equal(int64(-1), parseContentRangeStart(""))
+}
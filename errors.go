@@ -0,0 +1,111 @@
+package devops
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by functions in this package,
+// use `errors.Is` to check for them
+var (
+	// ErrInvalidOptions is returned when an options object fails
+	// its .Validate() checks
+	ErrInvalidOptions = errors.New("failed to validate options")
+
+	// ErrBinaryNotFound is returned when a binary cannot be
+	// found in the $PATH
+	ErrBinaryNotFound = errors.New("failed to find binary")
+
+	// ErrIsDirectory is returned when a file was expected but
+	// a directory was found instead
+	ErrIsDirectory = errors.New("it's a directory")
+
+	// ErrRefuseOverwrite is returned when a file exists at a
+	// destination path and overwriting was not requested
+	ErrRefuseOverwrite = errors.New("refusing to overwrite file")
+
+	// ErrPassphraseRequired is returned when a private key is
+	// passphrase-protected but no passphrase was provided
+	ErrPassphraseRequired = errors.New("failed to provide a required passphrase")
+
+	// ErrConfigPrereqs is matched by LoadConfigurationError
+	// instances with the ErrorLoadConfigurationPrereqs code
+	ErrConfigPrereqs = errors.New("invalid configuration target")
+
+	// ErrConfigNotFound is matched by LoadConfigurationError
+	// instances with the ErrorLoadConfigurationNotFound code
+	ErrConfigNotFound = errors.New("configuration value not found")
+
+	// ErrConfigInvalidType is matched by LoadConfigurationError
+	// instances with the ErrorLoadConfigurationInvalidType code
+	ErrConfigInvalidType = errors.New("unsupported configuration type")
+
+	// ErrConfigInvalidValue is matched by LoadConfigurationError
+	// instances with the ErrorLoadConfigurationInvalidValue code
+	ErrConfigInvalidValue = errors.New("invalid configuration value")
+
+	// ErrApplicationNotFound is matched by errors from
+	// ValidateApplications for each application not found
+	ErrApplicationNotFound = errors.New("application not found")
+
+	// ErrEnvironmentKeyMissing is matched by ValidateEnvironmentError
+	// instances for keys that are not defined
+	ErrEnvironmentKeyMissing = errors.New("environment key not defined")
+
+	// ErrEnvironmentKeyInvalid is matched by ValidateEnvironmentError
+	// instances for keys whose values do not match the expected type
+	ErrEnvironmentKeyInvalid = errors.New("environment key has an invalid value")
+
+	// ErrInvalidSelection is returned when the user input for
+	// .Select or .MultiSelect does not match any option
+	ErrInvalidSelection = errors.New("failed to match a valid selection")
+
+	// ErrInterrupted is returned when the user cancels an
+	// interactive prompt using Ctrl+C
+	ErrInterrupted = errors.New("interrupted by user")
+
+	// ErrInvalidInput is returned when the user fails to provide
+	// a valid input within the allowed number of attempts
+	ErrInvalidInput = errors.New("failed to receive valid input")
+
+	// ErrNoInput is returned when the input stream ends before the
+	// user provides any input
+	ErrNoInput = errors.New("failed to receive any input")
+
+	// ErrNonInteractive is returned when a prompt is triggered in
+	// a non-interactive session and the policy is to error out
+	ErrNonInteractive = errors.New("refusing to prompt in a non-interactive session")
+
+	// ErrUnexpectedStatusCode is returned when a HTTP response has
+	// a status code that was not expected
+	ErrUnexpectedStatusCode = errors.New("received an unexpected status code")
+
+	// ErrVerificationFailed is matched by VerificationError instances
+	// returned when a file does not match its checksum or signature
+	ErrVerificationFailed = errors.New("failed to verify file")
+
+	// ErrUnsafeArchivePath is returned when an archive contains an
+	// entry that would be written outside of the destination
+	ErrUnsafeArchivePath = errors.New("refusing to extract outside of the destination")
+
+	// ErrCacheMiss is returned when a file is not in the download
+	// cache in offline mode
+	ErrCacheMiss = errors.New("failed to find a cached copy")
+
+	// ErrAuthenticationFailed is returned when an Authenticator
+	// cannot apply credentials to a request
+	ErrAuthenticationFailed = errors.New("failed to authenticate")
+
+	// ErrUnsupportedCurlOption is returned when a curl command
+	// uses an option that cannot be converted
+	ErrUnsupportedCurlOption = errors.New("unsupported curl option")
+
+	// ErrNoRecordedResponse is returned by HTTPReplayer when a
+	// request does not match any recorded request
+	ErrNoRecordedResponse = errors.New("failed to find a recorded response")
+
+	// ErrRateLimited is returned when a rate limit does not
+	// reset within the time allowed to wait for it
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrHTTPCheckFailed is returned by HTTPCheckReport.Err when
+	// any assertion of a HTTPCheck failed
+	ErrHTTPCheckFailed = errors.New("http check failed")
+)
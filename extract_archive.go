@@ -0,0 +1,564 @@
+package devops
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// ArchiveFormat defines a supported archive format
+type ArchiveFormat string
+
+const (
+	ArchiveFormatTar      ArchiveFormat = "tar"
+	ArchiveFormatTarBzip2 ArchiveFormat = "tar.bz2"
+	ArchiveFormatTarGzip  ArchiveFormat = "tar.gz"
+	ArchiveFormatTarXz    ArchiveFormat = "tar.xz"
+	ArchiveFormatZip      ArchiveFormat = "zip"
+)
+
+// archiveExtensions maps file extensions to their archive formats,
+// longer extensions are listed first so that they match first
+var archiveExtensions = []struct {
+	Extension string
+	Format    ArchiveFormat
+}{
+	{".tar.bz2", ArchiveFormatTarBzip2},
+	{".tar.gz", ArchiveFormatTarGzip},
+	{".tar.xz", ArchiveFormatTarXz},
+	{".tbz2", ArchiveFormatTarBzip2},
+	{".tgz", ArchiveFormatTarGzip},
+	{".txz", ArchiveFormatTarXz},
+	{".tar", ArchiveFormatTar},
+	{".zip", ArchiveFormatZip},
+}
+
+// ExtractArchiveOpts presents configuration for the
+// ExtractArchive method
+type ExtractArchiveOpts struct {
+	// Archive defines a stream to read the archive from (eg. the body
+	// of a HTTP response), this is used instead of .ArchivePath if
+	// specified. Zip archives are buffered into a temporary file
+	// since they cannot be read as a stream
+	Archive io.Reader
+
+	// ArchivePath defines the path to the archive to extract
+	ArchivePath string
+
+	// DestinationPath defines the directory to extract into, it is
+	// created if it does not exist
+	DestinationPath string
+
+	// Format defines the format of the archive
+	//
+	// Defaults to the format matching the extension of .ArchivePath
+	// or the format detected from the content of the archive if not
+	// specified
+	Format ArchiveFormat
+
+	// Include can optionally be specified to only extract entries
+	// whose paths (after .StripComponents is applied) or parent
+	// directories match one of the glob patterns (eg. 'bin/*'). The
+	// syntax is the same as path.Match
+	Include []string
+
+	// Overwrite when set to true allows existing files in
+	// .DestinationPath to be replaced
+	Overwrite bool
+
+	// StripComponents defines the number of leading path components
+	// to remove from the path of each entry in the same way as
+	// `tar --strip-components`
+	StripComponents int
+}
+
+// Validate verifies that this object instance is usable
+// by the ExtractArchive method
+func (o ExtractArchiveOpts) Validate() error {
+	errors := []string{}
+
+	if o.Archive == nil && o.ArchivePath == "" {
+		errors = append(errors, "missing archive path or archive stream")
+	}
+	if o.DestinationPath == "" {
+		errors = append(errors, "missing destination path")
+	}
+	switch o.Format {
+	case "", ArchiveFormatTar, ArchiveFormatTarBzip2, ArchiveFormatTarGzip, ArchiveFormatTarXz, ArchiveFormatZip:
+	default:
+		errors = append(errors, fmt.Sprintf("unsupported archive format '%s'", o.Format))
+	}
+	for _, pattern := range o.Include {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid include pattern '%s'", pattern))
+		}
+	}
+	if o.StripComponents < 0 {
+		errors = append(errors, "strip components cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// ExtractArchive extracts a tar, tar.gz, tar.bz2, tar.xz or zip archive
+// as directed by the configuration set in the options object `opts`
+// and returns the paths of the extracted files. File modes are
+// preserved without the setuid, setgid and sticky bits. Entries that
+// would be written outside of .DestinationPath (including through
+// symbolic links) are refused with an error wrapping
+// ErrUnsafeArchivePath
+func ExtractArchive(opts ExtractArchiveOpts) ([]string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %w", err)
+	}
+	destination, err := NormalizeLocalPath(opts.DestinationPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize path '%s': %w", opts.DestinationPath, err)
+	}
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory at '%s': %w", destination, err)
+	}
+	if destination, err = filepath.EvalSymlinks(destination); err != nil {
+		return nil, fmt.Errorf("failed to resolve path '%s': %w", destination, err)
+	}
+
+	archive := opts.Archive
+	if archive == nil {
+		archivePath, err := NormalizeLocalPath(opts.ArchivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize path '%s': %w", opts.ArchivePath, err)
+		}
+		/* #nosec - this is required to read the archive */
+		fileHandle, err := os.Open(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive at '%s': %w", archivePath, err)
+		}
+		defer fileHandle.Close()
+		archive = fileHandle
+		if opts.Format == "" {
+			opts.Format = getArchiveFormat(archivePath)
+		}
+	}
+	bufferedArchive := bufio.NewReader(archive)
+	if opts.Format == "" {
+		opts.Format = detectArchiveFormat(bufferedArchive)
+	}
+
+	extractor := archiveExtractor{
+		destination:     destination,
+		include:         opts.Include,
+		overwrite:       opts.Overwrite,
+		stripComponents: opts.StripComponents,
+	}
+	if opts.Format == ArchiveFormatZip {
+		err = extractor.ExtractZip(archive, bufferedArchive)
+	} else {
+		err = extractor.ExtractTar(bufferedArchive, opts.Format)
+	}
+	if err != nil {
+		return extractor.extracted, fmt.Errorf("failed to extract archive: %w", err)
+	}
+	return extractor.extracted, nil
+}
+
+// getArchiveFormat returns the archive format matching the extension
+// of the provided file path, an empty string is returned if none match
+func getArchiveFormat(filePath string) ArchiveFormat {
+	lowercasePath := strings.ToLower(filePath)
+	for _, archiveExtension := range archiveExtensions {
+		if strings.HasSuffix(lowercasePath, archiveExtension.Extension) {
+			return archiveExtension.Format
+		}
+	}
+	return ""
+}
+
+// detectArchiveFormat returns the archive format detected from the
+// magic bytes of the archive, tar is assumed if none match
+func detectArchiveFormat(archive *bufio.Reader) ArchiveFormat {
+	header, _ := archive.Peek(6)
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return ArchiveFormatTarGzip
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return ArchiveFormatTarBzip2
+	case bytes.HasPrefix(header, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return ArchiveFormatTarXz
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return ArchiveFormatZip
+	}
+	return ArchiveFormatTar
+}
+
+// archiveExtractor writes archive entries into .destination
+type archiveExtractor struct {
+	destination     string
+	extracted       []string
+	include         []string
+	overwrite       bool
+	stripComponents int
+}
+
+// ExtractTar extracts a tar archive compressed using the provided format
+func (e *archiveExtractor) ExtractTar(archive io.Reader, format ArchiveFormat) error {
+	var err error
+	switch format {
+	case ArchiveFormatTarBzip2:
+		archive = bzip2.NewReader(archive)
+	case ArchiveFormatTarGzip:
+		var gzipReader *gzip.Reader
+		if gzipReader, err = gzip.NewReader(archive); err != nil {
+			return fmt.Errorf("failed to read gzip stream: %w", err)
+		}
+		defer gzipReader.Close()
+		archive = gzipReader
+	case ArchiveFormatTarXz:
+		if archive, err = xz.NewReader(archive); err != nil {
+			return fmt.Errorf("failed to read xz stream: %w", err)
+		}
+	}
+	tarReader := tar.NewReader(archive)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read tar entry: %w", err)
+		}
+		mode := header.FileInfo().Mode()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.extractDirectory(header.Name, mode)
+		case tar.TypeReg:
+			err = e.extractFile(header.Name, mode, tarReader)
+		case tar.TypeSymlink:
+			err = e.extractSymlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = e.extractHardLink(header.Name, header.Linkname)
+		default:
+			// devices, fifos and other special files are skipped
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ExtractZip extracts a zip archive, the archive is buffered into a
+// temporary file if it is not a regular file already
+func (e *archiveExtractor) ExtractZip(archive io.Reader, bufferedArchive io.Reader) error {
+	fileHandle, ok := archive.(*os.File)
+	if ok {
+		fileInfo, err := fileHandle.Stat()
+		ok = err == nil && fileInfo.Mode().IsRegular()
+	}
+	if !ok {
+		temporaryFile, err := ioutil.TempFile("", "devops-archive-*.zip")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		defer os.Remove(temporaryFile.Name())
+		defer temporaryFile.Close()
+		if _, err := io.Copy(temporaryFile, bufferedArchive); err != nil {
+			return fmt.Errorf("failed to buffer archive into '%s': %w", temporaryFile.Name(), err)
+		}
+		fileHandle = temporaryFile
+	}
+	fileInfo, err := fileHandle.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to access '%s': %w", fileHandle.Name(), err)
+	}
+	zipReader, err := zip.NewReader(fileHandle, fileInfo.Size())
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+	for _, entry := range zipReader.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			err = e.extractDirectory(entry.Name, mode)
+		case mode&os.ModeSymlink != 0:
+			var linkname string
+			if linkname, err = readZipEntry(entry); err == nil {
+				err = e.extractSymlink(entry.Name, linkname)
+			}
+		case mode.IsRegular():
+			var entryReader io.ReadCloser
+			if entryReader, err = entry.Open(); err == nil {
+				err = e.extractFile(entry.Name, mode, entryReader)
+				entryReader.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readZipEntry returns the content of a small zip entry like the
+// target of a symbolic link
+func readZipEntry(entry *zip.File) (string, error) {
+	entryReader, err := entry.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open '%s': %w", entry.Name, err)
+	}
+	defer entryReader.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(entryReader, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read '%s': %w", entry.Name, err)
+	}
+	return string(content), nil
+}
+
+// getTargetPath returns the path to write the entry with the provided
+// name to and false if the entry should be skipped
+func (e *archiveExtractor) getTargetPath(name string) (string, bool, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	for _, component := range strings.Split(name, "/") {
+		if component == ".." {
+			return "", false, fmt.Errorf("%w: '%s'", ErrUnsafeArchivePath, name)
+		}
+	}
+	// leading slashes are removed in the same way as `tar`
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	components := strings.Split(name, "/")
+	if name == "" || len(components) <= e.stripComponents {
+		return "", false, nil
+	}
+	name = path.Join(components[e.stripComponents:]...)
+	if !e.isIncluded(name) {
+		return "", false, nil
+	}
+	return filepath.Join(e.destination, filepath.FromSlash(name)), true, nil
+}
+
+// isIncluded returns true if .include is empty or if the provided name
+// or any of its parent directories matches a pattern in .include
+func (e *archiveExtractor) isIncluded(name string) bool {
+	if len(e.include) == 0 {
+		return true
+	}
+	for candidate := name; candidate != "." && candidate != "/"; candidate = path.Dir(candidate) {
+		for _, pattern := range e.include {
+			if matched, _ := path.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isWithinDestination returns true if the provided path is the
+// destination or is inside of it
+func (e *archiveExtractor) isWithinDestination(targetPath string) bool {
+	relativePath, err := filepath.Rel(e.destination, targetPath)
+	if err != nil {
+		return false
+	}
+	return relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// verifyResolvedPath verifies that the provided existing path does not
+// resolve to outside of the destination through a symbolic link
+func (e *archiveExtractor) verifyResolvedPath(existingPath string) error {
+	resolvedPath, err := filepath.EvalSymlinks(existingPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path '%s': %w", existingPath, err)
+	}
+	if !e.isWithinDestination(resolvedPath) {
+		return fmt.Errorf("%w: '%s' resolves to '%s'", ErrUnsafeArchivePath, existingPath, resolvedPath)
+	}
+	return nil
+}
+
+// isSafeLinkTarget returns true if a symbolic link at the provided
+// path to the provided relative target resolves to inside of the
+// destination. The target is resolved from the parent of the link
+// with its symbolic links resolved since that is where the link is
+// created, and '..' is only allowed at the start of the target so
+// that it cannot be applied to a symbolic link extracted later
+func (e *archiveExtractor) isSafeLinkTarget(targetPath, linkTarget string) (bool, error) {
+	if filepath.IsAbs(linkTarget) {
+		return false, nil
+	}
+	hasName := false
+	for _, component := range strings.Split(linkTarget, string(filepath.Separator)) {
+		if component == ".." && hasName {
+			return false, nil
+		} else if component != ".." && component != "." && component != "" {
+			hasName = true
+		}
+	}
+	parentPath := filepath.Dir(targetPath)
+	resolvedParentPath, err := filepath.EvalSymlinks(parentPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve path '%s': %w", parentPath, err)
+	}
+	return e.isWithinDestination(filepath.Join(resolvedParentPath, linkTarget)), nil
+}
+
+// prepareParent creates the parent directory of the target path and
+// verifies that it is inside of the destination
+func (e *archiveExtractor) prepareParent(targetPath string) error {
+	parentPath := filepath.Dir(targetPath)
+	if err := e.verifyExistingAncestor(parentPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(parentPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory at '%s': %w", parentPath, err)
+	}
+	return e.verifyResolvedPath(parentPath)
+}
+
+// verifyExistingAncestor verifies the closest existing ancestor of the
+// provided path so that directories are never created outside of the
+// destination
+func (e *archiveExtractor) verifyExistingAncestor(targetPath string) error {
+	for ancestor := targetPath; ; ancestor = filepath.Dir(ancestor) {
+		if _, err := os.Lstat(ancestor); err == nil {
+			return e.verifyResolvedPath(ancestor)
+		}
+		if ancestor == e.destination || ancestor == filepath.Dir(ancestor) {
+			return nil
+		}
+	}
+}
+
+// prepareTarget prepares the parent of the target path and removes an
+// existing file at the target path if overwriting is allowed
+func (e *archiveExtractor) prepareTarget(targetPath string) error {
+	if err := e.prepareParent(targetPath); err != nil {
+		return err
+	}
+	fileInfo, err := os.Lstat(targetPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to access path '%s': %w", targetPath, err)
+	}
+	if fileInfo.IsDir() {
+		return fmt.Errorf("failed to get a file at '%s': %w", targetPath, ErrIsDirectory)
+	}
+	if !e.overwrite {
+		return fmt.Errorf("%w at '%s' (set .Overwrite to true)", ErrRefuseOverwrite, targetPath)
+	}
+	// existing files are removed instead of truncated so that files
+	// outside of the destination cannot be written to through links
+	if err := os.Remove(targetPath); err != nil {
+		return fmt.Errorf("failed to remove existing file at '%s': %w", targetPath, err)
+	}
+	return nil
+}
+
+func (e *archiveExtractor) extractDirectory(name string, mode os.FileMode) error {
+	targetPath, ok, err := e.getTargetPath(name)
+	if !ok || err != nil {
+		return err
+	}
+	if err := e.prepareParent(targetPath); err != nil {
+		return err
+	}
+	if err := e.verifyExistingAncestor(targetPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(targetPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory at '%s': %w", targetPath, err)
+	}
+	// the owner always needs access to extract files into the directory
+	if err := os.Chmod(targetPath, mode.Perm()|0700); err != nil {
+		return fmt.Errorf("failed to set permissions of '%s': %w", targetPath, err)
+	}
+	return nil
+}
+
+func (e *archiveExtractor) extractFile(name string, mode os.FileMode, content io.Reader) error {
+	targetPath, ok, err := e.getTargetPath(name)
+	if !ok || err != nil {
+		return err
+	}
+	if err := e.prepareTarget(targetPath); err != nil {
+		return err
+	}
+	/* #nosec - this is required to write the file */
+	fileHandle, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("failed to open file at '%s': %w", targetPath, err)
+	}
+	defer fileHandle.Close()
+	if _, err := io.Copy(fileHandle, content); err != nil {
+		return fmt.Errorf("failed to write to file at '%s': %w", targetPath, err)
+	}
+	// the mode passed to OpenFile is subject to the umask
+	if err := fileHandle.Chmod(mode.Perm()); err != nil {
+		return fmt.Errorf("failed to set permissions of '%s': %w", targetPath, err)
+	}
+	e.extracted = append(e.extracted, targetPath)
+	return nil
+}
+
+func (e *archiveExtractor) extractSymlink(name, linkname string) error {
+	targetPath, ok, err := e.getTargetPath(name)
+	if !ok || err != nil {
+		return err
+	}
+	if err := e.prepareParent(targetPath); err != nil {
+		return err
+	}
+	linkTarget := filepath.FromSlash(linkname)
+	if isSafe, err := e.isSafeLinkTarget(targetPath, linkTarget); err != nil {
+		return err
+	} else if !isSafe {
+		return fmt.Errorf("%w: link '%s' points to '%s'", ErrUnsafeArchivePath, name, linkname)
+	}
+	if err := e.prepareTarget(targetPath); err != nil {
+		return err
+	}
+	if err := os.Symlink(linkTarget, targetPath); err != nil {
+		return fmt.Errorf("failed to create link at '%s': %w", targetPath, err)
+	}
+	e.extracted = append(e.extracted, targetPath)
+	return nil
+}
+
+func (e *archiveExtractor) extractHardLink(name, linkname string) error {
+	targetPath, ok, err := e.getTargetPath(name)
+	if !ok || err != nil {
+		return err
+	}
+	// hard link targets are relative to the root of the archive and
+	// should have been extracted already
+	linkTarget, ok, err := e.getTargetPath(linkname)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("failed to link '%s' to '%s' which was not extracted", name, linkname)
+	}
+	if err := e.prepareParent(linkTarget); err != nil {
+		return err
+	}
+	if err := e.prepareTarget(targetPath); err != nil {
+		return err
+	}
+	if err := os.Link(linkTarget, targetPath); err != nil {
+		return fmt.Errorf("failed to create link at '%s': %w", targetPath, err)
+	}
+	e.extracted = append(e.extracted, targetPath)
+	return nil
+}
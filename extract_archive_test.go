@@ -0,0 +1,237 @@
+package devops
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/ulikunitz/xz"
+)
+
+type ExtractArchiveTests struct {
+	suite.Suite
+}
+
+func TestExtractArchive(t *testing.T) {
+	suite.Run(t, &ExtractArchiveTests{})
+}
+
+// testArchiveEntry defines an entry for creating test archives
+type testArchiveEntry struct {
+	Name     string
+	Content  string
+	Linkname string
+	Mode     int64
+	Type     byte
+}
+
+func (s ExtractArchiveTests) createTar(entries []testArchiveEntry) []byte {
+	var archive bytes.Buffer
+	tarWriter := tar.NewWriter(&archive)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.Name,
+			Linkname: entry.Linkname,
+			Mode:     entry.Mode,
+			Size:     int64(len(entry.Content)),
+			Typeflag: entry.Type,
+		}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		s.Nil(tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write([]byte(entry.Content))
+		s.Nil(err)
+	}
+	s.Nil(tarWriter.Close())
+	return archive.Bytes()
+}
+
+func (s ExtractArchiveTests) createTarGz(entries []testArchiveEntry) []byte {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	_, err := gzipWriter.Write(s.createTar(entries))
+	s.Nil(err)
+	s.Nil(gzipWriter.Close())
+	return archive.Bytes()
+}
+
+func (s ExtractArchiveTests) TestExtractArchive_tarGz() {
+	archivePath := path.Join(s.T().TempDir(), "tool_linux_amd64.tar.gz")
+	s.Nil(ioutil.WriteFile(archivePath, s.createTarGz([]testArchiveEntry{
+		{Name: "tool-1.0.0/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "tool-1.0.0/bin/tool", Content: "binary", Mode: 0755},
+		{Name: "tool-1.0.0/README.md", Content: "readme"},
+		{Name: "tool-1.0.0/bin/tool-alias", Linkname: "tool", Type: tar.TypeSymlink},
+	}), 0644))
+	destination := s.T().TempDir()
+
+	extracted, err := ExtractArchive(ExtractArchiveOpts{
+		ArchivePath:     archivePath,
+		DestinationPath: destination,
+		StripComponents: 1,
+	})
+	s.Nil(err)
+	sort.Strings(extracted)
+	s.Equal([]string{
+		path.Join(destination, "README.md"),
+		path.Join(destination, "bin/tool"),
+		path.Join(destination, "bin/tool-alias"),
+	}, extracted)
+	fileInfo, err := os.Stat(path.Join(destination, "bin/tool"))
+	s.Nil(err)
+	s.Equal(os.FileMode(0755), fileInfo.Mode().Perm())
+	content, err := ioutil.ReadFile(path.Join(destination, "bin/tool-alias"))
+	s.Nil(err)
+	s.Equal("binary", string(content))
+
+	_, err = ExtractArchive(ExtractArchiveOpts{
+		ArchivePath:     archivePath,
+		DestinationPath: destination,
+		StripComponents: 1,
+	})
+	s.True(errors.Is(err, ErrRefuseOverwrite))
+}
+
+func (s ExtractArchiveTests) TestExtractArchive_Include() {
+	destination := s.T().TempDir()
+	extracted, err := ExtractArchive(ExtractArchiveOpts{
+		Archive: bytes.NewReader(s.createTarGz([]testArchiveEntry{
+			{Name: "tool/bin/tool", Content: "binary", Mode: 0755},
+			{Name: "tool/share/doc", Content: "doc"},
+			{Name: "tool/LICENSE", Content: "license"},
+		})),
+		DestinationPath: destination,
+		Include:         []string{"bin", "LICEN?E"},
+		StripComponents: 1,
+	})
+	s.Nil(err)
+	sort.Strings(extracted)
+	s.Equal([]string{
+		path.Join(destination, "LICENSE"),
+		path.Join(destination, "bin/tool"),
+	}, extracted)
+}
+
+func (s ExtractArchiveTests) TestExtractArchive_tarXz() {
+	var archive bytes.Buffer
+	xzWriter, err := xz.NewWriter(&archive)
+	s.Nil(err)
+	_, err = xzWriter.Write(s.createTar([]testArchiveEntry{{Name: "file", Content: "content"}}))
+	s.Nil(err)
+	s.Nil(xzWriter.Close())
+	destination := s.T().TempDir()
+
+	_, err = ExtractArchive(ExtractArchiveOpts{
+		Archive:         &archive,
+		DestinationPath: destination,
+	})
+	s.Nil(err)
+	content, err := ioutil.ReadFile(path.Join(destination, "file"))
+	s.Nil(err)
+	s.Equal("content", string(content))
+}
+
+func (s ExtractArchiveTests) TestExtractArchive_zip() {
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	header := &zip.FileHeader{Name: "dir/script.sh"}
+	header.SetMode(0750)
+	entryWriter, err := zipWriter.CreateHeader(header)
+	s.Nil(err)
+	_, err = io.WriteString(entryWriter, "#!/bin/sh")
+	s.Nil(err)
+	s.Nil(zipWriter.Close())
+	destination := s.T().TempDir()
+
+	_, err = ExtractArchive(ExtractArchiveOpts{
+		Archive:         &archive,
+		DestinationPath: destination,
+	})
+	s.Nil(err)
+	fileInfo, err := os.Stat(path.Join(destination, "dir/script.sh"))
+	s.Nil(err)
+	s.Equal(os.FileMode(0750), fileInfo.Mode().Perm())
+}
+
+func (s ExtractArchiveTests) TestExtractArchive_pathTraversal() {
+	parent := s.T().TempDir()
+	destination := path.Join(parent, "destination")
+	_, err := ExtractArchive(ExtractArchiveOpts{
+		Archive:         bytes.NewReader(s.createTar([]testArchiveEntry{{Name: "../escaped", Content: "x"}})),
+		DestinationPath: destination,
+	})
+	s.True(errors.Is(err, ErrUnsafeArchivePath))
+	_, err = os.Lstat(path.Join(parent, "escaped"))
+	s.True(errors.Is(err, os.ErrNotExist))
+}
+
+func (s ExtractArchiveTests) TestExtractArchive_symlinkEscape() {
+	parent := s.T().TempDir()
+	destination := path.Join(parent, "destination")
+	_, err := ExtractArchive(ExtractArchiveOpts{
+		Archive: bytes.NewReader(s.createTar([]testArchiveEntry{
+			{Name: "link", Linkname: "..", Type: tar.TypeSymlink},
+			{Name: "link/escaped", Content: "x"},
+		})),
+		DestinationPath: destination,
+	})
+	s.True(errors.Is(err, ErrUnsafeArchivePath))
+
+	s.Nil(os.Symlink(parent, path.Join(destination, "existing-link")))
+	_, err = ExtractArchive(ExtractArchiveOpts{
+		Archive: bytes.NewReader(s.createTar([]testArchiveEntry{
+			{Name: "existing-link/escaped", Content: "x"},
+		})),
+		DestinationPath: destination,
+	})
+	s.True(errors.Is(err, ErrUnsafeArchivePath), "existing links should not be followed out of the destination")
+	_, err = os.Lstat(path.Join(parent, "escaped"))
+	s.True(errors.Is(err, os.ErrNotExist))
+}
+
+func (s ExtractArchiveTests) TestExtractArchive_symlinkChainEscape() {
+	parent := s.T().TempDir()
+	s.Nil(os.WriteFile(path.Join(parent, "secret"), []byte("secret"), 0600))
+	for _, entries := range [][]testArchiveEntry{
+		{
+			{Name: "s", Linkname: ".", Type: tar.TypeSymlink},
+			{Name: "s/esc", Linkname: "../secret", Type: tar.TypeSymlink},
+		},
+		{
+			{Name: "esc", Linkname: "later/../../secret", Type: tar.TypeSymlink},
+			{Name: "later", Linkname: ".", Type: tar.TypeSymlink},
+		},
+	} {
+		destination := path.Join(parent, "destination")
+		s.Nil(os.RemoveAll(destination))
+		_, err := ExtractArchive(ExtractArchiveOpts{
+			Archive:         bytes.NewReader(s.createTar(entries)),
+			DestinationPath: destination,
+		})
+		s.True(errors.Is(err, ErrUnsafeArchivePath), entries)
+		_, err = os.Lstat(path.Join(destination, "esc"))
+		s.True(errors.Is(err, os.ErrNotExist), "links which escape through other links should not be created")
+	}
+}
+
+func (s ExtractArchiveTests) Test_getArchiveFormat() {
+	s.Equal(ArchiveFormatTarGzip, getArchiveFormat("tool.tar.gz"))
+	s.Equal(ArchiveFormatTarGzip, getArchiveFormat("tool.TGZ"))
+	s.Equal(ArchiveFormatTarXz, getArchiveFormat("tool.tar.xz"))
+	s.Equal(ArchiveFormatTarBzip2, getArchiveFormat("tool.tar.bz2"))
+	s.Equal(ArchiveFormatZip, getArchiveFormat("tool.zip"))
+	s.Equal(ArchiveFormat(""), getArchiveFormat("tool"))
+}
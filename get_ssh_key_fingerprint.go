@@ -38,6 +38,11 @@ type GetSshKeyFingerprintOpts struct {
 	// applicable
 	Passphrase string
 
+	// PassphrasePrompt if defined is used to ask the user for the
+	// passphrase when the private key requires one and .Passphrase
+	// is not set
+	PassphrasePrompt *PromptPasswordOpts
+
 	// Path defines the file directory path to the key file
 	// of interest
 	Path string
@@ -64,7 +69,7 @@ func (o GetSshKeyFingerprintOpts) Validate() error {
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to validate options: ['%s']", strings.Join(errors, "', '"))
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
 	}
 	return nil
 }
@@ -75,40 +80,50 @@ func (o GetSshKeyFingerprintOpts) Validate() error {
 func GetSshKeyFingerprint(opts GetSshKeyFingerprintOpts) (SshKeyFingerprint, error) {
 	opts.SetDefaults()
 	if err := opts.Validate(); err != nil {
-		return nil, fmt.Errorf("failed to get ssh fingerprint: %s", err)
+		return nil, fmt.Errorf("failed to get ssh fingerprint: %w", err)
 	}
 	keyPath := opts.Path
 	/* #nosec - this is needed to read the file */
 	keyContent, err := ioutil.ReadFile(keyPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file at '%s': %s", keyPath, err)
+		return nil, fmt.Errorf("failed to open file at '%s': %w", keyPath, err)
 	}
 
 	var publicKey ssh.PublicKey
 	if opts.IsPrivateKey {
 		privateKey, err := ssh.ParsePrivateKey(keyContent)
 		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			if opts.Passphrase == "" && opts.PassphrasePrompt != nil {
+				if opts.Passphrase, err = PromptPassword(*opts.PassphrasePrompt); err != nil {
+					return nil, fmt.Errorf("failed to get passphrase: %w", err)
+				}
+			}
 			if opts.Passphrase == "" {
-				return nil, fmt.Errorf("failed to provide a required passphrase")
+				return nil, fmt.Errorf("%w: %w", ErrPassphraseRequired, err)
 			}
 			privateKey, err = ssh.ParsePrivateKeyWithPassphrase(keyContent, []byte(opts.Passphrase))
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse private key using provided passphrase: %s", err)
+				return nil, fmt.Errorf("failed to parse private key using provided passphrase: %w", err)
 			}
 		} else if err != nil {
-			return nil, fmt.Errorf("failed to parse private key: %s", err)
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
 		}
 		publicKey = privateKey.PublicKey()
 	} else if opts.IsPublicKey {
 		publicKey, _, _, _, err = ssh.ParseAuthorizedKey(keyContent)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse public key: %s", err)
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
 		}
 	}
 
-	fingerprint := sshKeyFingerprint{
+	return getSSHPublicKeyFingerprint(publicKey), nil
+}
+
+// getSSHPublicKeyFingerprint returns the fingerprints of a public key
+// in the formats used by ssh-keygen
+func getSSHPublicKeyFingerprint(publicKey ssh.PublicKey) sshKeyFingerprint {
+	return sshKeyFingerprint{
 		md5:    ssh.FingerprintLegacyMD5(publicKey),
 		sha256: ssh.FingerprintSHA256(publicKey),
 	}
-	return fingerprint, nil
 }
@@ -1,6 +1,9 @@
 package devops
 
 import (
+	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -49,6 +52,7 @@ func (s SshFingerprintTests) Test_GetSshKeyFingerprint_privateKeyWithPassphrase(
 
 	fingerprint, err := GetSshKeyFingerprint(options)
 	s.NotNil(err)
+	s.True(errors.Is(err, ErrPassphraseRequired))
 	s.Contains(err.Error(), "failed to provide a required passphrase")
 
 	options.Passphrase = "password"
@@ -85,3 +89,16 @@ func (s SshFingerprintTests) Test_GetSshKeyFingerprint_publicKeyWithPassphrasedP
 	s.Equal(TestSshKeysFingerprint["id_rsa_1024-w-password"].sha256, fingerprint.GetSHA256(),
 		"fingerprint should match the sha256 generated from 'ssh-keygen -lf ...'")
 }
+
+func (s SshFingerprintTests) Test_GetSshKeyFingerprint_privateKeyWithPassphrasePrompt() {
+	fingerprint, err := GetSshKeyFingerprint(GetSshKeyFingerprintOpts{
+		IsPrivateKey: true,
+		Path:         "./tests/sshkeys/id_rsa_1024-w-password",
+		PassphrasePrompt: &PromptPasswordOpts{
+			Input:  strings.NewReader("password\n"),
+			Output: &bytes.Buffer{},
+		},
+	})
+	s.Nil(err)
+	s.Equal(TestSshKeysFingerprint["id_rsa_1024-w-password"].sha256, fingerprint.GetSHA256())
+}
@@ -1,16 +1,20 @@
 module gitlab.com/zephinzer/go-devops
 
-go 1.17
+go 1.20
 
 require (
+	github.com/ProtonMail/go-crypto v1.0.0
 	github.com/stretchr/testify v1.7.0
+	github.com/ulikunitz/xz v0.5.11
 	github.com/zephinzer/go-strcase v1.0.1
-	golang.org/x/crypto v0.0.0-20210915214749-c084706c2272
+	golang.org/x/crypto v0.17.0
+	golang.org/x/sys v0.15.0
+	golang.org/x/term v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/cloudflare/circl v1.3.3 // indirect
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
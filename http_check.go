@@ -0,0 +1,505 @@
+package devops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHTTPCheckTimeout defines the default duration after which a
+// HTTPCheck request is cancelled
+const DefaultHTTPCheckTimeout = 30 * time.Second
+
+// HTTPCheck declares a request to send and the assertions its response
+// should pass, use RunHTTPCheck to run it or LoadHTTPChecks to load
+// checks from a YAML file
+type HTTPCheck struct {
+	// Body defines the body to send with the request
+	Body string `yaml:"body"`
+
+	// BodyContains defines strings which the response body should
+	// contain
+	BodyContains []string `yaml:"bodyContains"`
+
+	// BodyMatches defines regular expressions which the response
+	// body should match
+	BodyMatches []string `yaml:"bodyMatches"`
+
+	// Client defines the HTTP client to use, see NewHTTPClient. If
+	// left nil, defaults to http.DefaultClient
+	Client *http.Client `yaml:"-"`
+
+	// ExpectedHeaders defines regular expressions keyed by header
+	// name which the response headers should match
+	ExpectedHeaders map[string]string `yaml:"expectedHeaders"`
+
+	// ExpectedJSON defines values keyed by JSONPath (eg. "$.status" or
+	// "$.items[0].name") which should be equal to the values at those
+	// paths in the JSON response body
+	ExpectedJSON map[string]interface{} `yaml:"expectedJSON"`
+
+	// ExpectedStatusCodes defines the status codes the response can
+	// have. If left empty, any 2xx status code passes
+	ExpectedStatusCodes []int `yaml:"expectedStatusCodes"`
+
+	// Headers defines the headers to send with the request
+	Headers map[string]string `yaml:"headers"`
+
+	// MaxLatency defines the longest time allowed to receive the full
+	// response. If left as 0, latency is not checked
+	MaxLatency time.Duration `yaml:"maxLatency"`
+
+	// Method defines the HTTP method to use
+	//
+	// Defaults to http.MethodGet if not specified
+	Method string `yaml:"method"`
+
+	// MinCertificateDays defines the minimum number of days before
+	// the TLS certificate of the server expires. If left as 0, the
+	// certificate is not checked
+	MinCertificateDays int `yaml:"minCertificateDays"`
+
+	// Name defines a name for the check used in its report
+	//
+	// Defaults to the method and URL if not specified
+	Name string `yaml:"name"`
+
+	// Timeout defines the duration after which the request is
+	// cancelled
+	//
+	// Defaults to DefaultHTTPCheckTimeout if not specified
+	Timeout time.Duration `yaml:"timeout"`
+
+	// URL defines the endpoint to check
+	URL string `yaml:"url"`
+}
+
+// SetDefaults sets defaults for this object instance
+func (c *HTTPCheck) SetDefaults() {
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("%s %s", c.Method, c.URL)
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultHTTPCheckTimeout
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the RunHTTPCheck method
+func (c HTTPCheck) Validate() error {
+	errors := []string{}
+
+	for _, pattern := range c.BodyMatches {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid body pattern '%s'", pattern))
+		}
+	}
+
+	for header, pattern := range c.ExpectedHeaders {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid pattern '%s' for header '%s'", pattern, header))
+		}
+	}
+
+	for path := range c.ExpectedJSON {
+		if _, err := parseJSONPath(path); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid json path '%s'", path))
+		}
+	}
+
+	for _, statusCode := range c.ExpectedStatusCodes {
+		if statusCode < 100 || statusCode > 599 {
+			errors = append(errors, fmt.Sprintf("invalid status code '%v'", statusCode))
+		}
+	}
+
+	if c.MaxLatency < 0 {
+		errors = append(errors, "max latency cannot be negative")
+	}
+
+	if c.MinCertificateDays < 0 {
+		errors = append(errors, "min certificate days cannot be negative")
+	}
+
+	if c.Timeout < 0 {
+		errors = append(errors, "timeout cannot be negative")
+	}
+
+	if c.URL == "" {
+		errors = append(errors, "missing url")
+	} else if checkURL, err := url.Parse(c.URL); err != nil {
+		errors = append(errors, fmt.Sprintf("invalid url '%s'", c.URL))
+	} else if checkURL.Host == "" {
+		errors = append(errors, "missing host in url")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// HTTPCheckFailure describes an assertion of a HTTPCheck which failed
+type HTTPCheckFailure struct {
+	// Assertion is the name of the assertion which failed
+	// (eg. "status code" or "json path '$.status'")
+	Assertion string
+
+	// Expected describes what was expected
+	Expected string
+
+	// Actual describes what was received
+	Actual string
+}
+
+// String returns a description of the failure
+func (f HTTPCheckFailure) String() string {
+	return fmt.Sprintf("%s: expected %s but got %s", f.Assertion, f.Expected, f.Actual)
+}
+
+// HTTPCheckReport describes the result of a HTTPCheck
+type HTTPCheckReport struct {
+	// Name is the name of the check
+	Name string
+
+	// URL is the redacted URL which was checked
+	URL string
+
+	// Passed is true if all assertions passed
+	Passed bool
+
+	// Failures lists every assertion which failed
+	Failures []HTTPCheckFailure
+
+	// StatusCode is the status code of the response, 0 if no
+	// response was received
+	StatusCode int
+
+	// Latency is the time taken to receive the full response
+	Latency time.Duration
+
+	// CertificateExpiresAt is the expiry time of the TLS certificate
+	// of the server, zero if the server did not use TLS
+	CertificateExpiresAt time.Time
+}
+
+// Err returns an error wrapping ErrHTTPCheckFailed which lists the
+// failures if the check did not pass, nil otherwise
+func (r HTTPCheckReport) Err() error {
+	if r.Passed {
+		return nil
+	}
+	failures := []string{}
+	for _, failure := range r.Failures {
+		failures = append(failures, failure.String())
+	}
+	return fmt.Errorf("%w for '%s': ['%s']", ErrHTTPCheckFailed, r.Name, strings.Join(failures, "', '"))
+}
+
+// addFailure records a failed assertion
+func (r *HTTPCheckReport) addFailure(assertion, expected, actual string) {
+	r.Failures = append(r.Failures, HTTPCheckFailure{
+		Assertion: assertion,
+		Expected:  expected,
+		Actual:    actual,
+	})
+}
+
+// RunHTTPCheck sends the request of the HTTPCheck `check` and returns a
+// report of the assertions which failed. Failing to receive a response
+// is reported as a failed assertion, an error is only returned if the
+// check is invalid
+func RunHTTPCheck(check HTTPCheck) (*HTTPCheckReport, error) {
+	check.SetDefaults()
+	if err := check.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to run http check '%s': %w", check.Name, err)
+	}
+	checkURL, _ := url.Parse(check.URL)
+	report := &HTTPCheckReport{Name: check.Name, URL: checkURL.Redacted()}
+	defer func() { report.Passed = len(report.Failures) == 0 }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), check.Timeout)
+	defer cancel()
+	headers := http.Header{}
+	for key, value := range check.Headers {
+		headers.Set(key, value)
+	}
+	requestOpts := SendHTTPRequestOpts{
+		Client:  check.Client,
+		Context: ctx,
+		Headers: headers,
+		Method:  check.Method,
+		URL:     checkURL,
+	}
+	if check.Body != "" {
+		requestOpts.Body = []byte(check.Body)
+	}
+	startedAt := time.Now()
+	res, err := SendHTTPRequest(requestOpts)
+	if err != nil {
+		report.addFailure("response", "a response", err.Error())
+		return report, nil
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	report.Latency = time.Since(startedAt)
+	report.StatusCode = res.StatusCode
+	if err != nil {
+		report.addFailure("body", "a complete body", err.Error())
+	}
+
+	checkHTTPStatusCode(report, check, res)
+	checkHTTPHeaders(report, check, res)
+	checkHTTPBody(report, check, body)
+	if check.MaxLatency > 0 && report.Latency > check.MaxLatency {
+		report.addFailure("latency", fmt.Sprintf("at most %s", check.MaxLatency), report.Latency.String())
+	}
+	if res.TLS != nil && len(res.TLS.PeerCertificates) > 0 {
+		report.CertificateExpiresAt = res.TLS.PeerCertificates[0].NotAfter
+	}
+	if check.MinCertificateDays > 0 {
+		if report.CertificateExpiresAt.IsZero() {
+			report.addFailure("certificate", fmt.Sprintf("at least %v days to expiry", check.MinCertificateDays), "no certificate")
+		} else if days := int(time.Until(report.CertificateExpiresAt).Hours() / 24); days < check.MinCertificateDays {
+			report.addFailure("certificate", fmt.Sprintf("at least %v days to expiry", check.MinCertificateDays), fmt.Sprintf("%v days", days))
+		}
+	}
+	return report, nil
+}
+
+// RunHTTPChecks runs each of the HTTPChecks in `checks` in order and
+// returns their reports, an error is returned if any check is invalid
+func RunHTTPChecks(checks []HTTPCheck) ([]HTTPCheckReport, error) {
+	reports := []HTTPCheckReport{}
+	for _, check := range checks {
+		report, err := RunHTTPCheck(check)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+// LoadHTTPChecks loads HTTPChecks from the YAML file at `filePath`
+// which lists the checks under a `checks` key:
+//
+//	checks:
+//	  - name: health
+//	    url: https://example.com/healthz
+//	    expectedStatusCodes: [200]
+//	    maxLatency: 500ms
+func LoadHTTPChecks(filePath string) ([]HTTPCheck, error) {
+	/* #nosec - this is required to read the checks */
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read http checks at '%s': %w", filePath, err)
+	}
+	var document struct {
+		Checks []HTTPCheck `yaml:"checks"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&document); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse http checks at '%s': %w", filePath, err)
+	}
+	for index, check := range document.Checks {
+		check.SetDefaults()
+		if err := check.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to load http check %v ('%s') at '%s': %w", index, check.Name, filePath, err)
+		}
+	}
+	return document.Checks, nil
+}
+
+// checkHTTPStatusCode verifies the status code of the response
+func checkHTTPStatusCode(report *HTTPCheckReport, check HTTPCheck, res *http.Response) {
+	if len(check.ExpectedStatusCodes) == 0 {
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			report.addFailure("status code", "2xx", strconv.Itoa(res.StatusCode))
+		}
+		return
+	}
+	for _, statusCode := range check.ExpectedStatusCodes {
+		if res.StatusCode == statusCode {
+			return
+		}
+	}
+	report.addFailure("status code", fmt.Sprintf("one of %v", check.ExpectedStatusCodes), strconv.Itoa(res.StatusCode))
+}
+
+// checkHTTPHeaders verifies the headers of the response
+func checkHTTPHeaders(report *HTTPCheckReport, check HTTPCheck, res *http.Response) {
+	headers := []string{}
+	for header := range check.ExpectedHeaders {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		pattern := check.ExpectedHeaders[header]
+		assertion := fmt.Sprintf("header '%s'", header)
+		expected := fmt.Sprintf("a match for '%s'", pattern)
+		values := res.Header.Values(header)
+		if len(values) == 0 {
+			report.addFailure(assertion, expected, "no header")
+			continue
+		}
+		matcher := regexp.MustCompile(pattern)
+		isMatched := false
+		for _, value := range values {
+			if matcher.MatchString(value) {
+				isMatched = true
+				break
+			}
+		}
+		if !isMatched {
+			report.addFailure(assertion, expected, fmt.Sprintf("'%s'", strings.Join(values, "', '")))
+		}
+	}
+}
+
+// checkHTTPBody verifies the body of the response
+func checkHTTPBody(report *HTTPCheckReport, check HTTPCheck, body []byte) {
+	for _, substring := range check.BodyContains {
+		if !bytes.Contains(body, []byte(substring)) {
+			report.addFailure("body", fmt.Sprintf("to contain '%s'", substring), "a body without it")
+		}
+	}
+	for _, pattern := range check.BodyMatches {
+		if !regexp.MustCompile(pattern).Match(body) {
+			report.addFailure("body", fmt.Sprintf("a match for '%s'", pattern), "a body without one")
+		}
+	}
+	if len(check.ExpectedJSON) == 0 {
+		return
+	}
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		report.addFailure("body", "valid json", err.Error())
+		return
+	}
+	paths := []string{}
+	for path := range check.ExpectedJSON {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		expectedValue := check.ExpectedJSON[path]
+		assertion := fmt.Sprintf("json path '%s'", path)
+		expected, err := normalizeJSONValue(expectedValue)
+		if err != nil {
+			report.addFailure(assertion, "a json value", err.Error())
+			continue
+		}
+		expectedJSON, _ := json.Marshal(expected)
+		segments, _ := parseJSONPath(path)
+		actual, ok := getJSONPathValue(document, segments)
+		if !ok {
+			report.addFailure(assertion, string(expectedJSON), "no value")
+			continue
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			actualJSON, _ := json.Marshal(actual)
+			report.addFailure(assertion, string(expectedJSON), string(actualJSON))
+		}
+	}
+}
+
+// normalizeJSONValue converts a value to the types produced by
+// decoding JSON so that it can be compared with decoded values
+func normalizeJSONValue(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(encoded, &normalized)
+	return normalized, err
+}
+
+// parseJSONPath parses a JSONPath consisting of `$` followed by any
+// number of `.field`, `['field']` and `[index]` segments, field names
+// are returned as strings and indices as ints
+func parseJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path '%s' does not start with '$'", path)
+	}
+	segments := []interface{}{}
+	remaining := path[1:]
+	for remaining != "" {
+		switch remaining[0] {
+		case '.':
+			end := strings.IndexAny(remaining[1:], ".[")
+			if end < 0 {
+				end = len(remaining) - 1
+			}
+			field := remaining[1 : end+1]
+			if field == "" {
+				return nil, fmt.Errorf("json path '%s' has an empty field", path)
+			}
+			segments = append(segments, field)
+			remaining = remaining[end+1:]
+		case '[':
+			end := strings.Index(remaining, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("json path '%s' has an unclosed '['", path)
+			}
+			selector := remaining[1:end]
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				segments = append(segments, selector[1:len(selector)-1])
+			} else if index, err := strconv.Atoi(selector); err == nil && index >= 0 {
+				segments = append(segments, index)
+			} else {
+				return nil, fmt.Errorf("json path '%s' has an invalid selector '[%s]'", path, selector)
+			}
+			remaining = remaining[end+1:]
+		default:
+			return nil, fmt.Errorf("json path '%s' has an unexpected '%c'", path, remaining[0])
+		}
+	}
+	return segments, nil
+}
+
+// getJSONPathValue returns the value at the path `segments` of a
+// decoded JSON document and whether it exists
+func getJSONPathValue(document interface{}, segments []interface{}) (interface{}, bool) {
+	current := document
+	for _, segment := range segments {
+		switch selector := segment.(type) {
+		case string:
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[selector]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := current.([]interface{})
+			if !ok || selector >= len(array) {
+				return nil, false
+			}
+			current = array[selector]
+		}
+	}
+	return current, true
+}
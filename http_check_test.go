@@ -0,0 +1,204 @@
+package devops
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPCheckTests struct {
+	suite.Suite
+}
+
+func TestHTTPCheck(t *testing.T) {
+	suite.Run(t, &HTTPCheckTests{})
+}
+
+func (s HTTPCheckTests) getServer() *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("value", r.Header.Get("X-Custom"))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"status":"ok","version":3,"items":[{"name":"a"},{"name":"b"}]}`))
+	}))
+	s.T().Cleanup(server.Close)
+	return server
+}
+
+func (s HTTPCheckTests) TestRunHTTPCheck() {
+	server := s.getServer()
+	report, err := RunHTTPCheck(HTTPCheck{
+		BodyContains:        []string{`"status":"ok"`},
+		BodyMatches:         []string{`"version":\d+`},
+		Client:              server.Client(),
+		ExpectedHeaders:     map[string]string{"Content-Type": "^application/json"},
+		ExpectedJSON:        map[string]interface{}{"$.status": "ok", "$.version": 3, "$.items[1]['name']": "b"},
+		ExpectedStatusCodes: []int{http.StatusOK},
+		Headers:             map[string]string{"X-Custom": "value"},
+		MaxLatency:          5 * time.Second,
+		MinCertificateDays:  1,
+		URL:                 server.URL,
+	})
+	s.Nil(err)
+	s.Empty(report.Failures)
+	s.True(report.Passed)
+	s.Nil(report.Err())
+	s.Equal("GET "+server.URL, report.Name)
+	s.Equal(http.StatusOK, report.StatusCode)
+	s.Greater(report.Latency, time.Duration(0))
+	s.False(report.CertificateExpiresAt.IsZero())
+}
+
+func (s HTTPCheckTests) TestRunHTTPCheck_failures() {
+	server := s.getServer()
+	report, err := RunHTTPCheck(HTTPCheck{
+		BodyContains:        []string{"missing"},
+		BodyMatches:         []string{`"version":"\d+"`},
+		Client:              server.Client(),
+		ExpectedHeaders:     map[string]string{"Content-Type": "^text/html", "X-Missing": "."},
+		ExpectedJSON:        map[string]interface{}{"$.status": "down", "$.items[5]": "a"},
+		ExpectedStatusCodes: []int{http.StatusCreated},
+		Headers:             map[string]string{"X-Custom": "value"},
+		MaxLatency:          time.Nanosecond,
+		MinCertificateDays:  365 * 100,
+		Name:                "api",
+		URL:                 server.URL,
+	})
+	s.Nil(err)
+	s.False(report.Passed)
+	failures := map[string]HTTPCheckFailure{}
+	assertions := []string{}
+	for _, failure := range report.Failures {
+		failures[failure.Assertion+" "+failure.Expected] = failure
+		assertions = append(assertions, failure.Assertion)
+	}
+	s.Equal([]string{"header 'Content-Type'", "header 'X-Missing'"}, assertions[1:3], "failures should be in a deterministic order")
+	s.Equal([]string{"json path '$.items[5]'", "json path '$.status'"}, assertions[5:7], "failures should be in a deterministic order")
+	s.Len(report.Failures, 9)
+	s.Equal("200", failures["status code one of [201]"].Actual)
+	s.Equal("'application/json; charset=utf-8'", failures["header 'Content-Type' a match for '^text/html'"].Actual)
+	s.Equal("no header", failures["header 'X-Missing' a match for '.'"].Actual)
+	s.Contains(failures, "body to contain 'missing'")
+	s.Contains(failures, `body a match for '"version":"\d+"'`)
+	s.Equal(`"ok"`, failures[`json path '$.status' "down"`].Actual)
+	s.Equal("no value", failures[`json path '$.items[5]' "a"`].Actual)
+	s.Contains(failures, "latency at most 1ns")
+	s.Contains(failures, "certificate at least 36500 days to expiry")
+
+	err = report.Err()
+	s.True(errors.Is(err, ErrHTTPCheckFailed))
+	s.Contains(err.Error(), "for 'api'")
+	s.Contains(err.Error(), "status code: expected one of [201] but got 200")
+}
+
+func (s HTTPCheckTests) TestRunHTTPCheck_noResponse() {
+	server := s.getServer()
+	report, err := RunHTTPCheck(HTTPCheck{URL: server.URL})
+	s.Nil(err)
+	s.False(report.Passed)
+	s.Len(report.Failures, 1)
+	s.Equal("response", report.Failures[0].Assertion)
+	s.Contains(report.Failures[0].Actual, "certificate")
+}
+
+func (s HTTPCheckTests) TestRunHTTPChecks() {
+	server := s.getServer()
+	reports, err := RunHTTPChecks([]HTTPCheck{
+		{Client: server.Client(), Headers: map[string]string{"X-Custom": "value"}, URL: server.URL},
+		{Client: server.Client(), Headers: map[string]string{"X-Custom": "value"}, ExpectedStatusCodes: []int{http.StatusNotFound}, URL: server.URL},
+	})
+	s.Nil(err)
+	s.Len(reports, 2)
+	s.True(reports[0].Passed)
+	s.False(reports[1].Passed)
+
+	reports, err = RunHTTPChecks([]HTTPCheck{{URL: server.URL, Client: server.Client(), Headers: map[string]string{"X-Custom": "value"}}, {}})
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Len(reports, 1)
+}
+
+func (s HTTPCheckTests) TestLoadHTTPChecks() {
+	checksPath := filepath.Join(s.T().TempDir(), "checks.yaml")
+	s.Nil(os.WriteFile(checksPath, []byte(`
+checks:
+  - name: health
+    url: https://example.com/healthz
+    expectedStatusCodes: [200, 204]
+    expectedHeaders:
+      Content-Type: ^application/json
+    expectedJSON:
+      $.status: ok
+      $.checks.database: true
+    maxLatency: 500ms
+    minCertificateDays: 14
+  - url: https://example.com/login
+    method: POST
+    headers:
+      Content-Type: application/json
+    body: '{"username":"smoke"}'
+    bodyContains: [token]
+    timeout: 5s
+`), 0644))
+	checks, err := LoadHTTPChecks(checksPath)
+	s.Nil(err)
+	s.Len(checks, 2)
+	s.Equal("health", checks[0].Name)
+	s.Equal([]int{200, 204}, checks[0].ExpectedStatusCodes)
+	s.Equal(map[string]interface{}{"$.status": "ok", "$.checks.database": true}, checks[0].ExpectedJSON)
+	s.Equal(500*time.Millisecond, checks[0].MaxLatency)
+	s.Equal(14, checks[0].MinCertificateDays)
+	s.Equal(http.MethodPost, checks[1].Method)
+	s.Equal(`{"username":"smoke"}`, checks[1].Body)
+	s.Equal(5*time.Second, checks[1].Timeout)
+
+	s.Nil(os.WriteFile(checksPath, []byte("checks:\n  - url: https://example.com\n    expectedStatus: 200\n"), 0644))
+	_, err = LoadHTTPChecks(checksPath)
+	s.NotNil(err)
+	s.Contains(err.Error(), "expectedStatus")
+
+	s.Nil(os.WriteFile(checksPath, []byte("checks:\n  - name: broken\n"), 0644))
+	_, err = LoadHTTPChecks(checksPath)
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "'broken'")
+}
+
+func (s HTTPCheckTests) TestParseJSONPath() {
+	segments, err := parseJSONPath(`$.items[0]['first name'].value`)
+	s.Nil(err)
+	s.Equal([]interface{}{"items", 0, "first name", "value"}, segments)
+
+	segments, err = parseJSONPath("$")
+	s.Nil(err)
+	s.Empty(segments)
+
+	for _, path := range []string{"items", "$..items", "$.items[", "$.items[-1]", "$items"} {
+		_, err := parseJSONPath(path)
+		s.NotNil(err, path)
+	}
+}
+
+func (s HTTPCheckTests) TestHTTPCheck_Validate() {
+	check := HTTPCheck{
+		BodyMatches:         []string{"("},
+		ExpectedHeaders:     map[string]string{"X-Header": "["},
+		ExpectedJSON:        map[string]interface{}{"status": "ok"},
+		ExpectedStatusCodes: []int{42},
+		MaxLatency:          -1,
+		MinCertificateDays:  -1,
+		URL:                 "/path",
+	}
+	err := check.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "invalid body pattern '('")
+	s.Contains(err.Error(), "invalid pattern '[' for header 'X-Header'")
+	s.Contains(err.Error(), "invalid json path 'status'")
+	s.Contains(err.Error(), "invalid status code '42'")
+	s.Contains(err.Error(), "max latency cannot be negative")
+	s.Contains(err.Error(), "min certificate days cannot be negative")
+	s.Contains(err.Error(), "missing host in url")
+}
@@ -0,0 +1,340 @@
+package devops
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	DefaultHTTPClientMinTLSVersion = tls.VersionTLS12
+)
+
+// NewHTTPClientOpts presents configuration for the NewHTTPClient method
+type NewHTTPClientOpts struct {
+	// CACertificatePaths defines paths to PEM-encoded certificate
+	// authority bundles which are trusted in addition to the system's
+	// certificate authorities
+	CACertificatePaths []string
+
+	// ClientCertificatePath defines the path to a PEM-encoded client
+	// certificate (optionally followed by its chain) for mutual TLS,
+	// must be specified with .ClientKeyPath
+	ClientCertificatePath string
+
+	// ClientKeyPath defines the path to the PEM-encoded private key of
+	// the client certificate, must be specified with
+	// .ClientCertificatePath
+	ClientKeyPath string
+
+	// ClientKeyPassphrase defines the passphrase for an encrypted
+	// .ClientKeyPath if applicable. Keys encrypted in the legacy PEM
+	// format, the OpenSSH format or the PKCS#8 format using PBES2 with
+	// PBKDF2 (the default of `openssl genpkey -aes256` and `openssl
+	// pkcs8 -topk8`) are supported, scrypt-based PKCS#8 keys are not
+	ClientKeyPassphrase string
+
+	// DisableProxy when set to true ignores the HTTP_PROXY, HTTPS_PROXY
+	// and NO_PROXY environment variables. Cannot be used with .Proxy
+	DisableProxy bool
+
+	// ExcludeSystemCertificates when set to true only trusts the
+	// certificate authorities in .CACertificatePaths
+	ExcludeSystemCertificates bool
+
+	// InsecureSkipVerify when set to true disables verification of
+	// server certificates and prints a warning to .WarningOutput. This
+	// should only be used for testing
+	InsecureSkipVerify bool
+
+	// MinTLSVersion defines the minimum TLS version to use (eg.
+	// tls.VersionTLS13)
+	//
+	// Defaults to DefaultHTTPClientMinTLSVersion if not specified
+	MinTLSVersion uint16
+
+	// NoProxy defines hosts which should not use .Proxy in the same
+	// format as the NO_PROXY environment variable: "*", host names
+	// which also match their subdomains, domains with a leading "."
+	// which only match subdomains, IP addresses and CIDR ranges, each
+	// optionally followed by ":<port>"
+	NoProxy []string
+
+	// Proxy defines the URL of the proxy to use, the scheme can be one
+	// of "http", "https" or "socks5". If left nil, the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables are used
+	Proxy *url.URL
+
+	// ServerName overrides the host name used to verify server
+	// certificates
+	ServerName string
+
+	// Timeout defines the time limit for requests made by the client,
+	// see http.Client. If left as 0, requests do not time out
+	Timeout time.Duration
+
+	// WarningOutput defines where warnings are written to
+	//
+	// Defaults to os.Stderr if not specified
+	WarningOutput io.Writer
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *NewHTTPClientOpts) SetDefaults() {
+	if o.MinTLSVersion == 0 {
+		o.MinTLSVersion = DefaultHTTPClientMinTLSVersion
+	}
+	if o.WarningOutput == nil {
+		o.WarningOutput = os.Stderr
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the NewHTTPClient method
+func (o NewHTTPClientOpts) Validate() error {
+	errors := []string{}
+
+	if (o.ClientCertificatePath == "") != (o.ClientKeyPath == "") {
+		errors = append(errors, "client certificate and client key must be specified together")
+	}
+
+	if o.DisableProxy && o.Proxy != nil {
+		errors = append(errors, "proxy cannot be used with disable proxy")
+	}
+
+	if o.ExcludeSystemCertificates && len(o.CACertificatePaths) == 0 {
+		errors = append(errors, "missing ca certificates when excluding system certificates")
+	}
+
+	switch o.MinTLSVersion {
+	case tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13:
+	default:
+		errors = append(errors, fmt.Sprintf("unknown min tls version %#04x", o.MinTLSVersion))
+	}
+
+	if o.Proxy != nil {
+		switch o.Proxy.Scheme {
+		case "http", "https", "socks5":
+			if o.Proxy.Host == "" {
+				errors = append(errors, "missing host in proxy url")
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("unsupported proxy scheme '%s'", o.Proxy.Scheme))
+		}
+	}
+
+	if o.Timeout < 0 {
+		errors = append(errors, "timeout cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// NewHTTPClient returns a HTTP client with a transport configured by
+// the options object instance `opts` for use as the .Client of
+// SendHTTPRequest, DownloadFile and other HTTP functions. The client
+// should be reused so that connections can be reused
+func NewHTTPClient(opts NewHTTPClientOpts) (*http.Client, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
+
+	/* #nosec - the minimum version is configurable by the caller */
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+		MinVersion:         opts.MinTLSVersion,
+		ServerName:         opts.ServerName,
+	}
+	if opts.InsecureSkipVerify {
+		fmt.Fprintln(opts.WarningOutput, "WARNING: TLS certificate verification is disabled, connections made by this client can be intercepted")
+	}
+	if len(opts.CACertificatePaths) > 0 {
+		rootCAs, err := loadCACertificates(opts.CACertificatePaths, opts.ExcludeSystemCertificates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create http client: %w", err)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if opts.ClientCertificatePath != "" {
+		certificate, err := loadClientCertificate(opts.ClientCertificatePath, opts.ClientKeyPath, opts.ClientKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create http client: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	switch {
+	case opts.DisableProxy:
+		transport.Proxy = nil
+	case opts.Proxy != nil:
+		proxyURL := *opts.Proxy
+		noProxy := opts.NoProxy
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if isNoProxyHost(req.URL, noProxy) {
+				return nil, nil
+			}
+			return &proxyURL, nil
+		}
+	default:
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+	}, nil
+}
+
+// loadCACertificates returns a certificate pool containing the
+// certificates in the PEM files at `paths`
+func loadCACertificates(paths []string, excludeSystemCertificates bool) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !excludeSystemCertificates {
+		if systemPool, err := x509.SystemCertPool(); err == nil {
+			pool = systemPool
+		}
+	}
+	for _, certificatePath := range paths {
+		/* #nosec - this is required to read the certificates */
+		content, err := ioutil.ReadFile(certificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca certificates at '%s': %w", certificatePath, err)
+		}
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("failed to find any pem-encoded certificates in '%s'", certificatePath)
+		}
+	}
+	return pool, nil
+}
+
+// loadClientCertificate returns the certificate at `certificatePath`
+// with the private key at `keyPath` which is decrypted using
+// `passphrase` if it is encrypted. Encrypted keys can be in the legacy
+// PEM format (`Proc-Type: 4,ENCRYPTED`), the OpenSSH format or the
+// PKCS#8 format (`ENCRYPTED PRIVATE KEY`) using PBES2 with PBKDF2 and
+// AES-CBC or DES-EDE3-CBC
+func loadClientCertificate(certificatePath, keyPath, passphrase string) (tls.Certificate, error) {
+	/* #nosec - this is required to read the certificate */
+	certificateContent, err := ioutil.ReadFile(certificatePath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read client certificate at '%s': %w", certificatePath, err)
+	}
+	/* #nosec - this is required to read the key */
+	keyContent, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read client key at '%s': %w", keyPath, err)
+	}
+
+	if keyBlock, _ := pem.Decode(keyContent); keyBlock != nil && keyBlock.Type == "ENCRYPTED PRIVATE KEY" {
+		// encrypted PKCS#8 keys (eg. from `openssl genpkey -aes256`) are
+		// not supported by ssh.ParseRawPrivateKeyWithPassphrase
+		if passphrase == "" {
+			return tls.Certificate{}, fmt.Errorf("%w for client key at '%s'", ErrPassphraseRequired, keyPath)
+		}
+		keyDER, err := decryptPKCS8PrivateKey(keyBlock.Bytes, []byte(passphrase))
+		if err == nil {
+			if _, parseError := x509.ParsePKCS8PrivateKey(keyDER); parseError != nil {
+				err = errIncorrectPKCS8Passphrase
+			}
+		}
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to parse client key at '%s' using provided passphrase: %w", keyPath, err)
+		}
+		keyContent = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	} else if _, err := ssh.ParseRawPrivateKey(keyContent); err != nil {
+		if _, ok := err.(*ssh.PassphraseMissingError); !ok {
+			return tls.Certificate{}, fmt.Errorf("failed to parse client key at '%s': %w", keyPath, err)
+		}
+		if passphrase == "" {
+			return tls.Certificate{}, fmt.Errorf("%w for client key at '%s': %w", ErrPassphraseRequired, keyPath, err)
+		}
+		privateKey, err := ssh.ParseRawPrivateKeyWithPassphrase(keyContent, []byte(passphrase))
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to parse client key at '%s' using provided passphrase: %w", keyPath, err)
+		}
+		// the decrypted key is re-encoded so that tls.X509KeyPair can
+		// verify that it matches the certificate
+		keyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to encode client key at '%s': %w", keyPath, err)
+		}
+		keyContent = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	}
+
+	certificate, err := tls.X509KeyPair(certificateContent, keyContent)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to use client certificate at '%s' with key at '%s': %w", certificatePath, keyPath, err)
+	}
+	return certificate, nil
+}
+
+// isNoProxyHost returns true if the host of `target` matches any of
+// the `noProxy` entries
+func isNoProxyHost(target *url.URL, noProxy []string) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		switch target.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	hostIP := net.ParseIP(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if hostIP != nil && network.Contains(hostIP) {
+				return true
+			}
+			continue
+		}
+		entryHost, entryPort := entry, ""
+		if splitHost, splitPort, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = splitHost, splitPort
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if hostIP != nil && entryIP.Equal(hostIP) {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(entryHost, ".") {
+			if strings.HasSuffix(host, entryHost) {
+				return true
+			}
+			continue
+		}
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package devops
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientTests struct {
+	suite.Suite
+}
+
+func TestHTTPClient(t *testing.T) {
+	suite.Run(t, &HTTPClientTests{})
+}
+
+// createCertificate creates a certificate signed by `parent` (or a self
+// signed certificate if `parent` is nil)
+func (s HTTPClientTests) createCertificate(template *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Nil(err)
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signerCertificate, signerKey := template, interface{}(privateKey)
+	if parent != nil {
+		signerCertificate, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, signerCertificate, &privateKey.PublicKey, signerKey)
+	s.Nil(err)
+	leaf, err := x509.ParseCertificate(certificateDER)
+	s.Nil(err)
+	return tls.Certificate{Certificate: [][]byte{certificateDER}, PrivateKey: privateKey, Leaf: leaf}
+}
+
+// writeCertificate writes the certificate and its key to PEM files,
+// the key is encrypted if `passphrase` is specified
+func (s HTTPClientTests) writeCertificate(certificate tls.Certificate, name, passphrase string) (string, string) {
+	directory := s.T().TempDir()
+	certificatePath := filepath.Join(directory, name+".crt")
+	keyPath := filepath.Join(directory, name+".key")
+	s.Nil(os.WriteFile(certificatePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0600))
+	keyDER, err := x509.MarshalECPrivateKey(certificate.PrivateKey.(*ecdsa.PrivateKey))
+	s.Nil(err)
+	keyBlock := &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}
+	if passphrase != "" {
+		// legacy PEM encryption is still used by openssl for EC keys
+		keyBlock, err = x509.EncryptPEMBlock(rand.Reader, keyBlock.Type, keyBlock.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+		s.Nil(err)
+	}
+	s.Nil(os.WriteFile(keyPath, pem.EncodeToMemory(keyBlock), 0600))
+	return certificatePath, keyPath
+}
+
+func (s HTTPClientTests) TestNewHTTPClient_mutualTLS() {
+	ca := s.createCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	serverCertificate := s.createCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "service.internal"},
+		DNSNames:    []string{"service.internal"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	clientCertificate := s.createCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+	caPath, _ := s.writeCertificate(ca, "ca", "")
+	clientCertificatePath, clientKeyPath := s.writeCertificate(clientCertificate, "client", "passphrase")
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.Leaf)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+
+	client, err := NewHTTPClient(NewHTTPClientOpts{
+		CACertificatePaths:    []string{caPath},
+		ClientCertificatePath: clientCertificatePath,
+		ClientKeyPath:         clientKeyPath,
+		ClientKeyPassphrase:   "passphrase",
+		DisableProxy:          true,
+		ServerName:            "service.internal",
+	})
+	s.Nil(err)
+	response, err := SendHTTPRequest(SendHTTPRequestOpts{Client: client, URL: serverURL})
+	s.Nil(err)
+	defer response.Body.Close()
+	body := make([]byte, 64)
+	n, _ := response.Body.Read(body)
+	s.Equal("client", string(body[:n]))
+
+	client, err = NewHTTPClient(NewHTTPClientOpts{
+		CACertificatePaths: []string{caPath},
+		DisableProxy:       true,
+		ServerName:         "service.internal",
+	})
+	s.Nil(err)
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{Client: client, URL: serverURL})
+	s.NotNil(err, "requests without a client certificate should be rejected")
+
+	client, err = NewHTTPClient(NewHTTPClientOpts{
+		CACertificatePaths: []string{caPath},
+		DisableProxy:       true,
+	})
+	s.Nil(err)
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{Client: client, URL: serverURL})
+	var hostnameError x509.HostnameError
+	s.True(errors.As(err, &hostnameError), "the server certificate is not valid for the ip address")
+
+	_, err = NewHTTPClient(NewHTTPClientOpts{
+		ClientCertificatePath: clientCertificatePath,
+		ClientKeyPath:         clientKeyPath,
+	})
+	s.True(errors.Is(err, ErrPassphraseRequired))
+}
+
+func (s HTTPClientTests) Test_loadClientCertificate_encryptedPKCS8() {
+	certificatePath := "./tests/certificates/client.crt"
+	for _, keyPath := range []string{
+		"./tests/certificates/client-aes256.key",
+		"./tests/certificates/client-aes128-sha1.key",
+		"./tests/certificates/client-des3.key",
+	} {
+		certificate, err := loadClientCertificate(certificatePath, keyPath, "passphrase")
+		s.Nil(err, keyPath)
+		s.NotNil(certificate.PrivateKey, keyPath)
+
+		_, err = loadClientCertificate(certificatePath, keyPath, "")
+		s.True(errors.Is(err, ErrPassphraseRequired), keyPath)
+		_, err = loadClientCertificate(certificatePath, keyPath, "incorrect")
+		s.NotNil(err, keyPath)
+		s.Contains(err.Error(), "using provided passphrase")
+	}
+
+	_, err := loadClientCertificate(certificatePath, "./tests/certificates/client-scrypt.key", "passphrase")
+	s.NotNil(err)
+	s.Contains(err.Error(), "only PBKDF2 is supported")
+}
+
+func (s HTTPClientTests) TestNewHTTPClient_InsecureSkipVerify() {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	var warnings bytes.Buffer
+	client, err := NewHTTPClient(NewHTTPClientOpts{
+		DisableProxy:       true,
+		InsecureSkipVerify: true,
+		WarningOutput:      &warnings,
+	})
+	s.Nil(err)
+	s.Contains(warnings.String(), "WARNING")
+	response, err := SendHTTPRequest(SendHTTPRequestOpts{Client: client, URL: serverURL})
+	s.Nil(err)
+	response.Body.Close()
+}
+
+func (s HTTPClientTests) TestNewHTTPClient_Proxy() {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "direct")
+	}))
+	defer target.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "proxied %s", r.URL.String())
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	s.Nil(err)
+	// the target is addressed by name so that it is not excluded from
+	// the proxy because it is a loopback address
+	_, port, err := net.SplitHostPort(target.Listener.Addr().String())
+	s.Nil(err)
+	targetURL, err := url.Parse("http://service.internal:" + port + "/path")
+	s.Nil(err)
+
+	client, err := NewHTTPClient(NewHTTPClientOpts{Proxy: proxyURL})
+	s.Nil(err)
+	response, err := SendHTTPRequest(SendHTTPRequestOpts{Client: client, URL: targetURL})
+	s.Nil(err)
+	defer response.Body.Close()
+	body := make([]byte, 128)
+	n, _ := response.Body.Read(body)
+	s.Equal("proxied "+targetURL.String(), string(body[:n]))
+
+	client, err = NewHTTPClient(NewHTTPClientOpts{Proxy: proxyURL, NoProxy: []string{"127.0.0.0/8"}})
+	s.Nil(err)
+	targetURL, err = url.Parse(target.URL)
+	s.Nil(err)
+	response, err = SendHTTPRequest(SendHTTPRequestOpts{Client: client, URL: targetURL})
+	s.Nil(err)
+	defer response.Body.Close()
+	n, _ = response.Body.Read(body)
+	s.Equal("direct", string(body[:n]))
+}
+
+func (s HTTPClientTests) TestNewHTTPClientOpts_Validate() {
+	err := NewHTTPClientOpts{
+		ClientCertificatePath: "client.crt",
+		DisableProxy:          true,
+		MinTLSVersion:         1,
+		Proxy:                 &url.URL{Scheme: "ftp", Host: "proxy"},
+		Timeout:               -1,
+	}.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "client certificate and client key must be specified together")
+	s.Contains(err.Error(), "proxy cannot be used with disable proxy")
+	s.Contains(err.Error(), "unknown min tls version")
+	s.Contains(err.Error(), "unsupported proxy scheme 'ftp'")
+	s.Contains(err.Error(), "timeout cannot be negative")
+}
+
+func (s HTTPClientTests) Test_isNoProxyHost() {
+	noProxy := []string{"example.com", ".internal", "10.0.0.0/8", "192.168.1.1", "localhost:8080"}
+	for target, expected := range map[string]bool{
+		"https://example.com":         true,
+		"https://api.example.com":     true,
+		"https://notexample.com":      false,
+		"https://internal":            false,
+		"https://service.internal":    true,
+		"http://10.1.2.3:8000":        true,
+		"http://11.1.2.3":             false,
+		"http://192.168.1.1":          true,
+		"http://localhost:8080/path":  true,
+		"http://localhost:8081/path":  false,
+		"https://other.org/localhost": false,
+	} {
+		targetURL, err := url.Parse(target)
+		s.Nil(err)
+		s.Equal(expected, isNoProxyHost(targetURL, noProxy), target)
+	}
+	targetURL, _ := url.Parse("https://anything.org")
+	s.True(isNoProxyHost(targetURL, []string{"*"}))
+}
@@ -0,0 +1,511 @@
+package devops
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	RecordingFormatHAR     = "har"
+	RecordingFormatFixture = "fixture"
+
+	DefaultRecordingFormat = RecordingFormatHAR
+	DefaultHARVersion      = "1.2"
+	DefaultHARCreatorName  = "go-devops"
+)
+
+// NewHTTPRecorderOpts presents configuration for the
+// NewHTTPRecorder method
+type NewHTTPRecorderOpts struct {
+	// Format defines the format of the recording, one of
+	// RecordingFormatHAR or RecordingFormatFixture
+	//
+	// Defaults to DefaultRecordingFormat if not specified
+	Format string
+
+	// Path defines the path of the file the recording is saved to
+	Path string
+
+	// RedactBodyPatterns defines patterns which are redacted from
+	// request and response bodies. If a pattern has a capturing group,
+	// only the first group is redacted (eg. `"password":"([^"]*)"`).
+	// The recorded hash of the request body is of the redacted body,
+	// the same patterns have to be provided to the HTTPReplayer for
+	// requests to be matched by HTTPMatchBodyHash
+	RedactBodyPatterns []*regexp.Regexp
+
+	// RedactHeaders defines the names of headers to redact in addition
+	// to headers with sensitive names (eg. "Authorization")
+	RedactHeaders []string
+
+	// Transport defines the transport used to send requests. If left
+	// nil, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *NewHTTPRecorderOpts) SetDefaults() {
+	if o.Format == "" {
+		o.Format = DefaultRecordingFormat
+	}
+	if o.Transport == nil {
+		o.Transport = http.DefaultTransport
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the NewHTTPRecorder method
+func (o NewHTTPRecorderOpts) Validate() error {
+	errors := []string{}
+
+	if o.Format != RecordingFormatHAR && o.Format != RecordingFormatFixture {
+		errors = append(errors, fmt.Sprintf("unknown format '%s'", o.Format))
+	}
+
+	if o.Path == "" {
+		errors = append(errors, "missing path")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// HTTPRecorder is a http.RoundTripper which records requests and
+// their responses so that they can be replayed by a HTTPReplayer. The
+// bodies of requests and responses are read into memory. It is safe
+// for concurrent use
+type HTTPRecorder struct {
+	opts       NewHTTPRecorderOpts
+	mutex      sync.Mutex
+	recordings []httpRecording
+}
+
+// NewHTTPRecorder returns a HTTPRecorder as configured by the options
+// object instance `opts`, use .GetClient to get a client which can be
+// used as the .Client of SendHTTPRequest and DownloadFile
+func NewHTTPRecorder(opts NewHTTPRecorderOpts) (*HTTPRecorder, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to create http recorder: %w", err)
+	}
+	return &HTTPRecorder{opts: opts}, nil
+}
+
+// GetClient returns a HTTP client which uses this recorder
+func (r *HTTPRecorder) GetClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *HTTPRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		// the request is copied as a RoundTripper should not modify it
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+	startedAt := time.Now()
+	res, err := r.opts.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	// the hash of the redacted body is recorded so that redacted
+	// values cannot be recovered from it
+	redactedRequestBody := redactBody(requestBody, r.opts.RedactBodyPatterns)
+	recording := httpRecording{
+		Duration:          time.Since(startedAt),
+		Method:            req.Method,
+		RequestBody:       redactedRequestBody,
+		RequestBodySHA256: getBodySHA256(redactedRequestBody),
+		RequestHeaders:    r.redactHeaders(req.Header),
+		ResponseBody:      redactBody(responseBody, r.opts.RedactBodyPatterns),
+		ResponseHeaders:   r.redactHeaders(res.Header),
+		StartedAt:         startedAt,
+		StatusCode:        res.StatusCode,
+		URL:               redactURL(req.URL),
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.recordings = append(r.recordings, recording)
+	return res, nil
+}
+
+// Save writes all recorded requests to .Path
+func (r *HTTPRecorder) Save() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var content interface{}
+	switch r.opts.Format {
+	case RecordingFormatHAR:
+		content = newHAR(r.recordings)
+	case RecordingFormatFixture:
+		fixtures := []httpFixture{}
+		for _, recording := range r.recordings {
+			fixtures = append(fixtures, newHTTPFixture(recording))
+		}
+		content = fixtures
+	}
+	serialised, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialise recording: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.opts.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", r.opts.Path, err)
+	}
+	if err := ioutil.WriteFile(r.opts.Path, append(serialised, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write recording to '%s': %w", r.opts.Path, err)
+	}
+	return nil
+}
+
+// redactHeaders returns a copy of the headers with sensitive values
+// redacted
+func (r *HTTPRecorder) redactHeaders(headers http.Header) http.Header {
+	redacted := http.Header{}
+	for name, values := range headers {
+		isRedacted := isSensitiveName(name)
+		for _, redactedName := range r.opts.RedactHeaders {
+			isRedacted = isRedacted || strings.EqualFold(name, redactedName)
+		}
+		for _, value := range values {
+			if isRedacted {
+				value = DefaultRedactedValue
+			}
+			redacted.Add(name, value)
+		}
+	}
+	return redacted
+}
+
+// redactBody returns the body with matches of `patterns` redacted
+func redactBody(body []byte, patterns []*regexp.Regexp) []byte {
+	for _, pattern := range patterns {
+		var redacted []byte
+		lastIndex := 0
+		for _, match := range pattern.FindAllSubmatchIndex(body, -1) {
+			start, end := match[0], match[1]
+			if len(match) >= 4 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			redacted = append(redacted, body[lastIndex:start]...)
+			redacted = append(redacted, DefaultRedactedValue...)
+			lastIndex = end
+		}
+		if redacted != nil {
+			body = append(redacted, body[lastIndex:]...)
+		}
+	}
+	return body
+}
+
+// redactURL returns the URL with its password and the values of query
+// parameters with sensitive names redacted
+func redactURL(target *url.URL) string {
+	redacted := *target
+	if redacted.User != nil {
+		if _, hasPassword := redacted.User.Password(); hasPassword {
+			redacted.User = url.UserPassword(redacted.User.Username(), DefaultRedactedValue)
+		}
+	}
+	if redacted.RawQuery != "" {
+		redacted.RawQuery = redactSensitiveValues(redacted.Query()).Encode()
+	}
+	return redacted.String()
+}
+
+// getBodySHA256 returns the hex-encoded SHA256 hash of the body or an
+// empty string if the body is empty
+func getBodySHA256(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+// httpRecording is a recorded request and its response
+type httpRecording struct {
+	Duration          time.Duration
+	Method            string
+	RequestBody       []byte
+	RequestBodySHA256 string
+	RequestHeaders    http.Header
+	ResponseBody      []byte
+	ResponseHeaders   http.Header
+	StartedAt         time.Time
+	StatusCode        int
+	URL               string
+}
+
+// encodeBody returns the body as a string and its encoding, bodies
+// which are not valid UTF-8 are base64-encoded
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeBody reverses encodeBody
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return nil, fmt.Errorf("unknown body encoding '%s'", encoding)
+}
+
+// httpFixture is a recording in the fixture format
+type httpFixture struct {
+	Request struct {
+		Method       string      `json:"method"`
+		URL          string      `json:"url"`
+		Headers      http.Header `json:"headers,omitempty"`
+		Body         string      `json:"body,omitempty"`
+		BodyEncoding string      `json:"bodyEncoding,omitempty"`
+		BodySHA256   string      `json:"bodySHA256,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode   int         `json:"statusCode"`
+		Headers      http.Header `json:"headers,omitempty"`
+		Body         string      `json:"body,omitempty"`
+		BodyEncoding string      `json:"bodyEncoding,omitempty"`
+	} `json:"response"`
+}
+
+// newHTTPFixture converts a recording to the fixture format
+func newHTTPFixture(recording httpRecording) httpFixture {
+	var fixture httpFixture
+	fixture.Request.Method = recording.Method
+	fixture.Request.URL = recording.URL
+	fixture.Request.Headers = recording.RequestHeaders
+	fixture.Request.Body, fixture.Request.BodyEncoding = encodeBody(recording.RequestBody)
+	fixture.Request.BodySHA256 = recording.RequestBodySHA256
+	fixture.Response.StatusCode = recording.StatusCode
+	fixture.Response.Headers = recording.ResponseHeaders
+	fixture.Response.Body, fixture.Response.BodyEncoding = encodeBody(recording.ResponseBody)
+	return fixture
+}
+
+// getRecording converts the fixture to a recording
+func (f httpFixture) getRecording() (httpRecording, error) {
+	requestBody, err := decodeBody(f.Request.Body, f.Request.BodyEncoding)
+	if err != nil {
+		return httpRecording{}, err
+	}
+	responseBody, err := decodeBody(f.Response.Body, f.Response.BodyEncoding)
+	if err != nil {
+		return httpRecording{}, err
+	}
+	return httpRecording{
+		Method:            f.Request.Method,
+		RequestBody:       requestBody,
+		RequestBodySHA256: f.Request.BodySHA256,
+		RequestHeaders:    f.Request.Headers,
+		ResponseBody:      responseBody,
+		ResponseHeaders:   f.Response.Headers,
+		StatusCode:        f.Response.StatusCode,
+		URL:               f.Request.URL,
+	}, nil
+}
+
+// har is the subset of the HTTP Archive 1.2 format used for
+// recordings, see http://www.softwareishard.com/blog/har-12-spec/
+type har struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	} `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	BodySHA256  string         `json:"_bodySHA256,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// newHARNameValues converts headers or query parameters to HAR
+// name-value pairs sorted by name
+func newHARNameValues(values map[string][]string) []harNameValue {
+	nameValues := []harNameValue{}
+	for name, nameValuesOfName := range values {
+		for _, value := range nameValuesOfName {
+			nameValues = append(nameValues, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(nameValues, func(i, j int) bool { return nameValues[i].Name < nameValues[j].Name })
+	return nameValues
+}
+
+// getHARHeaders converts HAR name-value pairs to headers
+func getHARHeaders(nameValues []harNameValue) http.Header {
+	headers := http.Header{}
+	for _, nameValue := range nameValues {
+		headers.Add(nameValue.Name, nameValue.Value)
+	}
+	return headers
+}
+
+// newHAR converts recordings to the HAR format
+func newHAR(recordings []httpRecording) har {
+	var archive har
+	archive.Log.Version = DefaultHARVersion
+	archive.Log.Creator.Name = DefaultHARCreatorName
+	archive.Log.Entries = []harEntry{}
+	for _, recording := range recordings {
+		milliseconds := float64(recording.Duration) / float64(time.Millisecond)
+		entry := harEntry{
+			StartedDateTime: recording.StartedAt.Format(time.RFC3339Nano),
+			Time:            milliseconds,
+			Request: harRequest{
+				Method:      recording.Method,
+				URL:         recording.URL,
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     newHARNameValues(recording.RequestHeaders),
+				QueryString: []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    int64(len(recording.RequestBody)),
+				BodySHA256:  recording.RequestBodySHA256,
+			},
+			Response: harResponse{
+				Status:      recording.StatusCode,
+				StatusText:  http.StatusText(recording.StatusCode),
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     newHARNameValues(recording.ResponseHeaders),
+				Content: harContent{
+					Size:     int64(len(recording.ResponseBody)),
+					MimeType: recording.ResponseHeaders.Get("Content-Type"),
+				},
+				RedirectURL: recording.ResponseHeaders.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    int64(len(recording.ResponseBody)),
+			},
+		}
+		entry.Timings.Wait = milliseconds
+		if recordedURL, err := url.Parse(recording.URL); err == nil {
+			entry.Request.QueryString = newHARNameValues(recordedURL.Query())
+		}
+		if len(recording.RequestBody) > 0 {
+			entry.Request.PostData = &harPostData{MimeType: recording.RequestHeaders.Get("Content-Type")}
+			entry.Request.PostData.Text, entry.Request.PostData.Encoding = encodeBody(recording.RequestBody)
+		}
+		entry.Response.Content.Text, entry.Response.Content.Encoding = encodeBody(recording.ResponseBody)
+		archive.Log.Entries = append(archive.Log.Entries, entry)
+	}
+	return archive
+}
+
+// getRecordings converts the HAR entries to recordings
+func (h har) getRecordings() ([]httpRecording, error) {
+	recordings := []httpRecording{}
+	for index, entry := range h.Log.Entries {
+		recording := httpRecording{
+			Method:            entry.Request.Method,
+			RequestBodySHA256: entry.Request.BodySHA256,
+			RequestHeaders:    getHARHeaders(entry.Request.Headers),
+			ResponseHeaders:   getHARHeaders(entry.Response.Headers),
+			StatusCode:        entry.Response.Status,
+			URL:               entry.Request.URL,
+		}
+		var err error
+		if entry.Request.PostData != nil {
+			if recording.RequestBody, err = decodeBody(entry.Request.PostData.Text, entry.Request.PostData.Encoding); err != nil {
+				return nil, fmt.Errorf("failed to decode request body of entry %v: %w", index, err)
+			}
+		}
+		if recording.ResponseBody, err = decodeBody(entry.Response.Content.Text, entry.Response.Content.Encoding); err != nil {
+			return nil, fmt.Errorf("failed to decode response body of entry %v: %w", index, err)
+		}
+		recordings = append(recordings, recording)
+	}
+	return recordings, nil
+}
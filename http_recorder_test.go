@@ -0,0 +1,189 @@
+package devops
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPRecorderTests struct {
+	suite.Suite
+}
+
+func TestHTTPRecorder(t *testing.T) {
+	suite.Run(t, &HTTPRecorderTests{})
+}
+
+// record sends requests to a test server through a HTTPRecorder and
+// returns the path of the saved recording
+func (s HTTPRecorderTests) record(format string) (string, *url.URL) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("X-Request-Number", fmt.Sprint(requests))
+		if r.URL.Path == "/binary" {
+			w.Write([]byte{0xff, 0x00, 0xfe})
+			return
+		}
+		fmt.Fprintf(w, `{"request":%v,"method":"%s","body":"%s","password":"hunter2"}`, requests, r.Method, string(body))
+	}))
+	s.T().Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+
+	recordingPath := filepath.Join(s.T().TempDir(), "recordings", "recording.json")
+	recorder, err := NewHTTPRecorder(NewHTTPRecorderOpts{
+		Format:             format,
+		Path:               recordingPath,
+		RedactBodyPatterns: []*regexp.Regexp{regexp.MustCompile(`"password":"([^"]*)"`)},
+		RedactHeaders:      []string{"X-Internal"},
+	})
+	s.Nil(err)
+	for _, request := range []SendHTTPRequestOpts{
+		{Auth: BearerToken{Token: "token"}, URL: serverURL.JoinPath("items")},
+		{URL: serverURL.JoinPath("items")},
+		{Body: []byte("first"), Headers: map[string][]string{"X-Internal": {"secret"}}, Method: http.MethodPost, URL: serverURL.JoinPath("items")},
+		{Body: []byte("second"), Method: http.MethodPost, URL: serverURL.JoinPath("items")},
+		{URL: serverURL.JoinPath("binary")},
+	} {
+		request.Client = recorder.GetClient()
+		res, err := SendHTTPRequest(request)
+		s.Nil(err)
+		_, err = ioutil.ReadAll(res.Body)
+		s.Nil(err)
+		res.Body.Close()
+	}
+	s.Nil(recorder.Save())
+	return recordingPath, serverURL
+}
+
+func (s HTTPRecorderTests) TestHTTPRecorder_redaction() {
+	for _, format := range []string{RecordingFormatHAR, RecordingFormatFixture} {
+		recordingPath, _ := s.record(format)
+		content, err := ioutil.ReadFile(recordingPath)
+		s.Nil(err)
+		s.NotContains(string(content), "token", format)
+		s.NotContains(string(content), "hunter2", format)
+		s.NotContains(string(content), "session=abc", format)
+		s.NotContains(string(content), "secret", format)
+		s.Contains(string(content), DefaultRedactedValue, format)
+		if format == RecordingFormatHAR {
+			s.Contains(string(content), `"version": "1.2"`)
+		}
+	}
+}
+
+func (s HTTPRecorderTests) TestHTTPRecorder_bodyHashRedaction() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	patterns := []*regexp.Regexp{regexp.MustCompile(`"password":"([^"]*)"`)}
+	recordingPath := filepath.Join(s.T().TempDir(), "recording.json")
+	recorder, err := NewHTTPRecorder(NewHTTPRecorderOpts{Path: recordingPath, RedactBodyPatterns: patterns})
+	s.Nil(err)
+	requestBody := []byte(`{"user":"alice","password":"hunter2"}`)
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{Body: requestBody, Client: recorder.GetClient(), Method: http.MethodPost, URL: serverURL})
+	s.Nil(err)
+	res.Body.Close()
+	s.Nil(recorder.Save())
+	content, err := ioutil.ReadFile(recordingPath)
+	s.Nil(err)
+	s.NotContains(string(content), getBodySHA256(requestBody), "the hash of the unredacted body should not be recorded")
+	s.Contains(string(content), getBodySHA256([]byte(`{"user":"alice","password":"REDACTED"}`)))
+
+	for _, redactBodyPatterns := range [][]*regexp.Regexp{nil, patterns} {
+		replayer, err := NewHTTPReplayer(NewHTTPReplayerOpts{
+			MatchBy:            []string{HTTPMatchBodyHash},
+			Path:               recordingPath,
+			RedactBodyPatterns: redactBodyPatterns,
+		})
+		s.Nil(err)
+		_, err = SendHTTPRequest(SendHTTPRequestOpts{Body: requestBody, Client: replayer.GetClient(), Method: http.MethodPost, URL: serverURL})
+		if redactBodyPatterns == nil {
+			s.True(errors.Is(err, ErrNoRecordedResponse), "request bodies should be redacted before matching")
+		} else {
+			s.Nil(err)
+		}
+	}
+}
+
+func (s HTTPRecorderTests) TestHTTPReplayer() {
+	for _, format := range []string{RecordingFormatHAR, RecordingFormatFixture} {
+		recordingPath, serverURL := s.record(format)
+		replayer, err := NewHTTPReplayer(NewHTTPReplayerOpts{
+			MatchBy: []string{HTTPMatchMethod, HTTPMatchURL, HTTPMatchBodyHash},
+			Path:    recordingPath,
+		})
+		s.Nil(err)
+		s.Equal(5, replayer.GetUnusedCount())
+
+		send := func(opts SendHTTPRequestOpts) (string, error) {
+			opts.Client = replayer.GetClient()
+			res, err := SendHTTPRequest(opts)
+			if err != nil {
+				return "", err
+			}
+			defer res.Body.Close()
+			body, err := ioutil.ReadAll(res.Body)
+			s.Nil(err)
+			return string(body), nil
+		}
+		body, err := send(SendHTTPRequestOpts{Body: []byte("second"), Method: http.MethodPost, URL: serverURL.JoinPath("items")})
+		s.Nil(err, format)
+		s.Contains(body, `"request":4`, "requests should be matched by their body")
+		body, err = send(SendHTTPRequestOpts{URL: serverURL.JoinPath("items")})
+		s.Nil(err, format)
+		s.Contains(body, `"request":1`)
+		s.Contains(body, `"password":"REDACTED"`)
+		body, err = send(SendHTTPRequestOpts{URL: serverURL.JoinPath("items")})
+		s.Nil(err, format)
+		s.Contains(body, `"request":2`, "matching responses should be served in order")
+		body, err = send(SendHTTPRequestOpts{URL: serverURL.JoinPath("binary")})
+		s.Nil(err, format)
+		s.Equal(string([]byte{0xff, 0x00, 0xfe}), body)
+		s.Equal(1, replayer.GetUnusedCount())
+
+		_, err = send(SendHTTPRequestOpts{URL: serverURL.JoinPath("items")})
+		s.True(errors.Is(err, ErrNoRecordedResponse), "responses should not be repeated")
+		_, err = send(SendHTTPRequestOpts{Body: []byte("third"), Method: http.MethodPost, URL: serverURL.JoinPath("items")})
+		s.True(errors.Is(err, ErrNoRecordedResponse))
+		_, err = send(SendHTTPRequestOpts{URL: serverURL.JoinPath("unknown")})
+		s.True(errors.Is(err, ErrNoRecordedResponse))
+		s.Contains(err.Error(), "/unknown")
+	}
+}
+
+func (s HTTPRecorderTests) TestHTTPReplayer_AllowRepeats() {
+	recordingPath, serverURL := s.record(RecordingFormatFixture)
+	replayer, err := NewHTTPReplayer(NewHTTPReplayerOpts{AllowRepeats: true, Path: recordingPath})
+	s.Nil(err)
+	for _, expected := range []string{"1", "2", "2"} {
+		res, err := SendHTTPRequest(SendHTTPRequestOpts{Client: replayer.GetClient(), URL: serverURL.JoinPath("items")})
+		s.Nil(err)
+		res.Body.Close()
+		s.Equal(expected, res.Header.Get("X-Request-Number"))
+	}
+}
+
+func (s HTTPRecorderTests) TestHTTPRecorder_Validate() {
+	_, err := NewHTTPRecorder(NewHTTPRecorderOpts{Format: "xml"})
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "unknown format 'xml'")
+	s.Contains(err.Error(), "missing path")
+	_, err = NewHTTPReplayer(NewHTTPReplayerOpts{MatchBy: []string{"headers"}})
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "unknown match property 'headers'")
+}
@@ -0,0 +1,243 @@
+package devops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	HTTPMatchBodyHash = "bodyHash"
+	HTTPMatchMethod   = "method"
+	HTTPMatchURL      = "url"
+)
+
+// DefaultHTTPMatchBy defines the default properties used to match
+// requests to recorded requests
+var DefaultHTTPMatchBy = []string{HTTPMatchMethod, HTTPMatchURL}
+
+// NewHTTPReplayerOpts presents configuration for the
+// NewHTTPReplayer method
+type NewHTTPReplayerOpts struct {
+	// AllowRepeats when set to true serves the last matching recorded
+	// response again once all matching responses have been served
+	AllowRepeats bool
+
+	// MatchBy defines the properties used to match requests to
+	// recorded requests, any of HTTPMatchMethod, HTTPMatchURL and
+	// HTTPMatchBodyHash
+	//
+	// Defaults to DefaultHTTPMatchBy if not specified
+	MatchBy []string
+
+	// Path defines the path of a recording saved by a HTTPRecorder in
+	// either the RecordingFormatHAR or RecordingFormatFixture format
+	Path string
+
+	// RedactBodyPatterns defines patterns which are redacted from
+	// request bodies before they are hashed for HTTPMatchBodyHash,
+	// this should be the .RedactBodyPatterns of the HTTPRecorder which
+	// saved the recording
+	RedactBodyPatterns []*regexp.Regexp
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *NewHTTPReplayerOpts) SetDefaults() {
+	if len(o.MatchBy) == 0 {
+		o.MatchBy = DefaultHTTPMatchBy
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the NewHTTPReplayer method
+func (o NewHTTPReplayerOpts) Validate() error {
+	errors := []string{}
+
+	for _, matchBy := range o.MatchBy {
+		switch matchBy {
+		case HTTPMatchBodyHash, HTTPMatchMethod, HTTPMatchURL:
+		default:
+			errors = append(errors, fmt.Sprintf("unknown match property '%s'", matchBy))
+		}
+	}
+
+	if o.Path == "" {
+		errors = append(errors, "missing path")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// HTTPReplayer is a http.RoundTripper which serves responses recorded
+// by a HTTPRecorder without making any requests. Matching recorded
+// responses are served in the order they were recorded and
+// ErrNoRecordedResponse is returned for requests which do not match
+// any remaining recorded request. It is safe for concurrent use
+type HTTPReplayer struct {
+	opts       NewHTTPReplayerOpts
+	mutex      sync.Mutex
+	recordings []httpRecording
+	served     []int
+}
+
+// NewHTTPReplayer returns a HTTPReplayer for the recording at .Path as
+// configured by the options object instance `opts`, use .GetClient to
+// get a client which can be used as the .Client of SendHTTPRequest and
+// DownloadFile
+func NewHTTPReplayer(opts NewHTTPReplayerOpts) (*HTTPReplayer, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to create http replayer: %w", err)
+	}
+	/* #nosec - this is required to read the recording */
+	content, err := ioutil.ReadFile(opts.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording at '%s': %w", opts.Path, err)
+	}
+	recordings, err := parseHTTPRecordings(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recording at '%s': %w", opts.Path, err)
+	}
+	return &HTTPReplayer{
+		opts:       opts,
+		recordings: recordings,
+		served:     make([]int, len(recordings)),
+	}, nil
+}
+
+// parseHTTPRecordings parses recordings in either format, HAR files
+// are JSON objects while fixture files are JSON arrays
+func parseHTTPRecordings(content []byte) ([]httpRecording, error) {
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		var fixtures []httpFixture
+		if err := json.Unmarshal(content, &fixtures); err != nil {
+			return nil, err
+		}
+		recordings := []httpRecording{}
+		for index, fixture := range fixtures {
+			recording, err := fixture.getRecording()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode fixture %v: %w", index, err)
+			}
+			recordings = append(recordings, recording)
+		}
+		return recordings, nil
+	}
+	var archive har
+	if err := json.Unmarshal(content, &archive); err != nil {
+		return nil, err
+	}
+	return archive.getRecordings()
+}
+
+// GetClient returns a HTTP client which uses this replayer
+func (r *HTTPReplayer) GetClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// GetUnusedCount returns the number of recorded responses which have
+// not been served, use this at the end of a test to verify that all
+// expected requests were made
+func (r *HTTPReplayer) GetUnusedCount() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	unused := 0
+	for _, served := range r.served {
+		if served == 0 {
+			unused++
+		}
+	}
+	return unused
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *HTTPReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	requestURL := redactURL(req.URL)
+	bodySHA256 := getBodySHA256(redactBody(requestBody, r.opts.RedactBodyPatterns))
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lastMatch := -1
+	for index, recording := range r.recordings {
+		if !r.isMatch(recording, req.Method, requestURL, bodySHA256) {
+			continue
+		}
+		lastMatch = index
+		if r.served[index] == 0 {
+			break
+		}
+	}
+	if lastMatch < 0 || (r.served[lastMatch] > 0 && !r.opts.AllowRepeats) {
+		return nil, fmt.Errorf("%w for %s '%s' in '%s'", ErrNoRecordedResponse, req.Method, requestURL, r.opts.Path)
+	}
+	r.served[lastMatch]++
+	recording := r.recordings[lastMatch]
+	return &http.Response{
+		Body:          io.NopCloser(bytes.NewReader(recording.ResponseBody)),
+		ContentLength: int64(len(recording.ResponseBody)),
+		Header:        recording.ResponseHeaders.Clone(),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Request:       req,
+		Status:        fmt.Sprintf("%v %s", recording.StatusCode, http.StatusText(recording.StatusCode)),
+		StatusCode:    recording.StatusCode,
+	}, nil
+}
+
+// isMatch returns true if the recording matches the request on all of
+// the properties in .MatchBy
+func (r *HTTPReplayer) isMatch(recording httpRecording, method, requestURL, bodySHA256 string) bool {
+	for _, matchBy := range r.opts.MatchBy {
+		switch matchBy {
+		case HTTPMatchBodyHash:
+			if recording.RequestBodySHA256 != bodySHA256 {
+				return false
+			}
+		case HTTPMatchMethod:
+			if recording.Method != method {
+				return false
+			}
+		case HTTPMatchURL:
+			if !isSameURL(recording.URL, requestURL) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isSameURL returns true if the URLs are the same regardless of the
+// order of their query parameters
+func isSameURL(this, that string) bool {
+	thisURL, err := url.Parse(this)
+	if err != nil {
+		return this == that
+	}
+	thatURL, err := url.Parse(that)
+	if err != nil {
+		return false
+	}
+	thisURL.RawQuery = thisURL.Query().Encode()
+	thatURL.RawQuery = thatURL.Query().Encode()
+	return thisURL.String() == thatURL.String()
+}
@@ -38,11 +38,11 @@ var projectDirMap = map[ProjectType][]string{
 func IsProjectType(pathToDirectory string, projectType ProjectType) (bool, error) {
 	normalizedPath, err := NormalizeLocalPath(pathToDirectory)
 	if err != nil {
-		return false, fmt.Errorf("failed to normalize input path '%s': %s", pathToDirectory, err)
+		return false, fmt.Errorf("failed to normalize input path '%s': %w", pathToDirectory, err)
 	}
 	fileListings, err := ioutil.ReadDir(normalizedPath)
 	if err != nil {
-		return false, fmt.Errorf("failed to access normalized path '%s': %s", normalizedPath, err)
+		return false, fmt.Errorf("failed to access normalized path '%s': %w", normalizedPath, err)
 	}
 	directories := []string{}
 	files := []string{}
@@ -43,6 +43,16 @@ func (e LoadConfigurationErrors) Error() string {
 	return fmt.Sprintf("LoadConfiguration/err[%v]: ['%s']", codes, strings.Join(messages, "', '"))
 }
 
+// Unwrap returns the individual LoadConfigurationError instances so
+// that `errors.Is` and `errors.As` can be used on the collection
+func (e LoadConfigurationErrors) Unwrap() []error {
+	errs := []error{}
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
 type LoadConfigurationError struct {
 	Code    int
 	Message string
@@ -52,7 +62,29 @@ func (e LoadConfigurationError) Error() string {
 	return fmt.Sprintf("LoadConfiguration/err[%v]: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the sentinel error corresponding to the .Code of
+// this error so that it can be matched using `errors.Is`
+func (e LoadConfigurationError) Unwrap() error {
+	switch e.Code {
+	case ErrorLoadConfigurationPrereqs:
+		return ErrConfigPrereqs
+	case ErrorLoadConfigurationNotFound:
+		return ErrConfigNotFound
+	case ErrorLoadConfigurationInvalidType:
+		return ErrConfigInvalidType
+	case ErrorLoadConfigurationInvalidValue:
+		return ErrConfigInvalidValue
+	}
+	return nil
+}
+
 func LoadConfiguration(config interface{}) error {
+	return loadConfiguration(config, os.LookupEnv)
+}
+
+// loadConfiguration loads values into the provided config using the
+// lookup function to retrieve values by their environment key
+func loadConfiguration(config interface{}, lookup func(key string) (string, bool)) error {
 	errors := LoadConfigurationErrors{}
 
 	c := newConfiguration(config)
@@ -69,7 +101,7 @@ func LoadConfiguration(config interface{}) error {
 
 	for _, field := range c.Fields {
 		environmentKey := field.GetEnvironmentKey()
-		environmentValue, isEnvironmentDefined := os.LookupEnv(environmentKey)
+		environmentValue, isEnvironmentDefined := lookup(environmentKey)
 		defaultValue := field.GetDefaultValue()
 		fieldType := field.Type.String()
 		switch fieldType {
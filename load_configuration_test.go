@@ -1,6 +1,7 @@
 package devops
 
 import (
+	"errors"
 	"os"
 	"testing"
 
@@ -49,6 +50,21 @@ func (s LoadConfigurationTest) TestLoadConfigurationError() {
 	s.Contains(message, "expected message")
 }
 
+func (s LoadConfigurationTest) TestLoadConfigurationErrors_Unwrap() {
+	errs := LoadConfigurationErrors{
+		{ErrorLoadConfigurationNotFound, "expected message 1"},
+		{ErrorLoadConfigurationInvalidValue, "expected message 2"},
+	}
+	s.True(errors.Is(errs, ErrConfigNotFound))
+	s.True(errors.Is(errs, ErrConfigInvalidValue))
+	s.False(errors.Is(errs, ErrConfigInvalidType))
+	s.False(errors.Is(errs, ErrConfigPrereqs))
+
+	var err LoadConfigurationError
+	s.True(errors.As(errs, &err))
+	s.Equal(ErrorLoadConfigurationNotFound, err.Code)
+}
+
 func (s LoadConfigurationTest) TestLoadConfiguration_validation() {
 	type testStruct struct{}
 	err := LoadConfiguration(testStruct{})
@@ -125,6 +141,7 @@ func (s LoadConfigurationTest) TestLoadConfiguration_Bool_notFoundError() {
 	err := LoadConfiguration(&instance)
 	s.NotNil(err)
 	s.Equal(ErrorLoadConfigurationNotFound, err.(LoadConfigurationErrors).GetCode())
+	s.True(errors.Is(err, ErrConfigNotFound))
 }
 
 func (s LoadConfigurationTest) TestLoadConfiguration_Bool_parseError() {
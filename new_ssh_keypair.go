@@ -1,9 +1,13 @@
 package devops
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"strings"
@@ -12,34 +16,106 @@ import (
 )
 
 const (
-	DefaultSSHKeyLength = 8192
+	SSHKeyTypeECDSA   = "ecdsa"
+	SSHKeyTypeEd25519 = "ed25519"
+	SSHKeyTypeRSA     = "rsa"
+
+	DefaultSSHKeyType        = SSHKeyTypeRSA
+	DefaultSSHKeyLength      = 4096
+	DefaultSSHECDSAKeyLength = 256
+	MinSSHKeyLength          = 1024
 )
 
+// sshECDSACurves maps the supported ECDSA key lengths to their curves
+var sshECDSACurves = map[int]elliptic.Curve{
+	256: elliptic.P256(),
+	384: elliptic.P384(),
+	521: elliptic.P521(),
+}
+
 type SSHKeypair struct {
+	// Private is the private key in the OpenSSH format used by
+	// ssh-keygen
 	Private []byte
-	Public  []byte
+
+	// Public is the public key in the authorized_keys format
+	// including the comment if any
+	Public []byte
 }
 
 type NewSSHKeypairOpts struct {
-	Bytes    int
+	// Bytes defines the length of the key in bits, this is the
+	// modulus size for RSA keys and one of 256, 384 or 521 (the
+	// curves P-256, P-384 and P-521) for ECDSA keys. Ed25519 keys
+	// have a fixed length and do not use this
+	//
+	// Defaults to DefaultSSHKeyLength for RSA keys and
+	// DefaultSSHECDSAKeyLength for ECDSA keys if not specified
+	Bytes int
+
+	// Comment defines the comment appended to the public key and
+	// stored in the private key, usually in the form user@host
+	Comment string
+
+	// Password defines the passphrase which protects the private key
+	// using the bcrypt KDF as done by ssh-keygen. If left empty, the
+	// private key is not protected
 	Password string
+
+	// PasswordPrompt if defined is used to ask the user for the
+	// password to protect the private key with when .Password is
+	// not set
+	PasswordPrompt *PromptPasswordOpts
+
+	// Type defines the type of key to generate, one of
+	// SSHKeyTypeRSA, SSHKeyTypeEd25519 or SSHKeyTypeECDSA
+	//
+	// Defaults to DefaultSSHKeyType if not specified
+	Type string
 }
 
 func (o *NewSSHKeypairOpts) SetDefaults() {
+	if o.Type == "" {
+		o.Type = DefaultSSHKeyType
+	}
 	if o.Bytes == 0 {
-		o.Bytes = DefaultSSHKeyLength
+		switch o.Type {
+		case SSHKeyTypeECDSA:
+			o.Bytes = DefaultSSHECDSAKeyLength
+		case SSHKeyTypeRSA:
+			o.Bytes = DefaultSSHKeyLength
+		}
 	}
 }
 
 func (o NewSSHKeypairOpts) Validate() error {
 	errors := []string{}
 
-	if o.Bytes == 0 {
-		errors = append(errors, "missing key length")
+	switch o.Type {
+	case SSHKeyTypeECDSA:
+		if _, ok := sshECDSACurves[o.Bytes]; !ok {
+			errors = append(errors, fmt.Sprintf("unsupported ecdsa key length '%v', use 256, 384 or 521", o.Bytes))
+		}
+	case SSHKeyTypeEd25519:
+		if o.Bytes != 0 {
+			errors = append(errors, "key length cannot be specified for ed25519 keys")
+		}
+	case SSHKeyTypeRSA:
+		if o.Bytes == 0 {
+			errors = append(errors, "missing key length")
+		} else if o.Bytes < MinSSHKeyLength {
+			errors = append(errors, fmt.Sprintf("rsa key length cannot be less than %v", MinSSHKeyLength))
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("unknown key type '%s'", o.Type))
+	}
+
+	if strings.ContainsAny(o.Comment, "\r\n") {
+		errors = append(errors, "comment cannot contain line breaks")
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to validate options: ['%s']", strings.Join(errors, "', '"))
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
 	}
 	return nil
 }
@@ -47,33 +123,50 @@ func (o NewSSHKeypairOpts) Validate() error {
 func NewSSHKeypair(opts NewSSHKeypairOpts) (*SSHKeypair, error) {
 	opts.SetDefaults()
 	if err := opts.Validate(); err != nil {
-		return nil, fmt.Errorf("failed to create new ssh key pair: %s", err)
+		return nil, fmt.Errorf("failed to create new ssh key pair: %w", err)
 	}
-	privateKey, err := rsa.GenerateKey(rand.Reader, opts.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate a private key: %s", err)
+	if opts.Password == "" && opts.PasswordPrompt != nil {
+		password, err := PromptPassword(*opts.PasswordPrompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get password: %w", err)
+		}
+		opts.Password = password
 	}
-	privateKeyPEM := &pem.Block{
-		Type:  "RSA_PRIVATE_KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	var privateKey crypto.Signer
+	var err error
+	switch opts.Type {
+	case SSHKeyTypeECDSA:
+		privateKey, err = ecdsa.GenerateKey(sshECDSACurves[opts.Bytes], rand.Reader)
+	case SSHKeyTypeEd25519:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	case SSHKeyTypeRSA:
+		privateKey, err = rsa.GenerateKey(rand.Reader, opts.Bytes)
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a private key: %w", err)
+	}
+	var privateKeyPEM *pem.Block
 	if opts.Password != "" {
-		privateKeyPEM, err = x509.EncryptPEMBlock(rand.Reader, privateKeyPEM.Type, privateKeyPEM.Bytes, []byte(opts.Password), x509.PEMCipherAES256)
+		privateKeyPEM, err = ssh.MarshalPrivateKeyWithPassphrase(privateKey, opts.Comment, []byte(opts.Password))
 		if err != nil {
-			return nil, fmt.Errorf("failed to protect private key with a password: %s", err)
+			return nil, fmt.Errorf("failed to protect private key with a password: %w", err)
+		}
+	} else {
+		privateKeyPEM, err = ssh.MarshalPrivateKey(privateKey, opts.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode private key: %w", err)
 		}
 	}
-	privateKeyData := pem.EncodeToMemory(privateKeyPEM)
-	if privateKeyData == nil {
-		return nil, fmt.Errorf("failed to encode private key: %s", err)
-	}
-	publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+	publicKey, err := ssh.NewPublicKey(privateKey.Public())
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate the public key: %s", err)
+		return nil, fmt.Errorf("failed to generate the public key: %w", err)
 	}
 	publicKeyData := ssh.MarshalAuthorizedKey(publicKey)
+	if opts.Comment != "" {
+		publicKeyData = append(bytes.TrimSuffix(publicKeyData, []byte("\n")), []byte(" "+opts.Comment+"\n")...)
+	}
 	return &SSHKeypair{
-		Private: privateKeyData,
+		Private: pem.EncodeToMemory(privateKeyPEM),
 		Public:  publicKeyData,
 	}, nil
 }
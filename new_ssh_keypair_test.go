@@ -1,8 +1,13 @@
 package devops
 
 import (
-	"crypto/x509"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"encoding/pem"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -20,14 +25,34 @@ func TestNewSSHKeypair(t *testing.T) {
 func (s NewSSHKeypairTest) Test_NewSSHKeypairOpts_SetDefaults() {
 	opts := NewSSHKeypairOpts{}
 	opts.SetDefaults()
+	s.Equal(DefaultSSHKeyType, opts.Type)
 	s.Equal(DefaultSSHKeyLength, opts.Bytes)
+
+	opts = NewSSHKeypairOpts{Type: SSHKeyTypeECDSA}
+	opts.SetDefaults()
+	s.Equal(DefaultSSHECDSAKeyLength, opts.Bytes)
+
+	opts = NewSSHKeypairOpts{Type: SSHKeyTypeEd25519}
+	opts.SetDefaults()
+	s.Equal(0, opts.Bytes)
 }
 
 func (s NewSSHKeypairTest) Test_NewSSHKeypairOpts_Validate() {
-	opts := NewSSHKeypairOpts{}
+	opts := NewSSHKeypairOpts{Type: SSHKeyTypeRSA}
 	err := opts.Validate()
 	s.NotNil(err)
 	s.Contains(err.Error(), "missing key length")
+
+	for _, opts := range []NewSSHKeypairOpts{
+		{Type: SSHKeyTypeRSA, Bytes: 512},
+		{Type: SSHKeyTypeECDSA, Bytes: 512},
+		{Type: SSHKeyTypeEd25519, Bytes: 256},
+		{Type: "dsa"},
+		{Type: SSHKeyTypeEd25519, Comment: "user@host\nssh-rsa AAAA"},
+	} {
+		err := opts.Validate()
+		s.True(errors.Is(err, ErrInvalidOptions), opts)
+	}
 }
 
 func (s NewSSHKeypairTest) Test_NewSSHKeypair() {
@@ -35,10 +60,59 @@ func (s NewSSHKeypairTest) Test_NewSSHKeypair() {
 	s.Nil(err)
 
 	privatePEM, _ := pem.Decode(keypair.Private)
-	privateKey, err := x509.ParsePKCS1PrivateKey(privatePEM.Bytes)
+	s.Equal("OPENSSH PRIVATE KEY", privatePEM.Type)
+	privateKey, err := ssh.ParsePrivateKey(keypair.Private)
 	s.Nil(err)
-	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(keypair.Public)
+	publicKey, comment, _, _, err := ssh.ParseAuthorizedKey(keypair.Public)
 	s.Nil(err)
+	s.Equal(ssh.KeyAlgoRSA, publicKey.Type())
+	s.Equal("", comment)
+
+	s.Equal(privateKey.PublicKey().Marshal(), publicKey.Marshal())
+}
 
-	s.EqualValues(privateKey.Public(), publicKey)
+func (s NewSSHKeypairTest) Test_NewSSHKeypair_Type() {
+	keypair, err := NewSSHKeypair(NewSSHKeypairOpts{Type: SSHKeyTypeEd25519, Comment: "user@host"})
+	s.Nil(err)
+	privateKey, err := ssh.ParseRawPrivateKey(keypair.Private)
+	s.Nil(err)
+	s.IsType(&ed25519.PrivateKey{}, privateKey)
+	publicKey, comment, _, _, err := ssh.ParseAuthorizedKey(keypair.Public)
+	s.Nil(err)
+	s.Equal(ssh.KeyAlgoED25519, publicKey.Type())
+	s.Equal("user@host", comment)
+	s.True(strings.HasSuffix(string(keypair.Public), " user@host\n"))
+
+	for bits, algorithm := range map[int]string{256: ssh.KeyAlgoECDSA256, 384: ssh.KeyAlgoECDSA384, 521: ssh.KeyAlgoECDSA521} {
+		keypair, err := NewSSHKeypair(NewSSHKeypairOpts{Type: SSHKeyTypeECDSA, Bytes: bits})
+		s.Nil(err)
+		privateKey, err := ssh.ParseRawPrivateKey(keypair.Private)
+		s.Nil(err)
+		s.IsType(&ecdsa.PrivateKey{}, privateKey)
+		publicKey, _, _, _, err := ssh.ParseAuthorizedKey(keypair.Public)
+		s.Nil(err)
+		s.Equal(algorithm, publicKey.Type())
+		signer, err := ssh.NewSignerFromKey(privateKey.(crypto.Signer))
+		s.Nil(err)
+		s.Equal(signer.PublicKey().Marshal(), publicKey.Marshal())
+	}
+}
+
+func (s NewSSHKeypairTest) Test_NewSSHKeypair_PasswordPrompt() {
+	keypair, err := NewSSHKeypair(NewSSHKeypairOpts{
+		Bytes: 1024,
+		PasswordPrompt: &PromptPasswordOpts{
+			Confirm: true,
+			Input:   strings.NewReader("password\npassword\n"),
+			Output:  &bytes.Buffer{},
+		},
+	})
+	s.Nil(err)
+	_, err = ssh.ParsePrivateKey(keypair.Private)
+	var passphraseMissingError *ssh.PassphraseMissingError
+	s.True(errors.As(err, &passphraseMissingError))
+	_, err = ssh.ParsePrivateKeyWithPassphrase(keypair.Private, []byte("password"))
+	s.Nil(err)
+	_, err = ssh.ParsePrivateKeyWithPassphrase(keypair.Private, []byte("wrong"))
+	s.NotNil(err)
 }
@@ -0,0 +1,434 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PaginationCursor = "cursor"
+	PaginationLink   = "link"
+	PaginationOffset = "offset"
+	PaginationPage   = "page"
+
+	DefaultPaginationStrategy         = PaginationLink
+	DefaultPaginationCursorParameter  = "cursor"
+	DefaultPaginationLimitParameter   = "limit"
+	DefaultPaginationOffsetParameter  = "offset"
+	DefaultPaginationPageParameter    = "page"
+	DefaultPaginationPerPageParameter = "per_page"
+	DefaultPaginationMaxRateLimitWait = time.Minute
+)
+
+// linkHeaderNext matches the URL of the next page in a Link header
+var linkHeaderNext = regexp.MustCompile(`<([^>]*)>\s*;[^,]*\brel="?([^",]*\s)?next(\s[^",]*)?"?`)
+
+// NewPaginatorOpts presents configuration for the
+// NewPaginator method
+type NewPaginatorOpts[Item any] struct {
+	// CursorParameter defines the query parameter used to send the
+	// cursor returned by .GetCursor when .Strategy is PaginationCursor
+	//
+	// Defaults to DefaultPaginationCursorParameter if not specified
+	CursorParameter string
+
+	// GetCursor is required when .Strategy is PaginationCursor and
+	// returns the cursor of the next page from a response and its
+	// body, an empty cursor indicates that there are no more pages
+	GetCursor func(res *http.Response, body []byte) (string, error)
+
+	// GetItems can optionally be specified to decode the items of a
+	// page from its body. If left nil, the body is decoded as a JSON
+	// array or as a JSON object with the array in .ItemsField
+	GetItems func(body []byte) ([]Item, error)
+
+	// HTTP defines the options used to request the first page, the
+	// URL is changed for subsequent pages
+	HTTP SendHTTPRequestOpts
+
+	// ItemsField defines the field of a JSON object body which
+	// contains the items (eg. "items"). If left empty, the body is
+	// expected to be a JSON array
+	ItemsField string
+
+	// LimitParameter defines the query parameter used to send
+	// .PerPage when .Strategy is PaginationOffset
+	//
+	// Defaults to DefaultPaginationLimitParameter if not specified
+	LimitParameter string
+
+	// MaxPages defines the maximum number of pages to request. If
+	// left as 0, all pages are requested
+	MaxPages int
+
+	// MaxRateLimitWait defines the longest time to wait for a rate
+	// limit to reset, ErrRateLimited is returned if the rate limit
+	// resets later than this
+	//
+	// Defaults to DefaultPaginationMaxRateLimitWait if not specified
+	MaxRateLimitWait time.Duration
+
+	// OffsetParameter defines the query parameter used to send the
+	// offset when .Strategy is PaginationOffset
+	//
+	// Defaults to DefaultPaginationOffsetParameter if not specified
+	OffsetParameter string
+
+	// PageParameter defines the query parameter used to send the page
+	// number when .Strategy is PaginationPage
+	//
+	// Defaults to DefaultPaginationPageParameter if not specified
+	PageParameter string
+
+	// PerPage defines the number of items to request per page, pages
+	// with fewer items are assumed to be the last page when .Strategy
+	// is PaginationPage or PaginationOffset. If left as 0, the number
+	// of items is not sent and pagination stops at an empty page
+	PerPage int
+
+	// PerPageParameter defines the query parameter used to send
+	// .PerPage when .Strategy is PaginationPage
+	//
+	// Defaults to DefaultPaginationPerPageParameter if not specified
+	PerPageParameter string
+
+	// RateLimitThreshold defines the number of remaining requests in
+	// the X-RateLimit-Remaining or RateLimit-Remaining header at
+	// which the paginator waits for the rate limit to reset before
+	// requesting the next page
+	RateLimitThreshold int
+
+	// StartPage defines the number of the first page when .Strategy
+	// is PaginationPage
+	//
+	// Defaults to 1 if not specified
+	StartPage int
+
+	// Strategy defines how the next page is requested, one of
+	// PaginationLink (follows the rel="next" URL of the Link header),
+	// PaginationPage, PaginationOffset or PaginationCursor
+	//
+	// Defaults to DefaultPaginationStrategy if not specified
+	Strategy string
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *NewPaginatorOpts[Item]) SetDefaults() {
+	if o.CursorParameter == "" {
+		o.CursorParameter = DefaultPaginationCursorParameter
+	}
+	if o.LimitParameter == "" {
+		o.LimitParameter = DefaultPaginationLimitParameter
+	}
+	if o.MaxRateLimitWait == 0 {
+		o.MaxRateLimitWait = DefaultPaginationMaxRateLimitWait
+	}
+	if o.OffsetParameter == "" {
+		o.OffsetParameter = DefaultPaginationOffsetParameter
+	}
+	if o.PageParameter == "" {
+		o.PageParameter = DefaultPaginationPageParameter
+	}
+	if o.PerPageParameter == "" {
+		o.PerPageParameter = DefaultPaginationPerPageParameter
+	}
+	if o.StartPage == 0 {
+		o.StartPage = 1
+	}
+	if o.Strategy == "" {
+		o.Strategy = DefaultPaginationStrategy
+	}
+	o.HTTP.SetDefaults()
+}
+
+// Validate verifies that this object instance is usable
+// by the NewPaginator method
+func (o NewPaginatorOpts[Item]) Validate() error {
+	errors := []string{}
+
+	switch o.Strategy {
+	case PaginationLink, PaginationOffset, PaginationPage:
+	case PaginationCursor:
+		if o.GetCursor == nil {
+			errors = append(errors, "missing cursor getter")
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("unknown strategy '%s'", o.Strategy))
+	}
+
+	if o.MaxPages < 0 {
+		errors = append(errors, "max pages cannot be negative")
+	}
+
+	if o.MaxRateLimitWait < 0 {
+		errors = append(errors, "max rate limit wait cannot be negative")
+	}
+
+	if o.PerPage < 0 {
+		errors = append(errors, "per page cannot be negative")
+	}
+
+	if o.HTTP.URL == nil {
+		errors = append(errors, "missing url")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// Paginator iterates over the pages of a paginated API, use .Next to
+// request the next page and .GetItems to get its items:
+//
+//	for paginator.Next() {
+//		for _, item := range paginator.GetItems() {
+//			// ...
+//		}
+//	}
+//	if err := paginator.Err(); err != nil {
+//		// ...
+//	}
+type Paginator[Item any] struct {
+	opts    NewPaginatorOpts[Item]
+	nextURL *url.URL
+	items   []Item
+	err     error
+	pages   int
+	offset  int
+	isDone  bool
+}
+
+// NewPaginator returns a Paginator as configured by the options object
+// instance `opts`
+func NewPaginator[Item any](opts NewPaginatorOpts[Item]) (*Paginator[Item], error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to create paginator: %w", err)
+	}
+	paginator := &Paginator[Item]{opts: opts}
+	firstURL := *opts.HTTP.URL
+	paginator.nextURL = paginator.setQuery(&firstURL, "")
+	return paginator, nil
+}
+
+// Next requests the next page and returns true if the page was
+// received, false is returned if there are no more pages or if an
+// error occurred which is available from .Err
+func (p *Paginator[Item]) Next() bool {
+	if p.isDone || p.err != nil || p.nextURL == nil {
+		return false
+	}
+	if p.opts.MaxPages > 0 && p.pages >= p.opts.MaxPages {
+		p.isDone = true
+		return false
+	}
+	requestOpts := p.opts.HTTP
+	requestURL := *p.nextURL
+	requestOpts.URL = &requestURL
+	headers := http.Header(requestOpts.Headers).Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if headers.Get("Accept") == "" {
+		headers.Set("Accept", DefaultJSONContentType)
+	}
+	requestOpts.Headers = headers
+
+	res, err := SendHTTPRequest(requestOpts)
+	if err != nil {
+		p.err = fmt.Errorf("failed to request page %v: %w", p.pages+1, err)
+		return false
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		p.err = fmt.Errorf("failed to request page %v: %w", p.pages+1, newHTTPError(res, DefaultHTTPErrorBodySize))
+		return false
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		p.err = fmt.Errorf("failed to read page %v: %w", p.pages+1, err)
+		return false
+	}
+	if p.items, err = p.getItems(body); err != nil {
+		p.err = fmt.Errorf("failed to decode page %v from '%s': %w", p.pages+1, requestURL.Redacted(), err)
+		return false
+	}
+	p.pages++
+
+	if p.nextURL, err = p.getNextURL(&requestURL, res, body); err != nil {
+		p.err = fmt.Errorf("failed to get page %v: %w", p.pages+1, err)
+		return true
+	}
+	if p.nextURL != nil && (p.opts.MaxPages == 0 || p.pages < p.opts.MaxPages) {
+		if err := p.waitForRateLimit(res.Header); err != nil {
+			p.err = err
+		}
+	}
+	return true
+}
+
+// GetItems returns the items of the current page
+func (p *Paginator[Item]) GetItems() []Item {
+	return p.items
+}
+
+// GetPageCount returns the number of pages received
+func (p *Paginator[Item]) GetPageCount() int {
+	return p.pages
+}
+
+// Err returns the error which stopped the pagination if any
+func (p *Paginator[Item]) Err() error {
+	return p.err
+}
+
+// PaginateAll returns the items of all pages requested as configured
+// by the options object instance `opts`
+func PaginateAll[Item any](opts NewPaginatorOpts[Item]) ([]Item, error) {
+	paginator, err := NewPaginator(opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	for paginator.Next() {
+		items = append(items, paginator.GetItems()...)
+	}
+	return items, paginator.Err()
+}
+
+// getItems decodes the items of a page
+func (p *Paginator[Item]) getItems(body []byte) ([]Item, error) {
+	if p.opts.GetItems != nil {
+		return p.opts.GetItems(body)
+	}
+	var items []Item
+	if p.opts.ItemsField == "" {
+		err := json.Unmarshal(body, &items)
+		return items, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	itemsJSON, ok := fields[p.opts.ItemsField]
+	if !ok {
+		return nil, fmt.Errorf("missing field '%s'", p.opts.ItemsField)
+	}
+	err := json.Unmarshal(itemsJSON, &items)
+	return items, err
+}
+
+// getNextURL returns the URL of the next page or nil if there are no
+// more pages
+func (p *Paginator[Item]) getNextURL(currentURL *url.URL, res *http.Response, body []byte) (*url.URL, error) {
+	switch p.opts.Strategy {
+	case PaginationLink:
+		for _, link := range res.Header.Values("Link") {
+			if match := linkHeaderNext.FindStringSubmatch(link); match != nil {
+				nextURL, err := currentURL.Parse(match[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid next link '%s': %w", match[1], err)
+				}
+				return nextURL, nil
+			}
+		}
+		return nil, nil
+	case PaginationCursor:
+		cursor, err := p.opts.GetCursor(res, body)
+		if err != nil || cursor == "" {
+			return nil, err
+		}
+		return p.setQuery(currentURL, cursor), nil
+	}
+	if len(p.items) == 0 || (p.opts.PerPage > 0 && len(p.items) < p.opts.PerPage) {
+		return nil, nil
+	}
+	p.offset += len(p.items)
+	return p.setQuery(currentURL, ""), nil
+}
+
+// setQuery returns a copy of the URL with the query parameters of the
+// strategy set for the next page to request
+func (p *Paginator[Item]) setQuery(target *url.URL, cursor string) *url.URL {
+	nextURL := *target
+	query := nextURL.Query()
+	switch p.opts.Strategy {
+	case PaginationCursor:
+		if cursor != "" {
+			query.Set(p.opts.CursorParameter, cursor)
+		}
+	case PaginationOffset:
+		query.Set(p.opts.OffsetParameter, strconv.Itoa(p.offset))
+		if p.opts.PerPage > 0 {
+			query.Set(p.opts.LimitParameter, strconv.Itoa(p.opts.PerPage))
+		}
+	case PaginationPage:
+		query.Set(p.opts.PageParameter, strconv.Itoa(p.opts.StartPage+p.pages))
+		if p.opts.PerPage > 0 {
+			query.Set(p.opts.PerPageParameter, strconv.Itoa(p.opts.PerPage))
+		}
+	default:
+		return &nextURL
+	}
+	nextURL.RawQuery = query.Encode()
+	return &nextURL
+}
+
+// waitForRateLimit waits for the rate limit to reset if the number of
+// remaining requests is at or below .RateLimitThreshold
+func (p *Paginator[Item]) waitForRateLimit(headers http.Header) error {
+	remaining, reset := getRateLimit(headers)
+	if remaining < 0 || remaining > p.opts.RateLimitThreshold || reset <= 0 {
+		return nil
+	}
+	if reset > p.opts.MaxRateLimitWait {
+		return fmt.Errorf("%w until %s", ErrRateLimited, time.Now().Add(reset).Format(time.RFC3339))
+	}
+	ctx := p.opts.HTTP.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(reset)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for rate limit to reset: %w", ctx.Err())
+	}
+}
+
+// getRateLimit returns the number of remaining requests and the
+// duration until the rate limit resets from the X-RateLimit-* or
+// RateLimit-* headers, -1 is returned if the headers are missing.
+// Resets larger than a billion are treated as Unix timestamps
+func getRateLimit(headers http.Header) (int, time.Duration) {
+	remaining, reset := -1, time.Duration(0)
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		remainingValue, err := strconv.Atoi(headers.Get(prefix + "Remaining"))
+		if err != nil {
+			continue
+		}
+		remaining = remainingValue
+		resetValue, err := strconv.ParseInt(headers.Get(prefix+"Reset"), 10, 64)
+		if err != nil {
+			break
+		}
+		if resetValue > 1e9 {
+			reset = time.Until(time.Unix(resetValue, 0))
+		} else {
+			reset = time.Duration(resetValue) * time.Second
+		}
+		break
+	}
+	return remaining, reset
+}
@@ -0,0 +1,206 @@
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PaginatorTests struct {
+	suite.Suite
+}
+
+func TestPaginator(t *testing.T) {
+	suite.Run(t, &PaginatorTests{})
+}
+
+func (s PaginatorTests) TestPaginateAll_link() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("application/json", r.Header.Get("Accept"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Add("Link", fmt.Sprintf(`</items?page=%v>; rel="next", </items?page=2>; rel="last"`, page+1))
+		}
+		json.NewEncoder(w).Encode([]int{page * 2, page*2 + 1})
+	})
+	items, err := PaginateAll(NewPaginatorOpts[int]{
+		HTTP: SendHTTPRequestOpts{URL: serverURL.JoinPath("items")},
+	})
+	s.Nil(err)
+	s.Equal([]int{0, 1, 2, 3, 4, 5}, items)
+}
+
+func (s PaginatorTests) TestPaginateAll_page() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("2", r.URL.Query().Get("per_page"))
+		s.Equal("value", r.URL.Query().Get("other"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		items := map[int][]string{1: {"a", "b"}, 2: {"c", "d"}, 3: {"e"}}[page]
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	})
+	serverURL.RawQuery = "other=value"
+	items, err := PaginateAll(NewPaginatorOpts[string]{
+		HTTP:       SendHTTPRequestOpts{URL: serverURL},
+		ItemsField: "items",
+		PerPage:    2,
+		Strategy:   PaginationPage,
+	})
+	s.Nil(err)
+	s.Equal([]string{"a", "b", "c", "d", "e"}, items)
+}
+
+func (s PaginatorTests) TestPaginateAll_offset() {
+	requests := 0
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		items := []int{}
+		for i := offset; i < 5; i++ {
+			items = append(items, i)
+		}
+		json.NewEncoder(w).Encode(items)
+	})
+	items, err := PaginateAll(NewPaginatorOpts[int]{
+		HTTP:     SendHTTPRequestOpts{URL: serverURL},
+		Strategy: PaginationOffset,
+	})
+	s.Nil(err)
+	s.Equal([]int{0, 1, 2, 3, 4}, items)
+	s.Equal(2, requests, "pagination without a page size should stop at an empty page")
+}
+
+func (s PaginatorTests) TestPaginator_cursor() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		next := map[string]string{"": "abc", "abc": "def", "def": ""}[r.URL.Query().Get("after")]
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []string{r.URL.Query().Get("after")},
+			"next": next,
+		})
+	})
+	paginator, err := NewPaginator(NewPaginatorOpts[string]{
+		CursorParameter: "after",
+		GetCursor: func(res *http.Response, body []byte) (string, error) {
+			var page struct {
+				Next string `json:"next"`
+			}
+			err := json.Unmarshal(body, &page)
+			return page.Next, err
+		},
+		HTTP:       SendHTTPRequestOpts{URL: serverURL},
+		ItemsField: "data",
+		Strategy:   PaginationCursor,
+	})
+	s.Nil(err)
+	pages := [][]string{}
+	for paginator.Next() {
+		pages = append(pages, paginator.GetItems())
+	}
+	s.Nil(paginator.Err())
+	s.Equal([][]string{{""}, {"abc"}, {"def"}}, pages)
+	s.Equal(3, paginator.GetPageCount())
+}
+
+func (s PaginatorTests) TestPaginateAll_maxPages() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `<?page=next>; rel="next"`)
+		json.NewEncoder(w).Encode([]int{1})
+	})
+	items, err := PaginateAll(NewPaginatorOpts[int]{
+		HTTP:     SendHTTPRequestOpts{URL: serverURL},
+		MaxPages: 3,
+	})
+	s.Nil(err)
+	s.Equal([]int{1, 1, 1}, items)
+}
+
+func (s PaginatorTests) TestPaginateAll_rateLimit() {
+	requests := 0
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 2 {
+			w.Header().Set("Link", `<?page=2>; rel="next"`)
+		}
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1")
+		json.NewEncoder(w).Encode([]int{requests})
+	})
+	startedAt := time.Now()
+	items, err := PaginateAll(NewPaginatorOpts[int]{
+		HTTP: SendHTTPRequestOpts{URL: serverURL},
+	})
+	s.Nil(err)
+	s.Equal([]int{1, 2}, items)
+	s.GreaterOrEqual(time.Since(startedAt), time.Second, "pagination should wait for the rate limit to reset")
+
+	requests = 0
+	items, err = PaginateAll(NewPaginatorOpts[int]{
+		HTTP:             SendHTTPRequestOpts{URL: serverURL},
+		MaxRateLimitWait: time.Millisecond,
+	})
+	s.True(errors.Is(err, ErrRateLimited))
+	s.Equal([]int{1}, items)
+
+	requests = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = PaginateAll(NewPaginatorOpts[int]{
+		HTTP: SendHTTPRequestOpts{Context: ctx, URL: serverURL},
+	})
+	s.True(errors.Is(err, context.Canceled))
+}
+
+func (s PaginatorTests) TestPaginateAll_unexpectedStatusCode() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+			return
+		}
+		w.Header().Set("Link", `<?page=2>; rel="next"`)
+		json.NewEncoder(w).Encode([]int{1})
+	})
+	items, err := PaginateAll(NewPaginatorOpts[int]{
+		HTTP: SendHTTPRequestOpts{URL: serverURL},
+	})
+	var httpError HTTPError
+	s.True(errors.As(err, &httpError))
+	s.Equal(http.StatusNotFound, httpError.StatusCode)
+	s.Equal("not found", string(httpError.Body))
+	s.Equal([]int{1}, items)
+}
+
+func (s PaginatorTests) TestGetRateLimit() {
+	remaining, reset := getRateLimit(http.Header{})
+	s.Equal(-1, remaining)
+	s.Equal(time.Duration(0), reset)
+
+	remaining, reset = getRateLimit(http.Header{"Ratelimit-Remaining": {"5"}, "Ratelimit-Reset": {"30"}})
+	s.Equal(5, remaining)
+	s.Equal(30*time.Second, reset)
+
+	resetAt := time.Now().Add(time.Hour).Unix()
+	remaining, reset = getRateLimit(http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(resetAt, 10)}})
+	s.Equal(0, remaining)
+	s.InDelta(time.Hour, reset, float64(2*time.Second))
+}
+
+func (s PaginatorTests) TestNewPaginatorOpts_Validate() {
+	opts := NewPaginatorOpts[int]{MaxPages: -1, PerPage: -1, Strategy: PaginationCursor}
+	err := opts.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "missing cursor getter")
+	s.Contains(err.Error(), "max pages cannot be negative")
+	s.Contains(err.Error(), "per page cannot be negative")
+	s.Contains(err.Error(), "missing url")
+
+	opts = NewPaginatorOpts[int]{Strategy: "unknown", HTTP: SendHTTPRequestOpts{URL: &url.URL{}}}
+	s.Contains(opts.Validate().Error(), "unknown strategy 'unknown'")
+}
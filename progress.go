@@ -0,0 +1,337 @@
+package devops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultProgressReportInterval  = 100 * time.Millisecond
+	DefaultProgressBarLogInterval  = 5 * time.Second
+	DefaultProgressBarWidth        = 30
+	DefaultProgressBarCompleteChar = "="
+	DefaultProgressBarPendingChar  = " "
+)
+
+// Progress describes the state of a transfer at a point in time
+type Progress struct {
+	// BytesTransferred is the number of bytes transferred so far
+	// including .InitialBytes
+	BytesTransferred int64
+
+	// InitialBytes is the number of bytes that were already
+	// transferred before this transfer started (eg. when a download
+	// is resumed)
+	InitialBytes int64
+
+	// TotalBytes is the expected total number of bytes, this is -1
+	// if the total is not known (eg. no Content-Length header)
+	TotalBytes int64
+
+	// Elapsed is the time since the transfer started
+	Elapsed time.Duration
+
+	// Done is true when the transfer has completed
+	Done bool
+}
+
+// GetRate returns the transfer rate in bytes per second
+func (p Progress) GetRate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.BytesTransferred-p.InitialBytes) / p.Elapsed.Seconds()
+}
+
+// GetPercentage returns the percentage of bytes transferred and true
+// if the total is known, returns false otherwise
+func (p Progress) GetPercentage() (float64, bool) {
+	if p.TotalBytes < 0 {
+		return 0, false
+	}
+	if p.TotalBytes == 0 {
+		return 100, true
+	}
+	return float64(p.BytesTransferred) * 100 / float64(p.TotalBytes), true
+}
+
+// GetETA returns the estimated time remaining and true if it can be
+// estimated, returns false otherwise
+func (p Progress) GetETA() (time.Duration, bool) {
+	rate := p.GetRate()
+	if p.TotalBytes < 0 || rate <= 0 {
+		return 0, false
+	}
+	remaining := p.TotalBytes - p.BytesTransferred
+	if remaining < 0 {
+		remaining = 0
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second)), true
+}
+
+// ProgressReporter receives progress updates from a transfer
+type ProgressReporter interface {
+	// Report is called periodically during the transfer and once
+	// more with .Done set to true when the transfer completes
+	Report(progress Progress)
+}
+
+// ProgressReporterFunc allows a function to be used as a
+// ProgressReporter
+type ProgressReporterFunc func(progress Progress)
+
+// Report implements ProgressReporter
+func (f ProgressReporterFunc) Report(progress Progress) {
+	f(progress)
+}
+
+// NewProgressReader returns a reader that reports the progress of
+// reading from `reader` to `reporter` at most once every
+// DefaultProgressReportInterval. `totalBytes` should be set to -1 if
+// the total is not known. A final report with .Done set to true is
+// sent when `reader` returns io.EOF
+func NewProgressReader(reader io.Reader, totalBytes int64, reporter ProgressReporter) io.Reader {
+	return newProgressReader(reader, 0, totalBytes, reporter)
+}
+
+// newProgressReader returns a progress reader for a transfer that
+// already has `initialBytes` transferred
+func newProgressReader(reader io.Reader, initialBytes, totalBytes int64, reporter ProgressReporter) *progressReader {
+	return &progressReader{
+		reader:     reader,
+		reportDone: true,
+		tracker:    newProgressTracker(initialBytes, totalBytes, reporter),
+	}
+}
+
+type progressReader struct {
+	reader     io.Reader
+	reportDone bool
+	tracker    *progressTracker
+}
+
+// Read implements io.Reader
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.tracker.Add(int64(n))
+	if err == io.EOF && r.reportDone {
+		r.tracker.Done()
+	}
+	return n, err
+}
+
+// newProgressTracker returns a tracker for a transfer that already
+// has `initialBytes` transferred
+func newProgressTracker(initialBytes, totalBytes int64, reporter ProgressReporter) *progressTracker {
+	return &progressTracker{
+		progress: Progress{
+			BytesTransferred: initialBytes,
+			InitialBytes:     initialBytes,
+			TotalBytes:       totalBytes,
+		},
+		reporter:  reporter,
+		startedAt: time.Now(),
+	}
+}
+
+// progressTracker accumulates the bytes transferred by one or more
+// concurrent readers and reports them at most once every
+// DefaultProgressReportInterval
+type progressTracker struct {
+	lastReportedAt time.Time
+	mutex          sync.Mutex
+	progress       Progress
+	reporter       ProgressReporter
+	startedAt      time.Time
+}
+
+// Add records `n` more bytes as transferred
+func (t *progressTracker) Add(n int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.progress.BytesTransferred += n
+	if t.progress.Done {
+		return
+	}
+	now := time.Now()
+	if now.Sub(t.lastReportedAt) >= DefaultProgressReportInterval {
+		t.lastReportedAt = now
+		t.progress.Elapsed = now.Sub(t.startedAt)
+		t.reporter.Report(t.progress)
+	}
+}
+
+// Done sends the final report, subsequent calls are ignored
+func (t *progressTracker) Done() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.progress.Done {
+		return
+	}
+	t.progress.Done = true
+	t.progress.Elapsed = time.Since(t.startedAt)
+	t.reporter.Report(t.progress)
+}
+
+// NewProgressBarOpts presents options for the NewProgressBar method
+type NewProgressBarOpts struct {
+	// Label can optionally be specified to print a string before
+	// the progress bar
+	Label string
+
+	// LogInterval defines the minimum duration between log lines
+	// when .Output is not a terminal
+	//
+	// Defaults to DefaultProgressBarLogInterval if not specified
+	LogInterval time.Duration
+
+	// Output defines the output stream to write the progress bar to
+	//
+	// Defaults to os.Stderr if not specified
+	Output io.Writer
+
+	// Width defines the number of characters used to draw the bar
+	//
+	// Defaults to DefaultProgressBarWidth if not specified
+	Width int
+}
+
+// SetDefaults checks for unspecified properties which have defaults
+// and adds them
+func (o *NewProgressBarOpts) SetDefaults() {
+	if o.LogInterval == 0 {
+		o.LogInterval = DefaultProgressBarLogInterval
+	}
+	if o.Output == nil {
+		o.Output = os.Stderr
+	}
+	if o.Width == 0 {
+		o.Width = DefaultProgressBarWidth
+	}
+}
+
+// NewProgressBar returns a ProgressReporter that draws a progress bar
+// with the percentage, transfer rate and ETA on a single line when
+// .Output is a terminal and degrades to printing a log line every
+// .LogInterval otherwise
+func NewProgressBar(opts NewProgressBarOpts) ProgressReporter {
+	opts.SetDefaults()
+	_, isTerminal := getTerminalFd(opts.Output)
+	return &progressBar{
+		isTerminal:  isTerminal,
+		label:       opts.Label,
+		logInterval: opts.LogInterval,
+		output:      opts.Output,
+		width:       opts.Width,
+	}
+}
+
+type progressBar struct {
+	isTerminal   bool
+	label        string
+	lastLoggedAt time.Time
+	logInterval  time.Duration
+	mutex        sync.Mutex
+	output       io.Writer
+	width        int
+}
+
+// Report implements ProgressReporter
+func (b *progressBar) Report(progress Progress) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.isTerminal {
+		line := "\r\033[K" + b.render(progress)
+		if progress.Done {
+			line += "\n"
+		}
+		fmt.Fprint(b.output, line)
+		return
+	}
+	now := time.Now()
+	if progress.Done || now.Sub(b.lastLoggedAt) >= b.logInterval {
+		b.lastLoggedAt = now
+		fmt.Fprintln(b.output, b.log(progress))
+	}
+}
+
+// render returns a line like 'label [=====     ] 50% 1.0 MiB/2.0 MiB 512.0 KiB/s ETA 2s'
+func (b *progressBar) render(progress Progress) string {
+	var line strings.Builder
+	if b.label != "" {
+		line.WriteString(b.label + " ")
+	}
+	if percentage, ok := progress.GetPercentage(); ok {
+		complete := int(percentage / 100 * float64(b.width))
+		if complete > b.width {
+			complete = b.width
+		}
+		line.WriteString(fmt.Sprintf(
+			"[%s%s] %3.0f%% %s/%s",
+			strings.Repeat(DefaultProgressBarCompleteChar, complete),
+			strings.Repeat(DefaultProgressBarPendingChar, b.width-complete),
+			percentage,
+			formatByteSize(progress.BytesTransferred),
+			formatByteSize(progress.TotalBytes),
+		))
+	} else {
+		line.WriteString(formatByteSize(progress.BytesTransferred))
+	}
+	line.WriteString(fmt.Sprintf(" %s/s", formatByteSize(int64(progress.GetRate()))))
+	if progress.Done {
+		line.WriteString(fmt.Sprintf(" in %s", progress.Elapsed.Round(time.Second)))
+	} else if eta, ok := progress.GetETA(); ok {
+		line.WriteString(fmt.Sprintf(" ETA %s", eta.Round(time.Second)))
+	}
+	return line.String()
+}
+
+// log returns a line like 'label: 50% (1.0 MiB/2.0 MiB) at 512.0 KiB/s, ETA 2s'
+func (b *progressBar) log(progress Progress) string {
+	var line strings.Builder
+	if b.label != "" {
+		line.WriteString(b.label + ": ")
+	}
+	if progress.Done {
+		line.WriteString(fmt.Sprintf(
+			"done (%s in %s at %s/s)",
+			formatByteSize(progress.BytesTransferred),
+			progress.Elapsed.Round(time.Second),
+			formatByteSize(int64(progress.GetRate())),
+		))
+		return line.String()
+	}
+	if percentage, ok := progress.GetPercentage(); ok {
+		line.WriteString(fmt.Sprintf(
+			"%.0f%% (%s/%s)",
+			percentage,
+			formatByteSize(progress.BytesTransferred),
+			formatByteSize(progress.TotalBytes),
+		))
+	} else {
+		line.WriteString(formatByteSize(progress.BytesTransferred))
+	}
+	line.WriteString(fmt.Sprintf(" at %s/s", formatByteSize(int64(progress.GetRate()))))
+	if eta, ok := progress.GetETA(); ok {
+		line.WriteString(fmt.Sprintf(", ETA %s", eta.Round(time.Second)))
+	}
+	return line.String()
+}
+
+// formatByteSize returns a human-readable size using binary units
+func formatByteSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	divisor, exponent := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		divisor *= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(divisor), "KMGTPE"[exponent])
+}
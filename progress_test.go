@@ -0,0 +1,92 @@
+package devops
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProgressTests struct {
+	suite.Suite
+}
+
+func TestProgress(t *testing.T) {
+	suite.Run(t, &ProgressTests{})
+}
+
+func (s ProgressTests) TestProgress() {
+	progress := Progress{
+		BytesTransferred: 300,
+		InitialBytes:     100,
+		TotalBytes:       1100,
+		Elapsed:          2 * time.Second,
+	}
+	s.Equal(float64(100), progress.GetRate(), "rate should exclude the initial bytes")
+	percentage, ok := progress.GetPercentage()
+	s.True(ok)
+	s.InDelta(27.27, percentage, 0.01)
+	eta, ok := progress.GetETA()
+	s.True(ok)
+	s.Equal(8*time.Second, eta)
+
+	progress.TotalBytes = -1
+	_, ok = progress.GetPercentage()
+	s.False(ok)
+	_, ok = progress.GetETA()
+	s.False(ok)
+}
+
+func (s ProgressTests) TestNewProgressReader() {
+	reports := []Progress{}
+	reader := NewProgressReader(strings.NewReader("hello world"), 11, ProgressReporterFunc(func(progress Progress) {
+		reports = append(reports, progress)
+	}))
+	data, err := ioutil.ReadAll(reader)
+	s.Nil(err)
+	s.Equal("hello world", string(data))
+	s.NotEmpty(reports)
+	last := reports[len(reports)-1]
+	s.True(last.Done)
+	s.Equal(int64(11), last.BytesTransferred)
+	s.Equal(int64(11), last.TotalBytes)
+}
+
+func (s ProgressTests) TestNewProgressBar_nonTerminal() {
+	var output bytes.Buffer
+	progressBar := NewProgressBar(NewProgressBarOpts{
+		Label:       "file.tar.gz",
+		LogInterval: time.Hour,
+		Output:      &output,
+	})
+	progressBar.Report(Progress{BytesTransferred: 512, TotalBytes: 2048, Elapsed: time.Second})
+	progressBar.Report(Progress{BytesTransferred: 1024, TotalBytes: 2048, Elapsed: 2 * time.Second})
+	progressBar.Report(Progress{BytesTransferred: 2048, TotalBytes: 2048, Elapsed: 4 * time.Second, Done: true})
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	s.Len(lines, 2, "reports within the log interval should be skipped except when done")
+	s.Equal("file.tar.gz: 25% (512 B/2.0 KiB) at 512 B/s, ETA 3s", lines[0])
+	s.Equal("file.tar.gz: done (2.0 KiB in 4s at 512 B/s)", lines[1])
+}
+
+func (s ProgressTests) Test_progressBar_render() {
+	progressBar := &progressBar{label: "file", width: 10}
+	s.Equal(
+		"file [=====     ]  50% 1.0 MiB/2.0 MiB 512.0 KiB/s ETA 2s",
+		progressBar.render(Progress{BytesTransferred: 1 << 20, TotalBytes: 2 << 20, Elapsed: 2 * time.Second}),
+	)
+	s.Equal(
+		"file 1.0 MiB 512.0 KiB/s",
+		progressBar.render(Progress{BytesTransferred: 1 << 20, TotalBytes: -1, Elapsed: 2 * time.Second}),
+	)
+}
+
+func (s ProgressTests) Test_formatByteSize() {
+	s.Equal("0 B", formatByteSize(0))
+	s.Equal("1023 B", formatByteSize(1023))
+	s.Equal("1.0 KiB", formatByteSize(1024))
+	s.Equal("1.5 MiB", formatByteSize(3<<19))
+	s.Equal("2.0 GiB", formatByteSize(2<<30))
+}
@@ -0,0 +1,291 @@
+package devops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	DefaultPromptInputHint                 = " (default: '%s') "
+	DefaultPromptMaxAttempts               = 3
+	DefaultPromptPasswordInputHint         = " "
+	DefaultPromptPasswordConfirmQuestion   = "confirm password:"
+	DefaultPromptInvalidInputMessageFormat = "invalid input: %s\n"
+)
+
+// PromptOpts presents options for the Prompt method
+type PromptOpts struct {
+	// Question can optionally be specified for the .Prompt method to
+	// print a string before requesting for input. A space will be
+	// added at the end of the provided .Question if .Default is not
+	// defined, otherwise the string defined in .InputHint is added
+	Question string
+
+	// Default defines the value returned when the user provides an
+	// empty input
+	Default string
+
+	// Input defines the input stream to read the input from
+	//
+	// Defaults to os.Stdin if not specified
+	Input io.Reader
+
+	// InputHint is a format string containing a single %s denoting
+	// the value of .Default, this is only printed if .Default is
+	// defined
+	//
+	// Defaults to DefaultPromptInputHint if not specified
+	InputHint string
+
+	// MaxAttempts defines the number of times the user will be asked
+	// for input before giving up when .Validator returns an error
+	//
+	// Defaults to DefaultPromptMaxAttempts if not specified
+	MaxAttempts int
+
+	// Output defines the output stream to write output to
+	//
+	// Defaults to os.Stdout if not specified
+	Output io.Writer
+
+	// Validator can optionally be specified to check the user's input,
+	// when an error is returned, the error is printed and the user
+	// is asked for input again
+	Validator func(input string) error
+}
+
+// SetDefaults checks for unspecified properties which have defaults
+// and adds them
+func (o *PromptOpts) SetDefaults() {
+	if o.Input == nil {
+		o.Input = os.Stdin
+	}
+	if o.InputHint == "" {
+		o.InputHint = DefaultPromptInputHint
+	}
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = DefaultPromptMaxAttempts
+	}
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+}
+
+// Validate runs validation checks against the provided options
+func (o PromptOpts) Validate() error {
+	errors := []string{}
+
+	if o.MaxAttempts < 0 {
+		errors = append(errors, "max attempts cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// Prompt requests for free-text input from the user and returns it.
+// The input is trimmed of surrounding whitespace and .Default is
+// returned if the input is empty
+func Prompt(opts PromptOpts) (string, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return "", fmt.Errorf("failed to trigger prompt: %w", err)
+	}
+	hint := " "
+	if opts.Default != "" {
+		hint = fmt.Sprintf(opts.InputHint, opts.Default)
+	}
+	var validationError error
+	for attempt := 0; attempt < opts.MaxAttempts; attempt++ {
+		if _, err := opts.Output.Write([]byte(opts.Question + hint)); err != nil {
+			return "", fmt.Errorf("failed to write to output: %w", err)
+		}
+		input, err := readLine(opts.Input)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user input: %w", err)
+		}
+		input = strings.Trim(input, " \n\t\r")
+		if input == "" {
+			input = opts.Default
+		}
+		if opts.Validator == nil {
+			return input, nil
+		}
+		if validationError = opts.Validator(input); validationError == nil {
+			return input, nil
+		}
+		if _, err := opts.Output.Write([]byte(fmt.Sprintf(DefaultPromptInvalidInputMessageFormat, validationError))); err != nil {
+			return "", fmt.Errorf("failed to write to output: %w", err)
+		}
+	}
+	return "", fmt.Errorf("%w after %v attempts: %w", ErrInvalidInput, opts.MaxAttempts, validationError)
+}
+
+// PromptPasswordOpts presents options for the PromptPassword method
+type PromptPasswordOpts struct {
+	// Question can optionally be specified for the .PromptPassword
+	// method to print a string before requesting for input. The string
+	// defined in .InputHint is added after it
+	Question string
+
+	// Confirm when set to true asks the user to enter the password a
+	// second time and fails the attempt if both inputs do not match
+	Confirm bool
+
+	// ConfirmQuestion is the string printed before asking the user to
+	// enter the password a second time
+	//
+	// Defaults to DefaultPromptPasswordConfirmQuestion if not specified
+	ConfirmQuestion string
+
+	// Input defines the input stream to read the input from. When
+	// this is a terminal, the input is not echoed
+	//
+	// Defaults to os.Stdin if not specified
+	Input io.Reader
+
+	// InputHint is a string printed after .Question and
+	// .ConfirmQuestion
+	//
+	// Defaults to DefaultPromptPasswordInputHint if not specified
+	InputHint string
+
+	// MaxAttempts defines the number of times the user will be asked
+	// for input before giving up when the password is rejected
+	//
+	// Defaults to DefaultPromptMaxAttempts if not specified
+	MaxAttempts int
+
+	// MinCharacterClasses defines the minimum number of character
+	// classes (lowercase, uppercase, digits, others) the password
+	// should contain
+	MinCharacterClasses int
+
+	// MinLength defines the minimum number of characters the password
+	// should contain
+	MinLength int
+
+	// Output defines the output stream to write output to
+	//
+	// Defaults to os.Stdout if not specified
+	Output io.Writer
+
+	// Validator can optionally be specified to run additional checks
+	// on the user's input
+	Validator func(input string) error
+}
+
+// SetDefaults checks for unspecified properties which have defaults
+// and adds them
+func (o *PromptPasswordOpts) SetDefaults() {
+	if o.ConfirmQuestion == "" {
+		o.ConfirmQuestion = DefaultPromptPasswordConfirmQuestion
+	}
+	if o.Input == nil {
+		o.Input = os.Stdin
+	}
+	if o.InputHint == "" {
+		o.InputHint = DefaultPromptPasswordInputHint
+	}
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = DefaultPromptMaxAttempts
+	}
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+}
+
+// Validate runs validation checks against the provided options
+func (o PromptPasswordOpts) Validate() error {
+	errors := []string{}
+
+	if o.MaxAttempts < 0 {
+		errors = append(errors, "max attempts cannot be negative")
+	}
+	if o.MinCharacterClasses > 4 {
+		errors = append(errors, "min character classes cannot be more than 4")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// check returns an error if the provided password does not meet the
+// strength requirements defined in the options
+func (o PromptPasswordOpts) check(password string) error {
+	if len([]rune(password)) < o.MinLength {
+		return fmt.Errorf("password should be at least %v characters long", o.MinLength)
+	}
+	if countCharacterClasses(password) < o.MinCharacterClasses {
+		return fmt.Errorf("password should contain at least %v of: lowercase letters, uppercase letters, digits, symbols", o.MinCharacterClasses)
+	}
+	if o.Validator != nil {
+		return o.Validator(password)
+	}
+	return nil
+}
+
+// PromptPassword requests for a password from the user and returns
+// it. The input is not echoed if the input is a terminal. This can be
+// used to collect the passphrase for .NewSSHKeypair and
+// .GetSshKeyFingerprint
+func PromptPassword(opts PromptPasswordOpts) (string, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return "", fmt.Errorf("failed to trigger password prompt: %w", err)
+	}
+	var validationError error
+	for attempt := 0; attempt < opts.MaxAttempts; attempt++ {
+		password, err := readPassword(opts.Input, opts.Output, opts.Question+opts.InputHint)
+		if err != nil {
+			return "", err
+		}
+		if validationError = opts.check(password); validationError == nil && opts.Confirm {
+			confirmation, err := readPassword(opts.Input, opts.Output, opts.ConfirmQuestion+opts.InputHint)
+			if err != nil {
+				return "", err
+			}
+			if confirmation != password {
+				validationError = fmt.Errorf("passwords do not match")
+			}
+		}
+		if validationError == nil {
+			return password, nil
+		}
+		if _, err := opts.Output.Write([]byte(fmt.Sprintf(DefaultPromptInvalidInputMessageFormat, validationError))); err != nil {
+			return "", fmt.Errorf("failed to write to output: %w", err)
+		}
+	}
+	return "", fmt.Errorf("%w after %v attempts: %w", ErrInvalidInput, opts.MaxAttempts, validationError)
+}
+
+// readPassword writes the prompt to the output and reads a line from
+// the input without echoing it if the input is a terminal
+func readPassword(input io.Reader, output io.Writer, prompt string) (string, error) {
+	if _, err := output.Write([]byte(prompt)); err != nil {
+		return "", fmt.Errorf("failed to write to output: %w", err)
+	}
+	if fd, ok := getTerminalFd(input); ok {
+		password, err := term.ReadPassword(fd)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user input: %w", err)
+		}
+		if _, err := output.Write([]byte("\n")); err != nil {
+			return "", fmt.Errorf("failed to write to output: %w", err)
+		}
+		return string(password), nil
+	}
+	password, err := readLine(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user input: %w", err)
+	}
+	return password, nil
+}
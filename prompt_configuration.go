@@ -0,0 +1,216 @@
+package devops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PromptConfigurationOpts presents options for the
+// PromptConfiguration method
+type PromptConfigurationOpts struct {
+	// Config is a pointer to a struct defined in the same way as
+	// the one passed to .LoadConfiguration
+	Config interface{}
+
+	// DotenvPath can optionally be specified to read previously
+	// provided answers from a dotenv file, values found in the file
+	// are not prompted for. When .SaveDotenv is true, answers are
+	// written to this file
+	DotenvPath string
+
+	// Input defines the input stream to read the input from
+	//
+	// Defaults to os.Stdin if not specified
+	Input io.Reader
+
+	// Output defines the output stream to write output to
+	//
+	// Defaults to os.Stdout if not specified
+	Output io.Writer
+
+	// SaveDotenv when set to true writes the answers into the dotenv
+	// file at .DotenvPath so that they are not prompted for the next
+	// time. Existing lines in the file are preserved
+	SaveDotenv bool
+}
+
+// SetDefaults checks for unspecified properties which have defaults
+// and adds them
+func (o *PromptConfigurationOpts) SetDefaults() {
+	if o.Input == nil {
+		o.Input = os.Stdin
+	}
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+}
+
+// Validate runs validation checks against the provided options
+func (o PromptConfigurationOpts) Validate() error {
+	errors := []string{}
+
+	if o.Config == nil {
+		errors = append(errors, "missing config")
+	}
+	if o.SaveDotenv && o.DotenvPath == "" {
+		errors = append(errors, "missing dotenv path to save to")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// PromptConfiguration prompts the user for every property of the
+// provided .Config that is not already defined in the environment (or
+// in the dotenv file at .DotenvPath) and then loads the configuration
+// in the same way as .LoadConfiguration. This is useful for providing
+// a "first-run setup" experience.
+//
+// In addition to the struct tags supported by .LoadConfiguration, the
+// following struct tags are supported:
+//
+//   - `prompt:"question"` defines the question to ask
+//   - `secret:"true"` masks the input using .PromptPassword
+//   - `enum:"a,b,c"` asks the user to pick from the options using
+//     .Select (or .MultiSelect for []string properties)
+func PromptConfiguration(opts PromptConfigurationOpts) error {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("failed to prompt for configuration: %w", err)
+	}
+	c := newConfiguration(opts.Config)
+	if !c.IsPointer() || !c.IsStruct() {
+		return loadConfiguration(opts.Config, os.LookupEnv)
+	}
+
+	env := &dotenv{keys: map[string]int{}, values: map[string]string{}}
+	if opts.DotenvPath != "" {
+		dotenvPath, err := NormalizeLocalPath(opts.DotenvPath)
+		if err != nil {
+			return fmt.Errorf("failed to normalize path '%s': %w", opts.DotenvPath, err)
+		}
+		opts.DotenvPath = dotenvPath
+		if env, err = readDotenv(opts.DotenvPath); err != nil {
+			return fmt.Errorf("failed to prompt for configuration: %w", err)
+		}
+	}
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, ok
+		}
+		return env.Lookup(key)
+	}
+
+	for _, field := range c.Fields {
+		environmentKey := field.GetEnvironmentKey()
+		if _, ok := lookup(environmentKey); ok {
+			continue
+		}
+		answer, err := promptConfigurationField(field, opts.Input, opts.Output)
+		if err != nil {
+			return fmt.Errorf("failed to get a value for '%s': %w", field.Name, err)
+		}
+		if answer != "" {
+			env.Set(environmentKey, answer)
+		}
+	}
+
+	if opts.SaveDotenv {
+		if err := env.Write(opts.DotenvPath); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+	}
+	return loadConfiguration(opts.Config, lookup)
+}
+
+// promptConfigurationField asks the user for the value of the provided
+// field using the prompt that matches its struct tags, an empty string
+// is returned if an optional field was left empty
+func promptConfigurationField(field configurationField, input io.Reader, output io.Writer) (string, error) {
+	fieldType := strings.TrimPrefix(field.Type.String(), "*")
+	switch fieldType {
+	case "[]string", "string", "bool", "int":
+	default:
+		// unsupported types are reported by loadConfiguration
+		return "", nil
+	}
+	question := field.GetPrompt()
+	defaultValue := ""
+	if value := field.GetDefaultValue(); value != nil {
+		defaultValue = *value
+	}
+
+	if options := field.GetEnum(); options != nil {
+		if fieldType == "[]string" {
+			defaults := []string{}
+			if defaultValue != "" {
+				defaults = strings.Split(defaultValue, field.GetDelimiter())
+			}
+			selections, err := MultiSelect(MultiSelectOpts{
+				Question: question,
+				Options:  options,
+				Defaults: defaults,
+				Input:    input,
+				Output:   output,
+			})
+			return strings.Join(selections, field.GetDelimiter()), err
+		}
+		return Select(SelectOpts{
+			Question: question,
+			Options:  options,
+			Default:  defaultValue,
+			Input:    input,
+			Output:   output,
+		})
+	}
+
+	validator := func(answer string) error {
+		if answer == "" {
+			if field.IsOptional() {
+				return nil
+			}
+			return fmt.Errorf("a value is required")
+		}
+		switch fieldType {
+		case "bool":
+			if _, err := strconv.ParseBool(answer); err != nil {
+				return fmt.Errorf("'%s' is not a boolean", answer)
+			}
+		case "int":
+			if _, err := strconv.ParseInt(answer, 10, 0); err != nil {
+				return fmt.Errorf("'%s' is not an int", answer)
+			}
+		}
+		return nil
+	}
+
+	if field.IsSecret() {
+		answer, err := PromptPassword(PromptPasswordOpts{
+			Question: question,
+			Input:    input,
+			Output:   output,
+			Validator: func(answer string) error {
+				if answer == "" {
+					answer = defaultValue
+				}
+				return validator(answer)
+			},
+		})
+		if answer == "" {
+			answer = defaultValue
+		}
+		return answer, err
+	}
+	return Prompt(PromptOpts{
+		Question:  question,
+		Default:   defaultValue,
+		Input:     input,
+		Output:    output,
+		Validator: validator,
+	})
+}
@@ -0,0 +1,124 @@
+package devops
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PromptConfigurationTest struct {
+	suite.Suite
+}
+
+func TestPromptConfiguration(t *testing.T) {
+	suite.Run(t, &PromptConfigurationTest{})
+}
+
+func (s PromptConfigurationTest) TestPromptConfiguration() {
+	type testStruct struct {
+		Name        string   `prompt:"project name?"`
+		Replicas    int      `default:"1"`
+		Debug       bool     `env:"TEST_PROMPT_DEBUG"`
+		Password    string   `secret:"true"`
+		Environment string   `enum:"dev,staging,prod" default:"dev"`
+		Services    []string `enum:"api,web,worker" default:"api"`
+		Description *string
+	}
+	os.Setenv("TEST_PROMPT_DEBUG", "true")
+	defer os.Unsetenv("TEST_PROMPT_DEBUG")
+	var output bytes.Buffer
+	input := strings.NewReader(strings.Join([]string{
+		"my-project", // Name
+		"many",       // Replicas (invalid)
+		"3",          // Replicas
+		"s3cr3t",     // Password
+		"3",          // Environment
+		"2,3",        // Services
+		"",           // Description
+	}, "\n") + "\n")
+	config := testStruct{}
+	err := PromptConfiguration(PromptConfigurationOpts{
+		Config: &config,
+		Input:  input,
+		Output: &output,
+	})
+	s.Nil(err)
+	s.Equal("my-project", config.Name)
+	s.Equal(3, config.Replicas)
+	s.True(config.Debug)
+	s.Equal("s3cr3t", config.Password)
+	s.Equal("prod", config.Environment)
+	s.Equal([]string{"web", "worker"}, config.Services)
+	s.Nil(config.Description)
+	s.Contains(output.String(), "project name?")
+	s.Contains(output.String(), "'many' is not an int")
+	s.NotContains(output.String(), "TEST_PROMPT_DEBUG", "values from the environment should not be prompted for")
+}
+
+func (s PromptConfigurationTest) TestPromptConfiguration_dotenv() {
+	type testStruct struct {
+		Name     string
+		Token    string `secret:"true"`
+		Replicas int
+	}
+	dotenvPath := path.Join(s.T().TempDir(), ".env")
+	s.Nil(ioutil.WriteFile(dotenvPath, []byte("# existing comment\nNAME=\"from dotenv\"\n"), 0600))
+
+	config := testStruct{}
+	err := PromptConfiguration(PromptConfigurationOpts{
+		Config:     &config,
+		DotenvPath: dotenvPath,
+		Input:      strings.NewReader("abc $def\n2\n"),
+		Output:     &bytes.Buffer{},
+		SaveDotenv: true,
+	})
+	s.Nil(err)
+	s.Equal("from dotenv", config.Name)
+	s.Equal("abc $def", config.Token)
+	s.Equal(2, config.Replicas)
+
+	content, err := ioutil.ReadFile(dotenvPath)
+	s.Nil(err)
+	s.Equal("# existing comment\nNAME=\"from dotenv\"\nTOKEN=\"abc \\$def\"\nREPLICAS=2\n", string(content))
+	fileInfo, err := os.Stat(dotenvPath)
+	s.Nil(err)
+	s.Equal(os.FileMode(0600), fileInfo.Mode().Perm())
+
+	config = testStruct{}
+	err = PromptConfiguration(PromptConfigurationOpts{
+		Config:     &config,
+		DotenvPath: dotenvPath,
+		Input:      &bytes.Buffer{},
+		Output:     &bytes.Buffer{},
+	})
+	s.Nil(err, "all values should be loaded from the dotenv file without prompting")
+	s.Equal("abc $def", config.Token)
+}
+
+func (s PromptConfigurationTest) TestPromptConfiguration_noInput() {
+	type testStruct struct {
+		Name string
+	}
+	err := PromptConfiguration(PromptConfigurationOpts{
+		Config: &testStruct{},
+		Input:  &bytes.Buffer{},
+		Output: &bytes.Buffer{},
+	})
+	s.True(errors.Is(err, ErrNoInput))
+}
+
+func (s PromptConfigurationTest) TestPromptConfiguration_validation() {
+	err := PromptConfiguration(PromptConfigurationOpts{SaveDotenv: true})
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "missing config")
+	s.Contains(err.Error(), "missing dotenv path")
+
+	err = PromptConfiguration(PromptConfigurationOpts{Config: struct{}{}})
+	s.True(errors.Is(err, ErrConfigPrereqs))
+}
@@ -0,0 +1,115 @@
+package devops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PromptTests struct {
+	suite.Suite
+}
+
+func TestPrompt(t *testing.T) {
+	suite.Run(t, &PromptTests{})
+}
+
+func (s PromptTests) TestPrompt() {
+	var output bytes.Buffer
+	input, err := Prompt(PromptOpts{
+		Question: "name?",
+		Input:    strings.NewReader("  hello world  \n"),
+		Output:   &output,
+	})
+	s.Nil(err)
+	s.Equal("hello world", input)
+	s.Equal("name? ", output.String())
+}
+
+func (s PromptTests) TestPrompt_default() {
+	var output bytes.Buffer
+	input, err := Prompt(PromptOpts{
+		Question: "name?",
+		Default:  "anonymous",
+		Input:    strings.NewReader("\n"),
+		Output:   &output,
+	})
+	s.Nil(err)
+	s.Equal("anonymous", input)
+	s.Contains(output.String(), fmt.Sprintf(DefaultPromptInputHint, "anonymous"))
+}
+
+func (s PromptTests) TestPrompt_Validator() {
+	var output bytes.Buffer
+	isNumber := func(input string) error {
+		_, err := strconv.Atoi(input)
+		return err
+	}
+	input, err := Prompt(PromptOpts{
+		Input:     strings.NewReader("abc\n42\n"),
+		Output:    &output,
+		Validator: isNumber,
+	})
+	s.Nil(err)
+	s.Equal("42", input)
+	s.Contains(output.String(), "invalid input")
+
+	_, err = Prompt(PromptOpts{
+		Input:       strings.NewReader("a\nb\nc\n"),
+		MaxAttempts: 2,
+		Output:      &bytes.Buffer{},
+		Validator:   isNumber,
+	})
+	s.True(errors.Is(err, ErrInvalidInput))
+	s.True(errors.Is(err, strconv.ErrSyntax))
+	s.Contains(err.Error(), "after 2 attempts")
+}
+
+func (s PromptTests) TestPromptPassword() {
+	var output bytes.Buffer
+	password, err := PromptPassword(PromptPasswordOpts{
+		Question: "password:",
+		Input:    strings.NewReader("s3cr3t\n"),
+		Output:   &output,
+	})
+	s.Nil(err)
+	s.Equal("s3cr3t", password)
+	s.Equal("password: ", output.String())
+}
+
+func (s PromptTests) TestPromptPassword_Confirm() {
+	var output bytes.Buffer
+	password, err := PromptPassword(PromptPasswordOpts{
+		Confirm: true,
+		Input:   strings.NewReader("first\nsecond\nthird\nthird\n"),
+		Output:  &output,
+	})
+	s.Nil(err)
+	s.Equal("third", password)
+	s.Contains(output.String(), "passwords do not match")
+	s.Contains(output.String(), DefaultPromptPasswordConfirmQuestion)
+}
+
+func (s PromptTests) TestPromptPassword_strength() {
+	var output bytes.Buffer
+	password, err := PromptPassword(PromptPasswordOpts{
+		Input:               strings.NewReader("short\nlongenough\nL0ngEnough!\n"),
+		MinCharacterClasses: 3,
+		MinLength:           8,
+		Output:              &output,
+	})
+	s.Nil(err)
+	s.Equal("L0ngEnough!", password)
+	s.Contains(output.String(), "at least 8 characters")
+	s.Contains(output.String(), "at least 3 of")
+}
+
+func (s PromptTests) TestPromptPassword_Validation() {
+	_, err := PromptPassword(PromptPasswordOpts{MinCharacterClasses: 5})
+	s.True(errors.Is(err, ErrInvalidOptions))
+}
@@ -0,0 +1,361 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimiterRecoveryFactor defines the default fraction of
+// .RequestsPerSecond which the rate of a host recovers by after every
+// successful response following a slowdown
+const DefaultRateLimiterRecoveryFactor = 0.1
+
+// NewRateLimiterOpts presents configuration for the
+// NewRateLimiter method
+type NewRateLimiterOpts struct {
+	// Burst defines the number of requests which can be sent to a
+	// host at once before .RequestsPerSecond applies
+	//
+	// Defaults to .RequestsPerSecond rounded up (minimum 1) if not
+	// specified
+	Burst int
+
+	// DisableAdaptive when set to true does not slow down requests to
+	// a host after it responds with 429 Too Many Requests. By default,
+	// the rate of the host is halved (down to .MinRequestsPerSecond)
+	// and requests are paused until the time in its Retry-After header
+	DisableAdaptive bool
+
+	// MaxInFlight defines the maximum number of requests to a host
+	// which can be in flight at once, a request is in flight until
+	// its response body is closed. If left as 0, the number of
+	// requests in flight is not limited
+	MaxInFlight int
+
+	// MinRequestsPerSecond defines the lowest rate a host can be
+	// slowed down to after responding with 429 Too Many Requests
+	//
+	// Defaults to a tenth of .RequestsPerSecond if not specified
+	MinRequestsPerSecond float64
+
+	// RecoveryFactor defines the fraction of .RequestsPerSecond which
+	// the rate of a slowed down host recovers by after every
+	// successful response
+	//
+	// Defaults to DefaultRateLimiterRecoveryFactor if not specified
+	RecoveryFactor float64
+
+	// RequestsPerSecond defines the number of requests which can be
+	// sent to each host per second. If left as 0, the rate is not
+	// limited
+	RequestsPerSecond float64
+
+	// Transport defines the transport used by .GetClient to send
+	// requests. If left nil, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *NewRateLimiterOpts) SetDefaults() {
+	if o.Burst == 0 {
+		o.Burst = int(math.Max(1, math.Ceil(o.RequestsPerSecond)))
+	}
+	if o.MinRequestsPerSecond == 0 {
+		o.MinRequestsPerSecond = o.RequestsPerSecond / 10
+	}
+	if o.RecoveryFactor == 0 {
+		o.RecoveryFactor = DefaultRateLimiterRecoveryFactor
+	}
+	if o.Transport == nil {
+		o.Transport = http.DefaultTransport
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the NewRateLimiter method
+func (o NewRateLimiterOpts) Validate() error {
+	errors := []string{}
+
+	if o.Burst < 0 {
+		errors = append(errors, "burst cannot be negative")
+	}
+
+	if o.MaxInFlight < 0 {
+		errors = append(errors, "max in flight cannot be negative")
+	}
+
+	if o.MinRequestsPerSecond < 0 {
+		errors = append(errors, "min requests per second cannot be negative")
+	} else if o.MinRequestsPerSecond > o.RequestsPerSecond {
+		errors = append(errors, "min requests per second cannot be more than requests per second")
+	}
+
+	if o.RecoveryFactor < 0 || o.RecoveryFactor > 1 {
+		errors = append(errors, "recovery factor must be between 0 and 1")
+	}
+
+	if o.RequestsPerSecond < 0 {
+		errors = append(errors, "requests per second cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// RateLimiterMetrics describes the requests sent to a host through a
+// RateLimiter
+type RateLimiterMetrics struct {
+	// InFlight is the number of requests currently in flight
+	InFlight int
+
+	// Requests is the number of requests sent
+	Requests int64
+
+	// RequestsPerSecond is the current rate of the host after any
+	// slowdowns, 0 if the rate is not limited
+	RequestsPerSecond float64
+
+	// Throttled is the number of 429 Too Many Requests responses
+	// received
+	Throttled int64
+
+	// WaitDuration is the total time requests spent waiting for the
+	// rate limit and for requests in flight to complete
+	WaitDuration time.Duration
+}
+
+// RateLimiter limits the rate and the number of requests in flight to
+// each host, set it as the .RateLimiter of SendHTTPRequest,
+// DownloadFile and UploadFile or use .GetClient to get a client which
+// uses it. A single RateLimiter should be shared by all requests which
+// need to be limited together. It is safe for concurrent use
+type RateLimiter struct {
+	opts  NewRateLimiterOpts
+	mutex sync.Mutex
+	hosts map[string]*rateLimiterHost
+}
+
+// rateLimiterHost holds the token bucket, the in flight semaphore and
+// the metrics of a host
+type rateLimiterHost struct {
+	inFlight    chan struct{}
+	metrics     RateLimiterMetrics
+	pausedUntil time.Time
+	rate        float64
+	tokens      float64
+	updatedAt   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter as configured by the options
+// object instance `opts`
+func NewRateLimiter(opts NewRateLimiterOpts) (*RateLimiter, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to create rate limiter: %w", err)
+	}
+	return &RateLimiter{
+		opts:  opts,
+		hosts: map[string]*rateLimiterHost{},
+	}, nil
+}
+
+// GetClient returns a HTTP client which sends requests through .Transport
+// using this rate limiter
+func (r *RateLimiter) GetClient() *http.Client {
+	return &http.Client{Transport: r.wrapTransport(r.opts.Transport)}
+}
+
+// GetMetrics returns the metrics of every host a request was sent to
+// keyed by host
+func (r *RateLimiter) GetMetrics() map[string]RateLimiterMetrics {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	metrics := map[string]RateLimiterMetrics{}
+	for hostname, host := range r.hosts {
+		hostMetrics := host.metrics
+		hostMetrics.InFlight = len(host.inFlight)
+		if r.opts.RequestsPerSecond > 0 {
+			hostMetrics.RequestsPerSecond = host.rate
+		}
+		metrics[hostname] = hostMetrics
+	}
+	return metrics
+}
+
+// Wait blocks until a request can be sent to `host` and returns a
+// function which must be called once the request is complete, use
+// this to rate limit requests which are not sent over HTTP
+func (r *RateLimiter) Wait(ctx context.Context, host string) (func(), error) {
+	startedAt := time.Now()
+	state := r.getHost(host)
+	defer func() {
+		r.mutex.Lock()
+		state.metrics.WaitDuration += time.Since(startedAt)
+		r.mutex.Unlock()
+	}()
+
+	release := func() {}
+	if state.inFlight != nil {
+		select {
+		case state.inFlight <- struct{}{}:
+			var once sync.Once
+			release = func() { once.Do(func() { <-state.inFlight }) }
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for requests to '%s' in flight: %w", host, ctx.Err())
+		}
+	}
+
+	delay, isReserved := r.reserve(state)
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			if isReserved {
+				r.mutex.Lock()
+				state.tokens++
+				r.mutex.Unlock()
+			}
+			release()
+			return nil, fmt.Errorf("failed to wait for rate limit of '%s': %w", host, ctx.Err())
+		}
+	}
+
+	r.mutex.Lock()
+	state.metrics.Requests++
+	r.mutex.Unlock()
+	return release, nil
+}
+
+// getHost returns the state of a host, creating it if it does not
+// exist
+func (r *RateLimiter) getHost(host string) *rateLimiterHost {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	state, ok := r.hosts[host]
+	if !ok {
+		state = &rateLimiterHost{
+			rate:      r.opts.RequestsPerSecond,
+			tokens:    float64(r.opts.Burst),
+			updatedAt: time.Now(),
+		}
+		if r.opts.MaxInFlight > 0 {
+			state.inFlight = make(chan struct{}, r.opts.MaxInFlight)
+		}
+		r.hosts[host] = state
+	}
+	return state
+}
+
+// reserve takes a token from the bucket of a host and returns the
+// duration to wait before it can be used and whether a token was
+// taken, tokens can go negative so that concurrent requests wait in
+// turn
+func (r *RateLimiter) reserve(state *rateLimiterHost) (time.Duration, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	delay := time.Duration(0)
+	if now.Before(state.pausedUntil) {
+		delay = state.pausedUntil.Sub(now)
+	}
+	if state.rate <= 0 {
+		return delay, false
+	}
+	elapsed := now.Sub(state.updatedAt).Seconds()
+	state.tokens = math.Min(float64(r.opts.Burst), state.tokens+elapsed*state.rate)
+	state.updatedAt = now
+	state.tokens--
+	if state.tokens < 0 {
+		tokenDelay := time.Duration(-state.tokens / state.rate * float64(time.Second))
+		if tokenDelay > delay {
+			delay = tokenDelay
+		}
+	}
+	return delay, true
+}
+
+// observe adapts the rate of a host to the response it sent
+func (r *RateLimiter) observe(host string, res *http.Response) {
+	state := r.getHost(host)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if res.StatusCode != http.StatusTooManyRequests {
+		if state.rate > 0 && state.rate < r.opts.RequestsPerSecond {
+			state.rate = math.Min(r.opts.RequestsPerSecond, state.rate+r.opts.RequestsPerSecond*r.opts.RecoveryFactor)
+		}
+		return
+	}
+	state.metrics.Throttled++
+	if r.opts.DisableAdaptive {
+		return
+	}
+	if state.rate > 0 {
+		state.rate = math.Max(r.opts.MinRequestsPerSecond, state.rate/2)
+		if state.tokens > 0 {
+			state.tokens = 0
+		}
+	}
+	if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+		if pausedUntil := time.Now().Add(retryAfter); pausedUntil.After(state.pausedUntil) {
+			state.pausedUntil = pausedUntil
+		}
+	}
+}
+
+// wrapTransport returns a transport which sends requests through
+// `transport` using this rate limiter
+func (r *RateLimiter) wrapTransport(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &rateLimitedTransport{limiter: r, transport: transport}
+}
+
+// rateLimitedTransport is a http.RoundTripper which waits for a
+// RateLimiter before sending each request
+type rateLimitedTransport struct {
+	limiter   *RateLimiter
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.Wait(req.Context(), req.URL.Host)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	res, err := t.transport.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	t.limiter.observe(req.URL.Host, res)
+	res.Body = &releaseOnCloseReader{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+// releaseOnCloseReader releases a request in flight when the response
+// body is closed
+type releaseOnCloseReader struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the response body and releases the request
+func (r *releaseOnCloseReader) Close() error {
+	defer r.release()
+	return r.ReadCloser.Close()
+}
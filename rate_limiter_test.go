@@ -0,0 +1,141 @@
+package devops
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimiterTests struct {
+	suite.Suite
+}
+
+func TestRateLimiter(t *testing.T) {
+	suite.Run(t, &RateLimiterTests{})
+}
+
+func (s RateLimiterTests) TestSendHTTPRequest_rate() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {})
+	limiter, err := NewRateLimiter(NewRateLimiterOpts{Burst: 1, RequestsPerSecond: 20})
+	s.Nil(err)
+	startedAt := time.Now()
+	for i := 0; i < 5; i++ {
+		res, err := SendHTTPRequest(SendHTTPRequestOpts{RateLimiter: limiter, URL: serverURL})
+		s.Nil(err)
+		res.Body.Close()
+	}
+	s.GreaterOrEqual(time.Since(startedAt), 150*time.Millisecond, "requests after the burst should wait for tokens")
+	metrics := limiter.GetMetrics()[serverURL.Host]
+	s.Equal(int64(5), metrics.Requests)
+	s.Equal(0, metrics.InFlight)
+	s.Equal(float64(20), metrics.RequestsPerSecond)
+	s.Greater(metrics.WaitDuration, time.Duration(0))
+}
+
+func (s RateLimiterTests) TestGetClient_maxInFlight() {
+	var inFlight, maxInFlight int32
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	})
+	limiter, err := NewRateLimiter(NewRateLimiterOpts{MaxInFlight: 2})
+	s.Nil(err)
+	client := limiter.GetClient()
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			res, err := client.Get(serverURL.String())
+			s.Nil(err)
+			res.Body.Close()
+		}()
+	}
+	waitGroup.Wait()
+	s.Equal(int32(2), maxInFlight)
+	metrics := limiter.GetMetrics()[serverURL.Host]
+	s.Equal(int64(6), metrics.Requests)
+	s.Equal(0, metrics.InFlight)
+	s.Equal(float64(0), metrics.RequestsPerSecond)
+}
+
+func (s RateLimiterTests) TestSendHTTPRequest_adaptive() {
+	requests := int32(0)
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	})
+	limiter, err := NewRateLimiter(NewRateLimiterOpts{RequestsPerSecond: 100, Burst: 10})
+	s.Nil(err)
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{RateLimiter: limiter, URL: serverURL})
+	s.Nil(err)
+	res.Body.Close()
+	s.Equal(http.StatusTooManyRequests, res.StatusCode)
+	metrics := limiter.GetMetrics()[serverURL.Host]
+	s.Equal(int64(1), metrics.Throttled)
+	s.Equal(float64(50), metrics.RequestsPerSecond)
+
+	startedAt := time.Now()
+	res, err = SendHTTPRequest(SendHTTPRequestOpts{RateLimiter: limiter, URL: serverURL})
+	s.Nil(err)
+	res.Body.Close()
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.GreaterOrEqual(time.Since(startedAt), 900*time.Millisecond, "requests should be paused until the Retry-After time")
+	s.Equal(float64(60), limiter.GetMetrics()[serverURL.Host].RequestsPerSecond, "rate should recover after a successful response")
+}
+
+func (s RateLimiterTests) TestWait_context() {
+	limiter, err := NewRateLimiter(NewRateLimiterOpts{MaxInFlight: 1, RequestsPerSecond: 1})
+	s.Nil(err)
+	release, err := limiter.Wait(context.Background(), "example.com")
+	s.Nil(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Wait(ctx, "example.com")
+	s.True(errors.Is(err, context.DeadlineExceeded))
+	s.Contains(err.Error(), "in flight")
+
+	release()
+	release()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Wait(ctx, "example.com")
+	s.True(errors.Is(err, context.DeadlineExceeded))
+	s.Contains(err.Error(), "rate limit")
+	s.Equal(0, limiter.GetMetrics()["example.com"].InFlight)
+
+	_, err = limiter.Wait(context.Background(), "other.example.com")
+	s.Nil(err, "hosts should be limited separately")
+}
+
+func (s RateLimiterTests) TestNewRateLimiterOpts_Validate() {
+	opts := NewRateLimiterOpts{Burst: -1, MaxInFlight: -1, MinRequestsPerSecond: 2, RecoveryFactor: 2, RequestsPerSecond: 1}
+	err := opts.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "burst cannot be negative")
+	s.Contains(err.Error(), "max in flight cannot be negative")
+	s.Contains(err.Error(), "min requests per second cannot be more than requests per second")
+	s.Contains(err.Error(), "recovery factor must be between 0 and 1")
+
+	opts = NewRateLimiterOpts{RequestsPerSecond: 5}
+	opts.SetDefaults()
+	s.Nil(opts.Validate())
+	s.Equal(5, opts.Burst)
+	s.Equal(0.5, opts.MinRequestsPerSecond)
+}
@@ -0,0 +1,258 @@
+package devops
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultFormContentType      = "application/x-www-form-urlencoded"
+	DefaultMultipartContentType = "application/octet-stream"
+)
+
+// MultipartForm defines a multipart/form-data body which is streamed
+// so that files are not loaded into memory
+type MultipartForm struct {
+	// Fields defines the form fields, fields are sent in the order of
+	// their names before .Files
+	Fields map[string][]string
+
+	// Files defines the files to send
+	Files []MultipartFile
+}
+
+// MultipartFile defines a file sent as part of a MultipartForm
+type MultipartFile struct {
+	// ContentType defines the content type of the file
+	//
+	// Defaults to DefaultMultipartContentType if not specified
+	ContentType string
+
+	// FieldName defines the name of the form field
+	FieldName string
+
+	// FileName defines the file name sent to the server
+	//
+	// Defaults to the base name of .Path if not specified
+	FileName string
+
+	// Path defines the path of the file to send. Cannot be used
+	// with .Reader
+	Path string
+
+	// Reader defines the content of the file to send. Requests with a
+	// .Reader are only retried if it implements io.Seeker. Cannot be
+	// used with .Path
+	Reader io.Reader
+
+	// Size defines the number of bytes in .Reader so that the
+	// Content-Length of the request can be set. If left as 0, the
+	// request is sent using chunked transfer encoding
+	Size int64
+}
+
+// Validate verifies that this object instance is usable
+func (f MultipartForm) Validate() error {
+	errors := []string{}
+
+	for index, file := range f.Files {
+		if file.FieldName == "" {
+			errors = append(errors, fmt.Sprintf("missing field name of file %v", index))
+		}
+		if (file.Path == "") == (file.Reader == nil) {
+			errors = append(errors, fmt.Sprintf("file %v must have either a path or a reader", index))
+		}
+		if file.Size < 0 {
+			errors = append(errors, fmt.Sprintf("size of file %v cannot be negative", index))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// isReplayable returns true if the form can be sent more than once
+func (f MultipartForm) isReplayable() bool {
+	for _, file := range f.Files {
+		if file.Reader != nil && !isReaderReplayable(file.Reader) {
+			return false
+		}
+	}
+	return true
+}
+
+// getBody returns the body, content length and content type of the
+// form for the provided attempt (starting from 1). The content length
+// is -1 if it is not known
+func (f MultipartForm) getBody(attempt int) (io.ReadCloser, int64, string, error) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	fieldNames := []string{}
+	for fieldName := range f.Fields {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		for _, value := range f.Fields[fieldName] {
+			if err := writer.WriteField(fieldName, value); err != nil {
+				return nil, 0, "", fmt.Errorf("failed to write form field '%s': %w", fieldName, err)
+			}
+		}
+	}
+
+	// the form is split into segments of part headers which are kept
+	// in memory and file contents which are streamed
+	segments := []io.Reader{}
+	closers := multiCloser{}
+	var length int64
+	for _, file := range f.Files {
+		header := textproto.MIMEHeader{}
+		fileName := file.FileName
+		if fileName == "" {
+			fileName = filepath.Base(file.Path)
+		}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeMultipartQuotes(file.FieldName), escapeMultipartQuotes(fileName)))
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = DefaultMultipartContentType
+		}
+		header.Set("Content-Type", contentType)
+		if _, err := writer.CreatePart(header); err != nil {
+			closers.Close()
+			return nil, 0, "", fmt.Errorf("failed to write form file '%s': %w", file.FieldName, err)
+		}
+		segment := append([]byte{}, buffer.Bytes()...)
+		buffer.Reset()
+		segments = append(segments, bytes.NewReader(segment))
+
+		var reader io.Reader
+		size := file.Size
+		if file.Path != "" {
+			/* #nosec - this is required to upload the file */
+			fileHandle, err := os.Open(file.Path)
+			if err != nil {
+				closers.Close()
+				return nil, 0, "", fmt.Errorf("failed to open file at '%s': %w", file.Path, err)
+			}
+			closers = append(closers, fileHandle)
+			fileInfo, err := fileHandle.Stat()
+			if err != nil {
+				closers.Close()
+				return nil, 0, "", fmt.Errorf("failed to access '%s': %w", file.Path, err)
+			}
+			reader, size = fileHandle, fileInfo.Size()
+		} else {
+			var err error
+			if reader, err = rewindReader(file.Reader, attempt); err != nil {
+				closers.Close()
+				return nil, 0, "", err
+			}
+			if size == 0 {
+				size = -1
+			}
+		}
+		segments = append(segments, reader)
+		if length >= 0 && size >= 0 {
+			length += int64(len(segment)) + size
+		} else {
+			length = -1
+		}
+	}
+	if err := writer.Close(); err != nil {
+		closers.Close()
+		return nil, 0, "", fmt.Errorf("failed to complete form: %w", err)
+	}
+	segments = append(segments, bytes.NewReader(buffer.Bytes()))
+	if length >= 0 {
+		length += int64(buffer.Len())
+	}
+	return readCloser{Reader: io.MultiReader(segments...), Closer: closers}, length, writer.FormDataContentType(), nil
+}
+
+// escapeMultipartQuotes escapes a value for use in a quoted string of
+// a Content-Disposition header in the same way as mime/multipart
+func escapeMultipartQuotes(value string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(value)
+}
+
+// isReaderReplayable returns true if the reader can be read again
+// by seeking to its start
+func isReaderReplayable(reader io.Reader) bool {
+	_, ok := reader.(io.Seeker)
+	return ok
+}
+
+// offsetReadSeeker is an io.ReadSeeker whose start is the offset the
+// underlying reader was at when it was provided so that retries send
+// the same bytes as the first attempt
+type offsetReadSeeker struct {
+	io.ReadSeeker
+	start int64
+}
+
+func (r offsetReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekStart {
+		offset += r.start
+	}
+	position, err := r.ReadSeeker.Seek(offset, whence)
+	return position - r.start, err
+}
+
+// newRewindableReader records the current offset of the reader if it
+// implements io.Seeker so that rewindReader seeks back to it
+func newRewindableReader(reader io.Reader) (io.Reader, error) {
+	readSeeker, ok := reader.(io.ReadSeeker)
+	if !ok {
+		return reader, nil
+	}
+	start, err := readSeeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the offset of the body: %w", err)
+	}
+	return offsetReadSeeker{ReadSeeker: readSeeker, start: start}, nil
+}
+
+// rewindReader seeks the reader to its start for attempts after the
+// first one
+func rewindReader(reader io.Reader, attempt int) (io.Reader, error) {
+	if attempt <= 1 {
+		return reader, nil
+	}
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return nil, fmt.Errorf("failed to read body again: reader does not implement io.Seeker")
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to the start of the body: %w", err)
+	}
+	return reader, nil
+}
+
+// readCloser combines a reader with a closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// multiCloser closes all of its closers
+type multiCloser []io.Closer
+
+// Close implements io.Closer
+func (c multiCloser) Close() error {
+	var err error
+	for _, closer := range c {
+		if closeError := closer.Close(); closeError != nil && err == nil {
+			err = closeError
+		}
+	}
+	return err
+}
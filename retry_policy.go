@@ -0,0 +1,168 @@
+package devops
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryInitialBackoff = 500 * time.Millisecond
+	DefaultRetryMaxBackoff     = 30 * time.Second
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryJitter         = 0.5
+)
+
+// RetryPolicy defines how failed HTTP requests are retried. Requests
+// are retried on connection errors, 429 Too Many Requests and 5xx
+// status codes (except 501 Not Implemented)
+type RetryPolicy struct {
+	// MaxAttempts defines the maximum number of attempts including
+	// the first one
+	//
+	// Defaults to DefaultRetryMaxAttempts if not specified
+	MaxAttempts int
+
+	// InitialBackoff defines the duration to wait before the first
+	// retry, the duration is multiplied by .Multiplier for every
+	// subsequent retry
+	//
+	// Defaults to DefaultRetryInitialBackoff if not specified
+	InitialBackoff time.Duration
+
+	// MaxBackoff defines the maximum duration to wait between attempts
+	// including durations from Retry-After headers
+	//
+	// Defaults to DefaultRetryMaxBackoff if not specified
+	MaxBackoff time.Duration
+
+	// Multiplier defines the factor the backoff is multiplied by after
+	// every retry
+	//
+	// Defaults to DefaultRetryMultiplier if not specified
+	Multiplier float64
+
+	// Jitter defines the fraction (between 0 and 1) of the backoff that
+	// is randomly removed so that clients do not retry at the same time
+	//
+	// Defaults to DefaultRetryJitter if not specified, set to a
+	// negative value to disable jitter
+	Jitter float64
+
+	// RetryNonIdempotent when set to true allows requests with methods
+	// that are not idempotent (eg. POST and PATCH) to be retried.
+	// These requests are also retried if they have an Idempotency-Key
+	// header
+	RetryNonIdempotent bool
+}
+
+// SetDefaults sets defaults for this object instance
+func (p *RetryPolicy) SetDefaults() {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = DefaultRetryMultiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = DefaultRetryJitter
+	}
+}
+
+// Validate verifies that this object instance is usable
+func (p RetryPolicy) Validate() error {
+	errors := []string{}
+	if p.MaxAttempts < 0 {
+		errors = append(errors, "max attempts cannot be negative")
+	}
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		errors = append(errors, "backoff cannot be negative")
+	}
+	if p.Multiplier < 0 {
+		errors = append(errors, "multiplier cannot be negative")
+	}
+	if p.Jitter > 1 {
+		errors = append(errors, "jitter cannot be more than 1")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// CanRetry returns true if a request with the provided method and
+// headers can be retried
+func (p RetryPolicy) CanRetry(method string, headers http.Header) bool {
+	if p.MaxAttempts <= 1 {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return p.RetryNonIdempotent || headers.Get("Idempotency-Key") != ""
+}
+
+// IsRetryableStatusCode returns true for 429 Too Many Requests and 5xx
+// status codes except 501 Not Implemented
+func (p RetryPolicy) IsRetryableStatusCode(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests ||
+		(statusCode >= 500 && statusCode <= 599 && statusCode != http.StatusNotImplemented)
+}
+
+// GetBackoff returns the duration to wait after the provided attempt
+// (starting from 1). The Retry-After header of the response is used
+// if present
+func (p RetryPolicy) GetBackoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			if retryAfter > p.MaxBackoff {
+				return p.MaxBackoff
+			}
+			return retryAfter
+		}
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		/* #nosec - jitter does not need to be cryptographically secure */
+		backoff -= backoff * p.Jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// parseRetryAfter parses a Retry-After header value which is either a
+// number of seconds or a HTTP date
+func parseRetryAfter(retryAfter string) (time.Duration, bool) {
+	if retryAfter == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if retryAt, err := http.ParseTime(retryAfter); err == nil {
+		duration := time.Until(retryAt)
+		if duration < 0 {
+			duration = 0
+		}
+		return duration, true
+	}
+	return 0, false
+}
@@ -0,0 +1,85 @@
+package devops
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RetryPolicyTests struct {
+	suite.Suite
+}
+
+func TestRetryPolicy(t *testing.T) {
+	suite.Run(t, &RetryPolicyTests{})
+}
+
+func (s RetryPolicyTests) TestRetryPolicy_CanRetry() {
+	policy := RetryPolicy{}
+	policy.SetDefaults()
+	s.True(policy.CanRetry(http.MethodGet, http.Header{}))
+	s.True(policy.CanRetry(http.MethodPut, http.Header{}))
+	s.False(policy.CanRetry(http.MethodPost, http.Header{}))
+	s.True(policy.CanRetry(http.MethodPost, http.Header{"Idempotency-Key": {"abc"}}))
+	policy.RetryNonIdempotent = true
+	s.True(policy.CanRetry(http.MethodPatch, http.Header{}))
+	policy.MaxAttempts = 1
+	s.False(policy.CanRetry(http.MethodGet, http.Header{}))
+}
+
+func (s RetryPolicyTests) TestRetryPolicy_IsRetryableStatusCode() {
+	policy := RetryPolicy{}
+	s.True(policy.IsRetryableStatusCode(http.StatusTooManyRequests))
+	s.True(policy.IsRetryableStatusCode(http.StatusServiceUnavailable))
+	s.False(policy.IsRetryableStatusCode(http.StatusNotImplemented))
+	s.False(policy.IsRetryableStatusCode(http.StatusNotFound))
+	s.False(policy.IsRetryableStatusCode(http.StatusOK))
+}
+
+func (s RetryPolicyTests) TestRetryPolicy_GetBackoff() {
+	policy := RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         -1,
+	}
+	policy.SetDefaults()
+	s.Equal(time.Second, policy.GetBackoff(1, nil))
+	s.Equal(2*time.Second, policy.GetBackoff(2, nil))
+	s.Equal(4*time.Second, policy.GetBackoff(3, nil))
+	s.Equal(5*time.Second, policy.GetBackoff(4, nil), "backoff should be capped")
+
+	res := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	s.Equal(3*time.Second, policy.GetBackoff(1, res), "Retry-After should be respected")
+	res.Header.Set("Retry-After", "60")
+	s.Equal(5*time.Second, policy.GetBackoff(1, res), "Retry-After should be capped")
+
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		backoff := policy.GetBackoff(2, nil)
+		s.GreaterOrEqual(backoff, time.Second)
+		s.LessOrEqual(backoff, 2*time.Second)
+	}
+}
+
+func (s RetryPolicyTests) TestRetryPolicy_Validate() {
+	err := RetryPolicy{MaxAttempts: -1, Jitter: 2}.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "max attempts cannot be negative")
+	s.Contains(err.Error(), "jitter cannot be more than 1")
+}
+
+func (s RetryPolicyTests) Test_parseRetryAfter() {
+	duration, ok := parseRetryAfter("120")
+	s.True(ok)
+	s.Equal(2*time.Minute, duration)
+	duration, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	s.True(ok)
+	s.InDelta(float64(time.Hour), float64(duration), float64(2*time.Second))
+	_, ok = parseRetryAfter("soon")
+	s.False(ok)
+	_, ok = parseRetryAfter("")
+	s.False(ok)
+}
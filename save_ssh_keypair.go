@@ -0,0 +1,245 @@
+package devops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	DefaultSSHDirectory                      = "~/.ssh"
+	DefaultSSHDirectoryMode      os.FileMode = 0700
+	DefaultSSHPrivateKeyFileMode os.FileMode = 0600
+	DefaultSSHPublicKeyFileMode  os.FileMode = 0644
+	DefaultSSHBackupTimeFormat               = "20060102150405"
+)
+
+// sshKeyFileNames maps public key types to the file names used by
+// ssh-keygen
+var sshKeyFileNames = map[string]string{
+	ssh.KeyAlgoECDSA256: "id_ecdsa",
+	ssh.KeyAlgoECDSA384: "id_ecdsa",
+	ssh.KeyAlgoECDSA521: "id_ecdsa",
+	ssh.KeyAlgoED25519:  "id_ed25519",
+	ssh.KeyAlgoRSA:      "id_rsa",
+}
+
+// SaveSSHKeypairOpts presents configuration for the
+// SaveSSHKeypair method
+type SaveSSHKeypairOpts struct {
+	// Backup when set to true renames existing keys at .PrivateKeyPath
+	// and .PublicKeyPath by appending a timestamp in the
+	// DefaultSSHBackupTimeFormat before saving the keypair
+	Backup bool
+
+	// Keypair defines the keypair to save, see NewSSHKeypair
+	Keypair *SSHKeypair
+
+	// Overwrite when set to true allows existing keys at
+	// .PrivateKeyPath and .PublicKeyPath to be replaced without a
+	// backup, ErrRefuseOverwrite is returned otherwise
+	Overwrite bool
+
+	// PrivateKeyPath defines the path to save the private key to,
+	// parent directories which do not exist are created with
+	// DefaultSSHDirectoryMode
+	//
+	// Defaults to the file name used by ssh-keygen for the type of
+	// the key (eg. id_ed25519) in DefaultSSHDirectory if not
+	// specified
+	PrivateKeyPath string
+
+	// PublicKeyPath defines the path to save the public key to
+	//
+	// Defaults to .PrivateKeyPath with a .pub extension if not
+	// specified
+	PublicKeyPath string
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *SaveSSHKeypairOpts) SetDefaults() {
+	if o.PrivateKeyPath == "" && o.Keypair != nil {
+		if publicKey, _, _, _, err := ssh.ParseAuthorizedKey(o.Keypair.Public); err == nil {
+			if fileName, ok := sshKeyFileNames[publicKey.Type()]; ok {
+				o.PrivateKeyPath = filepath.Join(DefaultSSHDirectory, fileName)
+			}
+		}
+	}
+	if o.PublicKeyPath == "" && o.PrivateKeyPath != "" {
+		o.PublicKeyPath = o.PrivateKeyPath + ".pub"
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the SaveSSHKeypair method
+func (o SaveSSHKeypairOpts) Validate() error {
+	errors := []string{}
+
+	if o.Keypair == nil {
+		errors = append(errors, "missing keypair")
+	} else if len(o.Keypair.Private) == 0 || len(o.Keypair.Public) == 0 {
+		errors = append(errors, "missing private or public key")
+	}
+
+	if o.PrivateKeyPath == "" {
+		errors = append(errors, "missing private key path")
+	} else if o.PrivateKeyPath == o.PublicKeyPath {
+		errors = append(errors, "private and public key paths cannot be the same")
+	}
+
+	if o.PublicKeyPath == "" {
+		errors = append(errors, "missing public key path")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// SaveSSHKeypair writes the private key of .Keypair with
+// DefaultSSHPrivateKeyFileMode and the public key with
+// DefaultSSHPublicKeyFileMode as configured by the options object
+// instance `opts`. Existing keys are only replaced when .Overwrite or
+// .Backup is set, both keys are checked before either is written and
+// existing keys are restored if either key cannot be saved
+func SaveSSHKeypair(opts SaveSSHKeypairOpts) error {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("failed to save ssh keypair: %w", err)
+	}
+	privateKeyPath, err := NormalizeLocalPath(opts.PrivateKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to normalize path '%s': %w", opts.PrivateKeyPath, err)
+	}
+	publicKeyPath, err := NormalizeLocalPath(opts.PublicKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to normalize path '%s': %w", opts.PublicKeyPath, err)
+	}
+
+	existingPaths := []string{}
+	for _, keyPath := range []string{privateKeyPath, publicKeyPath} {
+		fileInfo, err := os.Lstat(keyPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to access path '%s': %w", keyPath, err)
+		}
+		if fileInfo.IsDir() {
+			return fmt.Errorf("failed to save key to '%s': %w", keyPath, ErrIsDirectory)
+		}
+		if !opts.Overwrite && !opts.Backup {
+			return fmt.Errorf("%w at '%s' (set .Overwrite or .Backup to true)", ErrRefuseOverwrite, keyPath)
+		}
+		existingPaths = append(existingPaths, keyPath)
+	}
+
+	sshDirectory, err := NormalizeLocalPath(DefaultSSHDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to normalize path '%s': %w", DefaultSSHDirectory, err)
+	}
+	for _, keyPath := range []string{privateKeyPath, publicKeyPath} {
+		directory := filepath.Dir(keyPath)
+		if err := os.MkdirAll(directory, DefaultSSHDirectoryMode); err != nil {
+			return fmt.Errorf("failed to create directory for '%s': %w", keyPath, err)
+		}
+		// ssh refuses to use keys from a directory other users can
+		// access so an existing ssh directory is tightened
+		if directory == sshDirectory {
+			if err := restrictDirectoryMode(directory, DefaultSSHDirectoryMode); err != nil {
+				return err
+			}
+		}
+	}
+
+	// both keys are written before existing keys are backed up and
+	// either key is renamed so that a failed write does not leave a new
+	// private key with a stale public key
+	privateKeyTemporaryPath, err := writeTemporaryFile(privateKeyPath, bytes.NewReader(opts.Keypair.Private), DefaultSSHPrivateKeyFileMode)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(privateKeyTemporaryPath)
+	publicKeyTemporaryPath, err := writeTemporaryFile(publicKeyPath, bytes.NewReader(opts.Keypair.Public), DefaultSSHPublicKeyFileMode)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(publicKeyTemporaryPath)
+
+	// existing keys are moved aside before the new keys are moved into
+	// place so that they can be restored if either key cannot be moved
+	backupSuffix := "." + time.Now().Format(DefaultSSHBackupTimeFormat)
+	previousPaths := map[string]string{}
+	for _, keyPath := range existingPaths {
+		var previousPath string
+		if opts.Backup {
+			previousPath, err = backupFile(keyPath, backupSuffix)
+		} else {
+			previousPath, err = moveToTemporaryFile(keyPath)
+		}
+		if err != nil {
+			return errors.Join(err, restoreSSHKeys(previousPaths, nil))
+		}
+		previousPaths[keyPath] = previousPath
+	}
+	savedPaths := []string{}
+	for _, paths := range [][2]string{{privateKeyTemporaryPath, privateKeyPath}, {publicKeyTemporaryPath, publicKeyPath}} {
+		temporaryPath, keyPath := paths[0], paths[1]
+		if err := os.Rename(temporaryPath, keyPath); err != nil {
+			err = fmt.Errorf("failed to move '%s' to '%s': %w", temporaryPath, keyPath, err)
+			return errors.Join(err, restoreSSHKeys(previousPaths, savedPaths))
+		}
+		savedPaths = append(savedPaths, keyPath)
+	}
+	if !opts.Backup {
+		for _, previousPath := range previousPaths {
+			os.Remove(previousPath)
+		}
+	}
+	return nil
+}
+
+// restoreSSHKeys removes the keys saved at `savedPaths` and moves the
+// previous keys in `previousPaths` back to the paths they are keyed by
+func restoreSSHKeys(previousPaths map[string]string, savedPaths []string) error {
+	restoreErrors := []error{}
+	for _, keyPath := range savedPaths {
+		if _, ok := previousPaths[keyPath]; ok {
+			continue
+		}
+		if err := os.Remove(keyPath); err != nil {
+			restoreErrors = append(restoreErrors, fmt.Errorf("failed to remove '%s': %w", keyPath, err))
+		}
+	}
+	for keyPath, previousPath := range previousPaths {
+		if err := os.Rename(previousPath, keyPath); err != nil {
+			restoreErrors = append(restoreErrors, fmt.Errorf("failed to restore '%s' from '%s': %w", keyPath, previousPath, err))
+		}
+	}
+	return errors.Join(restoreErrors...)
+}
+
+// backupFile renames the file at `filePath` by appending `suffix` and
+// a counter if a backup with the same suffix exists and returns the
+// path of the backup
+func backupFile(filePath, suffix string) (string, error) {
+	backupPath := filePath + suffix
+	for counter := 1; ; counter++ {
+		if _, err := os.Lstat(backupPath); errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to access path '%s': %w", backupPath, err)
+		}
+		backupPath = fmt.Sprintf("%s%s.%v", filePath, suffix, counter)
+	}
+	if err := os.Rename(filePath, backupPath); err != nil {
+		return "", fmt.Errorf("failed to back up '%s' to '%s': %w", filePath, backupPath, err)
+	}
+	return backupPath, nil
+}
@@ -0,0 +1,166 @@
+package devops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SaveSSHKeypairTests struct {
+	suite.Suite
+}
+
+func TestSaveSSHKeypair(t *testing.T) {
+	suite.Run(t, &SaveSSHKeypairTests{})
+}
+
+func (s SaveSSHKeypairTests) getKeypair() *SSHKeypair {
+	keypair, err := NewSSHKeypair(NewSSHKeypairOpts{Type: SSHKeyTypeEd25519})
+	s.Nil(err)
+	return keypair
+}
+
+func (s SaveSSHKeypairTests) TestSaveSSHKeypair() {
+	homeDirectory := s.T().TempDir()
+	s.T().Setenv("HOME", homeDirectory)
+	keypair := s.getKeypair()
+	s.Nil(SaveSSHKeypair(SaveSSHKeypairOpts{Keypair: keypair}))
+
+	sshDirectory := filepath.Join(homeDirectory, ".ssh")
+	fileInfo, err := os.Stat(sshDirectory)
+	s.Nil(err)
+	s.Equal(DefaultSSHDirectoryMode, fileInfo.Mode().Perm())
+	fileInfo, err = os.Stat(filepath.Join(sshDirectory, "id_ed25519"))
+	s.Nil(err)
+	s.Equal(DefaultSSHPrivateKeyFileMode, fileInfo.Mode().Perm())
+	fileInfo, err = os.Stat(filepath.Join(sshDirectory, "id_ed25519.pub"))
+	s.Nil(err)
+	s.Equal(DefaultSSHPublicKeyFileMode, fileInfo.Mode().Perm())
+	privateKey, err := os.ReadFile(filepath.Join(sshDirectory, "id_ed25519"))
+	s.Nil(err)
+	s.Equal(keypair.Private, privateKey)
+	publicKey, err := os.ReadFile(filepath.Join(sshDirectory, "id_ed25519.pub"))
+	s.Nil(err)
+	s.Equal(keypair.Public, publicKey)
+
+	entries, err := os.ReadDir(sshDirectory)
+	s.Nil(err)
+	s.Len(entries, 2, "temporary files should not be left behind")
+}
+
+func (s SaveSSHKeypairTests) TestSaveSSHKeypair_sshDirectoryMode() {
+	homeDirectory := s.T().TempDir()
+	s.T().Setenv("HOME", homeDirectory)
+	sshDirectory := filepath.Join(homeDirectory, ".ssh")
+	s.Nil(os.Mkdir(sshDirectory, 0700))
+	s.Nil(os.Chmod(sshDirectory, 0775))
+	s.Nil(SaveSSHKeypair(SaveSSHKeypairOpts{Keypair: s.getKeypair()}))
+	fileInfo, err := os.Stat(sshDirectory)
+	s.Nil(err)
+	s.Equal(DefaultSSHDirectoryMode, fileInfo.Mode().Perm(), "an existing ssh directory should be restricted")
+}
+
+func (s SaveSSHKeypairTests) TestSaveSSHKeypair_refuseOverwrite() {
+	privateKeyPath := filepath.Join(s.T().TempDir(), "keys", "deploy")
+	s.Nil(SaveSSHKeypair(SaveSSHKeypairOpts{Keypair: s.getKeypair(), PrivateKeyPath: privateKeyPath}))
+	s.Nil(os.Remove(privateKeyPath))
+
+	err := SaveSSHKeypair(SaveSSHKeypairOpts{Keypair: s.getKeypair(), PrivateKeyPath: privateKeyPath})
+	s.True(errors.Is(err, ErrRefuseOverwrite))
+	s.Contains(err.Error(), "deploy.pub")
+	_, err = os.Stat(privateKeyPath)
+	s.True(errors.Is(err, os.ErrNotExist), "no key should be written if either key exists")
+
+	keypair := s.getKeypair()
+	s.Nil(SaveSSHKeypair(SaveSSHKeypairOpts{Keypair: keypair, Overwrite: true, PrivateKeyPath: privateKeyPath}))
+	publicKey, err := os.ReadFile(privateKeyPath + ".pub")
+	s.Nil(err)
+	s.Equal(keypair.Public, publicKey)
+	entries, err := os.ReadDir(filepath.Dir(privateKeyPath))
+	s.Nil(err)
+	s.Len(entries, 2, "overwritten keys should not be left behind")
+}
+
+func (s SaveSSHKeypairTests) TestSaveSSHKeypair_failedWrite() {
+	directory := s.T().TempDir()
+	privateKeyPath := filepath.Join(directory, "id_ed25519")
+	// the name of the temporary file for this path is too long
+	publicKeyPath := filepath.Join(directory, strings.Repeat("k", 250))
+	err := SaveSSHKeypair(SaveSSHKeypairOpts{Keypair: s.getKeypair(), PrivateKeyPath: privateKeyPath, PublicKeyPath: publicKeyPath})
+	s.NotNil(err)
+	entries, err := os.ReadDir(directory)
+	s.Nil(err)
+	s.Empty(entries, "the private key should not be saved if the public key cannot be written")
+}
+
+func (s SaveSSHKeypairTests) TestSaveSSHKeypair_backup() {
+	directory := s.T().TempDir()
+	privateKeyPath := filepath.Join(directory, "id_ed25519")
+	publicKeyPath := filepath.Join(directory, "deploy.pub")
+	firstKeypair := s.getKeypair()
+	s.Nil(SaveSSHKeypair(SaveSSHKeypairOpts{Keypair: firstKeypair, PrivateKeyPath: privateKeyPath, PublicKeyPath: publicKeyPath}))
+	for i := 0; i < 2; i++ {
+		s.Nil(SaveSSHKeypair(SaveSSHKeypairOpts{Backup: true, Keypair: s.getKeypair(), PrivateKeyPath: privateKeyPath, PublicKeyPath: publicKeyPath}))
+	}
+
+	backups, err := filepath.Glob(privateKeyPath + ".*")
+	s.Nil(err)
+	s.Len(backups, 2)
+	publicBackups, err := filepath.Glob(publicKeyPath + ".*")
+	s.Nil(err)
+	s.Len(publicBackups, 2)
+	fileInfo, err := os.Stat(backups[0])
+	s.Nil(err)
+	s.Equal(DefaultSSHPrivateKeyFileMode, fileInfo.Mode().Perm(), "backups should keep their permissions")
+	privateKey, err := os.ReadFile(backups[0])
+	s.Nil(err)
+	s.Equal(firstKeypair.Private, privateKey)
+}
+
+func (s SaveSSHKeypairTests) Test_restoreSSHKeys() {
+	directory := s.T().TempDir()
+	privateKeyPath := filepath.Join(directory, "id_ed25519")
+	publicKeyPath := filepath.Join(directory, "id_ed25519.pub")
+	s.Nil(os.WriteFile(privateKeyPath, []byte("previous"), 0600))
+	previousPath, err := moveToTemporaryFile(privateKeyPath)
+	s.Nil(err)
+	s.Nil(os.WriteFile(privateKeyPath, []byte("saved"), 0600))
+	s.Nil(os.WriteFile(publicKeyPath, []byte("saved"), 0644))
+
+	s.Nil(restoreSSHKeys(map[string]string{privateKeyPath: previousPath}, []string{privateKeyPath, publicKeyPath}))
+	privateKey, err := os.ReadFile(privateKeyPath)
+	s.Nil(err)
+	s.Equal("previous", string(privateKey), "previous keys should be restored")
+	entries, err := os.ReadDir(directory)
+	s.Nil(err)
+	s.Len(entries, 1, "saved keys without a previous key should be removed")
+}
+
+func (s SaveSSHKeypairTests) TestSaveSSHKeypairOpts_SetDefaults() {
+	keypair, err := NewSSHKeypair(NewSSHKeypairOpts{Type: SSHKeyTypeECDSA})
+	s.Nil(err)
+	opts := SaveSSHKeypairOpts{Keypair: keypair}
+	opts.SetDefaults()
+	s.Equal("~/.ssh/id_ecdsa", opts.PrivateKeyPath)
+	s.Equal("~/.ssh/id_ecdsa.pub", opts.PublicKeyPath)
+
+	opts = SaveSSHKeypairOpts{PrivateKeyPath: "./deploy"}
+	opts.SetDefaults()
+	s.Equal("./deploy.pub", opts.PublicKeyPath)
+}
+
+func (s SaveSSHKeypairTests) TestSaveSSHKeypairOpts_Validate() {
+	err := SaveSSHKeypairOpts{}.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "missing keypair")
+	s.Contains(err.Error(), "missing private key path")
+	s.Contains(err.Error(), "missing public key path")
+
+	err = SaveSSHKeypairOpts{Keypair: &SSHKeypair{}, PrivateKeyPath: "key", PublicKeyPath: "key"}.Validate()
+	s.Contains(err.Error(), "missing private or public key")
+	s.Contains(err.Error(), "private and public key paths cannot be the same")
+}
@@ -0,0 +1,436 @@
+package devops
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	DefaultSelectInputHint      = " (enter a number from 1 to %v) "
+	DefaultMultiSelectInputHint = " (enter numbers from 1 to %v separated by commas) "
+)
+
+// SelectOpts presents options for the Select method
+type SelectOpts struct {
+	// Question can optionally be specified for the .Select method to
+	// print a string before listing the options
+	Question string
+
+	// Options defines the list of choices the user can pick from
+	Options []string
+
+	// Default defines the option that is selected when the user
+	// provides an empty input. When defined, it should be one of
+	// the values in .Options
+	Default string
+
+	// Input defines the input stream to read the input from. When this
+	// is a terminal, an interactive menu navigable with the arrow keys
+	// is shown, otherwise a numbered list is printed
+	//
+	// Defaults to os.Stdin if not specified
+	Input io.Reader
+
+	// InputHint is a format string containing a single %v denoting the
+	// number of options available, this is only used when the numbered
+	// list is printed
+	//
+	// Defaults to DefaultSelectInputHint if not specified
+	InputHint string
+
+	// Output defines the output stream to write output to
+	//
+	// Defaults to os.Stdout if not specified
+	Output io.Writer
+}
+
+// SetDefaults checks for unspecified properties which have defaults
+// and adds them
+func (o *SelectOpts) SetDefaults() {
+	if o.Input == nil {
+		o.Input = os.Stdin
+	}
+	if o.InputHint == "" {
+		o.InputHint = DefaultSelectInputHint
+	}
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+}
+
+// Validate runs validation checks against the provided options
+func (o SelectOpts) Validate() error {
+	errors := []string{}
+
+	if len(o.Options) == 0 {
+		errors = append(errors, "missing options")
+	}
+	if o.Default != "" && indexOfString(o.Options, o.Default) < 0 {
+		errors = append(errors, fmt.Sprintf("default '%s' is not one of the options", o.Default))
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// Select prompts the user to pick one of the provided options and
+// returns the selected option
+func Select(opts SelectOpts) (string, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return "", fmt.Errorf("failed to trigger selection: %w", err)
+	}
+	defaults := []string{}
+	if opts.Default != "" {
+		defaults = append(defaults, opts.Default)
+	}
+	if fd, ok := getTerminalFd(opts.Input); ok {
+		selections, err := selectOnTerminal(fd, opts.Input, opts.Output, opts.Question, opts.Options, defaults, false)
+		if err != nil {
+			return "", err
+		}
+		return selections[0], nil
+	}
+
+	input, err := selectWithNumberedList(opts.Input, opts.Output, opts.Question, opts.Options, defaults, opts.InputHint)
+	if err != nil {
+		return "", err
+	}
+	if input == "" {
+		if opts.Default == "" {
+			return "", fmt.Errorf("%w: no option was selected", ErrInvalidSelection)
+		}
+		return opts.Default, nil
+	}
+	index, err := parseSelection(input, opts.Options)
+	if err != nil {
+		return "", err
+	}
+	return opts.Options[index], nil
+}
+
+// MultiSelectOpts presents options for the MultiSelect method
+type MultiSelectOpts struct {
+	// Question can optionally be specified for the .MultiSelect method
+	// to print a string before listing the options
+	Question string
+
+	// Options defines the list of choices the user can pick from
+	Options []string
+
+	// Defaults defines the options that are selected when the user
+	// provides an empty input. When defined, these should be values
+	// in .Options
+	Defaults []string
+
+	// Input defines the input stream to read the input from. When this
+	// is a terminal, an interactive menu navigable with the arrow keys
+	// is shown, otherwise a numbered list is printed
+	//
+	// Defaults to os.Stdin if not specified
+	Input io.Reader
+
+	// InputHint is a format string containing a single %v denoting the
+	// number of options available, this is only used when the numbered
+	// list is printed
+	//
+	// Defaults to DefaultMultiSelectInputHint if not specified
+	InputHint string
+
+	// Output defines the output stream to write output to
+	//
+	// Defaults to os.Stdout if not specified
+	Output io.Writer
+}
+
+// SetDefaults checks for unspecified properties which have defaults
+// and adds them
+func (o *MultiSelectOpts) SetDefaults() {
+	if o.Input == nil {
+		o.Input = os.Stdin
+	}
+	if o.InputHint == "" {
+		o.InputHint = DefaultMultiSelectInputHint
+	}
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+}
+
+// Validate runs validation checks against the provided options
+func (o MultiSelectOpts) Validate() error {
+	errors := []string{}
+
+	if len(o.Options) == 0 {
+		errors = append(errors, "missing options")
+	}
+	for _, defaultOption := range o.Defaults {
+		if indexOfString(o.Options, defaultOption) < 0 {
+			errors = append(errors, fmt.Sprintf("default '%s' is not one of the options", defaultOption))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// MultiSelect prompts the user to pick any number of the provided
+// options and returns the selected options in the order they were
+// provided in .Options
+func MultiSelect(opts MultiSelectOpts) ([]string, error) {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to trigger selection: %w", err)
+	}
+	if fd, ok := getTerminalFd(opts.Input); ok {
+		return selectOnTerminal(fd, opts.Input, opts.Output, opts.Question, opts.Options, opts.Defaults, true)
+	}
+
+	input, err := selectWithNumberedList(opts.Input, opts.Output, opts.Question, opts.Options, opts.Defaults, opts.InputHint)
+	if err != nil {
+		return nil, err
+	}
+	if input == "" {
+		return opts.Defaults, nil
+	}
+	isSelected := map[int]bool{}
+	for _, selection := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		index, err := parseSelection(selection, opts.Options)
+		if err != nil {
+			return nil, err
+		}
+		isSelected[index] = true
+	}
+	selections := []string{}
+	for index, option := range opts.Options {
+		if isSelected[index] {
+			selections = append(selections, option)
+		}
+	}
+	return selections, nil
+}
+
+// selectWithNumberedList prints the options as a numbered list with
+// defaults indicated by a '*' and returns the trimmed user input
+func selectWithNumberedList(input io.Reader, output io.Writer, question string, options, defaults []string, inputHint string) (string, error) {
+	var prompt strings.Builder
+	if question != "" {
+		prompt.WriteString(question + "\n")
+	}
+	for index, option := range options {
+		marker := " "
+		if indexOfString(defaults, option) >= 0 {
+			marker = "*"
+		}
+		prompt.WriteString(fmt.Sprintf("%s %v) %s\n", marker, index+1, option))
+	}
+	prompt.WriteString(fmt.Sprintf(inputHint, len(options)))
+	if _, err := output.Write([]byte(prompt.String())); err != nil {
+		return "", fmt.Errorf("failed to write to output: %w", err)
+	}
+	line, err := readLine(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user input: %w", err)
+	}
+	return strings.Trim(line, " \n\t\r"), nil
+}
+
+// parseSelection returns the index of the option referenced by the
+// provided input which can be either its number or its exact value
+func parseSelection(input string, options []string) (int, error) {
+	if number, err := strconv.Atoi(input); err == nil {
+		if number < 1 || number > len(options) {
+			return -1, fmt.Errorf("%w: '%v' is not between 1 and %v", ErrInvalidSelection, number, len(options))
+		}
+		return number - 1, nil
+	}
+	if index := indexOfString(options, input); index >= 0 {
+		return index, nil
+	}
+	return -1, fmt.Errorf("%w: '%s'", ErrInvalidSelection, input)
+}
+
+// selectOnTerminal switches the terminal to raw mode and runs an
+// interactive menu
+func selectOnTerminal(fd int, input io.Reader, output io.Writer, question string, options, defaults []string, isMulti bool) ([]string, error) {
+	previousState, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to provision a tty: %w", err)
+	}
+	defer term.Restore(fd, previousState)
+	return newSelectMenu(question, options, defaults, isMulti).Run(input, output)
+}
+
+// newSelectMenu returns a selectMenu with the cursor placed on the
+// first default option
+func newSelectMenu(question string, options, defaults []string, isMulti bool) *selectMenu {
+	menu := &selectMenu{
+		isMulti:  isMulti,
+		options:  options,
+		question: question,
+		selected: map[int]bool{},
+	}
+	for _, defaultOption := range defaults {
+		menu.selected[indexOfString(options, defaultOption)] = true
+	}
+	if len(defaults) > 0 {
+		menu.cursor = indexOfString(options, defaults[0])
+	}
+	return menu
+}
+
+// selectMenu holds the state of an interactive menu. The up/down arrow
+// keys move the cursor, printable characters filter the options,
+// space/tab toggles an option (multi-select only) and enter confirms
+type selectMenu struct {
+	cursor        int
+	filter        string
+	isMulti       bool
+	options       []string
+	question      string
+	renderedLines int
+	selected      map[int]bool
+}
+
+// Run reads keystrokes from the input until the user confirms or
+// cancels the selection
+func (m *selectMenu) Run(input io.Reader, output io.Writer) ([]string, error) {
+	reader := bufio.NewReader(input)
+	for {
+		if err := m.render(output); err != nil {
+			return nil, fmt.Errorf("failed to write to output: %w", err)
+		}
+		key, err := reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user input: %w", err)
+		}
+		switch key {
+		case 0x03: // ctrl+c
+			return nil, ErrInterrupted
+		case '\r', '\n':
+			if selections, ok := m.confirm(); ok {
+				_, err := output.Write([]byte("\r\n"))
+				return selections, err
+			}
+		case 0x1b: // escape sequences for arrow keys
+			if next, _ := reader.ReadByte(); next != '[' && next != 'O' {
+				continue
+			}
+			switch direction, _ := reader.ReadByte(); direction {
+			case 'A':
+				m.moveCursor(-1)
+			case 'B':
+				m.moveCursor(1)
+			}
+		case 0x10: // ctrl+p
+			m.moveCursor(-1)
+		case 0x0e: // ctrl+n
+			m.moveCursor(1)
+		case ' ', '\t':
+			if m.isMulti {
+				if visible := m.visibleOptions(); len(visible) > 0 {
+					index := visible[m.cursor]
+					m.selected[index] = !m.selected[index]
+				}
+			} else if key == ' ' {
+				m.setFilter(m.filter + " ")
+			}
+		case 0x7f, 0x08: // backspace
+			if len(m.filter) > 0 {
+				m.setFilter(m.filter[:len(m.filter)-1])
+			}
+		default:
+			if key >= 0x20 && key < 0x7f {
+				m.setFilter(m.filter + string(key))
+			}
+		}
+	}
+}
+
+// confirm returns the current selection and true if there is a valid
+// selection to return
+func (m *selectMenu) confirm() ([]string, bool) {
+	visible := m.visibleOptions()
+	if !m.isMulti {
+		if len(visible) == 0 {
+			return nil, false
+		}
+		return []string{m.options[visible[m.cursor]]}, true
+	}
+	selections := []string{}
+	for index, option := range m.options {
+		if m.selected[index] {
+			selections = append(selections, option)
+		}
+	}
+	return selections, true
+}
+
+func (m *selectMenu) moveCursor(delta int) {
+	visible := m.visibleOptions()
+	if len(visible) == 0 {
+		return
+	}
+	m.cursor = (m.cursor + delta + len(visible)) % len(visible)
+}
+
+func (m *selectMenu) setFilter(filter string) {
+	m.filter = filter
+	m.cursor = 0
+}
+
+// visibleOptions returns the indices of options that contain the
+// current filter (case-insensitive)
+func (m *selectMenu) visibleOptions() []int {
+	visible := []int{}
+	filter := strings.ToLower(m.filter)
+	for index, option := range m.options {
+		if strings.Contains(strings.ToLower(option), filter) {
+			visible = append(visible, index)
+		}
+	}
+	return visible
+}
+
+// render redraws the menu over the previously rendered menu, lines are
+// terminated with \r\n because the terminal is in raw mode
+func (m *selectMenu) render(output io.Writer) error {
+	var screen strings.Builder
+	if m.renderedLines > 0 {
+		screen.WriteString(fmt.Sprintf("\x1b[%vA", m.renderedLines))
+	}
+	screen.WriteString("\r\x1b[J")
+	lines := []string{}
+	if m.question != "" {
+		lines = append(lines, m.question)
+	}
+	lines = append(lines, fmt.Sprintf("filter: %s", m.filter))
+	for position, index := range m.visibleOptions() {
+		cursor := " "
+		if position == m.cursor {
+			cursor = ">"
+		}
+		checkbox := ""
+		if m.isMulti {
+			checkbox = "[ ] "
+			if m.selected[index] {
+				checkbox = "[x] "
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s %s%s", cursor, checkbox, m.options[index]))
+	}
+	screen.WriteString(strings.Join(lines, "\r\n"))
+	m.renderedLines = len(lines) - 1
+	_, err := output.Write([]byte(screen.String()))
+	return err
+}
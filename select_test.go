@@ -0,0 +1,144 @@
+package devops
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SelectTests struct {
+	suite.Suite
+}
+
+func TestSelect(t *testing.T) {
+	suite.Run(t, &SelectTests{})
+}
+
+func (s SelectTests) TestSelect_number() {
+	var output bytes.Buffer
+	selection, err := Select(SelectOpts{
+		Question: "environment?",
+		Options:  []string{"dev", "staging", "prod"},
+		Input:    strings.NewReader("2\n"),
+		Output:   &output,
+	})
+	s.Nil(err)
+	s.Equal("staging", selection)
+	s.Contains(output.String(), "environment?")
+	s.Contains(output.String(), "  3) prod")
+}
+
+func (s SelectTests) TestSelect_value() {
+	selection, err := Select(SelectOpts{
+		Options: []string{"dev", "staging", "prod"},
+		Input:   strings.NewReader("prod\n"),
+		Output:  &bytes.Buffer{},
+	})
+	s.Nil(err)
+	s.Equal("prod", selection)
+}
+
+func (s SelectTests) TestSelect_default() {
+	var output bytes.Buffer
+	selection, err := Select(SelectOpts{
+		Options: []string{"dev", "staging", "prod"},
+		Default: "dev",
+		Input:   strings.NewReader("\n"),
+		Output:  &output,
+	})
+	s.Nil(err)
+	s.Equal("dev", selection)
+	s.Contains(output.String(), "* 1) dev")
+}
+
+func (s SelectTests) TestSelect_invalid() {
+	_, err := Select(SelectOpts{
+		Options: []string{"dev", "staging", "prod"},
+		Input:   strings.NewReader("4\n"),
+		Output:  &bytes.Buffer{},
+	})
+	s.True(errors.Is(err, ErrInvalidSelection))
+
+	_, err = Select(SelectOpts{
+		Options: []string{"dev", "staging", "prod"},
+		Input:   strings.NewReader("\n"),
+		Output:  &bytes.Buffer{},
+	})
+	s.True(errors.Is(err, ErrInvalidSelection), "should fail when there is no default")
+}
+
+func (s SelectTests) TestSelect_Validation() {
+	_, err := Select(SelectOpts{})
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "missing options")
+
+	_, err = Select(SelectOpts{Options: []string{"a"}, Default: "b"})
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "default 'b'")
+}
+
+func (s SelectTests) TestMultiSelect() {
+	input := strings.NewReader("3, 1\n")
+	selections, err := MultiSelect(MultiSelectOpts{
+		Options: []string{"api", "web", "worker"},
+		Input:   input,
+		Output:  &bytes.Buffer{},
+	})
+	s.Nil(err)
+	s.Equal([]string{"api", "worker"}, selections)
+}
+
+func (s SelectTests) TestMultiSelect_defaults() {
+	var output bytes.Buffer
+	selections, err := MultiSelect(MultiSelectOpts{
+		Options:  []string{"api", "web", "worker"},
+		Defaults: []string{"web", "worker"},
+		Input:    strings.NewReader("\n"),
+		Output:   &output,
+	})
+	s.Nil(err)
+	s.Equal([]string{"web", "worker"}, selections)
+	s.Contains(output.String(), "* 2) web")
+	s.Contains(output.String(), "* 3) worker")
+}
+
+func (s SelectTests) TestMultiSelect_invalid() {
+	_, err := MultiSelect(MultiSelectOpts{
+		Options: []string{"api", "web", "worker"},
+		Input:   strings.NewReader("1,nope\n"),
+		Output:  &bytes.Buffer{},
+	})
+	s.True(errors.Is(err, ErrInvalidSelection))
+}
+
+func (s SelectTests) Test_selectMenu_arrowKeys() {
+	menu := newSelectMenu("", []string{"dev", "staging", "prod"}, nil, false)
+	selections, err := menu.Run(strings.NewReader("\x1b[B\x1b[B\x1b[B\x1b[A\r"), &bytes.Buffer{})
+	s.Nil(err)
+	s.Equal([]string{"prod"}, selections)
+}
+
+func (s SelectTests) Test_selectMenu_filter() {
+	var output bytes.Buffer
+	menu := newSelectMenu("", []string{"dev", "staging", "prod"}, nil, false)
+	selections, err := menu.Run(strings.NewReader("px\x7f\r"), &output)
+	s.Nil(err)
+	s.Equal([]string{"prod"}, selections)
+	s.Contains(output.String(), "filter: px")
+}
+
+func (s SelectTests) Test_selectMenu_multi() {
+	menu := newSelectMenu("", []string{"api", "web", "worker"}, []string{"web"}, true)
+	selections, err := menu.Run(strings.NewReader(" \x1b[B \x1b[B\r"), &bytes.Buffer{})
+	s.Nil(err)
+	s.Equal([]string{"worker"}, selections, "web should be toggled off, worker toggled on")
+}
+
+func (s SelectTests) Test_selectMenu_interrupt() {
+	menu := newSelectMenu("", []string{"dev"}, nil, false)
+	_, err := menu.Run(strings.NewReader("\x03"), &bytes.Buffer{})
+	s.True(errors.Is(err, ErrInterrupted))
+}
@@ -2,29 +2,70 @@ package devops
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
+	"time"
 )
 
 // SendHTTPRequestOpts presents options for the SendHTTPRequest
 // method
 type SendHTTPRequestOpts struct {
+	// Auth can optionally be specified to add credentials to the
+	// request, see BasicAuth, BearerToken, APIKey and
+	// NewOAuth2ClientCredentials. If left nil, no credentials will
+	// be sent
+	Auth Authenticator
+
 	// BasicAuth defines user credentials for use with the
 	// request similar to curl's --basic flag. If left nil,
-	// basic auth will not be used
+	// basic auth will not be used. Cannot be used with .Auth
 	BasicAuth *BasicAuth
 
 	// Body defines the body data to be sent with the request.
 	// If left nil, a request without a body will be sent
 	Body []byte
 
+	// BodyFile defines the path to a file which is streamed as the
+	// body of the request. Cannot be used with other bodies
+	BodyFile string
+
+	// BodyLength defines the number of bytes in .BodyReader so that
+	// the Content-Length of the request can be set. If left as 0, the
+	// request is sent using chunked transfer encoding
+	BodyLength int64
+
+	// BodyReader defines a reader which is streamed as the body of
+	// the request. Requests with a .BodyReader are only retried if it
+	// implements io.Seeker. Cannot be used with other bodies
+	BodyReader io.Reader
+
 	// Client defines the HTTP client to use. If left nil,
 	// defaults to http.DefaultClient
 	Client *http.Client
 
+	// Context can optionally be specified to set an overall deadline
+	// for the request including all retries, the response body can
+	// only be read until the context is done
+	//
+	// Defaults to context.Background() if not specified
+	Context context.Context
+
+	// DisableRedirects when set to true returns redirect responses
+	// instead of following them similar to curl without its
+	// --location flag
+	DisableRedirects bool
+
+	// Form defines fields which are sent as an
+	// application/x-www-form-urlencoded body. Cannot be used with
+	// other bodies
+	Form url.Values
+
 	// Headers defines the headers to be sent along with this
 	// request. If left nil, no headers will be sent
 	Headers map[string][]string
@@ -32,16 +73,72 @@ type SendHTTPRequestOpts struct {
 	// Method defines the HTTP method to make the request with
 	Method string
 
+	// Multipart defines fields and files which are streamed as a
+	// multipart/form-data body. Cannot be used with other bodies
+	Multipart *MultipartForm
+
+	// OnAttempt can optionally be specified to receive a report of
+	// every attempt made to send the request
+	OnAttempt func(attempt HTTPAttempt)
+
+	// Progress can optionally be specified to receive progress
+	// updates for the upload of the body, use .NewProgressBar for a
+	// ready-made progress bar. If left nil, progress will not be
+	// reported
+	Progress ProgressReporter
+
+	// RateLimiter can optionally be specified to limit the rate and
+	// the number of requests in flight to the host of .URL, see
+	// NewRateLimiter. If left nil, requests are not limited
+	RateLimiter *RateLimiter
+
+	// Retry can optionally be specified to retry the request on
+	// connection errors, 429 Too Many Requests and 5xx responses. If
+	// left nil, the request is only attempted once
+	Retry *RetryPolicy
+
+	// Timeout defines the maximum duration of each attempt including
+	// reading the response body. If left as 0, attempts do not time
+	// out
+	Timeout time.Duration
+
 	// URL defines the endpoint to call
 	URL *url.URL
 }
 
+// HTTPAttempt describes an attempt made by SendHTTPRequest
+type HTTPAttempt struct {
+	// Attempt is the number of this attempt starting from 1
+	Attempt int
+
+	// Duration is the time taken to receive the response headers
+	Duration time.Duration
+
+	// Err is the error returned by the HTTP client if any
+	Err error
+
+	// Response is the response received if any, its body should not
+	// be read
+	Response *http.Response
+
+	// Backoff is the duration before the next attempt if
+	// .WillRetry is true
+	Backoff time.Duration
+
+	// WillRetry is true if the request will be attempted again
+	WillRetry bool
+}
+
 // SetDefaults sets defaults for the options object instance
 func (o *SendHTTPRequestOpts) SetDefaults() {
 	if o.Client == nil {
 		o.Client = http.DefaultClient
 	}
 
+	if o.Context == nil {
+		o.Context = context.Background()
+	}
+
 	if o.Method == "" {
 		o.Method = http.MethodGet
 	}
@@ -52,6 +149,24 @@ func (o *SendHTTPRequestOpts) SetDefaults() {
 func (o SendHTTPRequestOpts) Validate() error {
 	errors := []string{}
 
+	if o.Auth != nil && o.BasicAuth != nil {
+		errors = append(errors, "basic auth cannot be used with auth")
+	}
+
+	bodies := 0
+	for _, isSet := range []bool{o.Body != nil, o.BodyFile != "", o.BodyReader != nil, o.Form != nil, o.Multipart != nil} {
+		if isSet {
+			bodies++
+		}
+	}
+	if bodies > 1 {
+		errors = append(errors, "only one of body, body file, body reader, form and multipart can be specified")
+	}
+
+	if o.BodyLength < 0 {
+		errors = append(errors, "body length cannot be negative")
+	}
+
 	if o.Client == nil {
 		errors = append(errors, "missing client")
 	}
@@ -60,6 +175,10 @@ func (o SendHTTPRequestOpts) Validate() error {
 		errors = append(errors, "missing method")
 	}
 
+	if o.Timeout < 0 {
+		errors = append(errors, "timeout cannot be negative")
+	}
+
 	if o.URL == nil {
 		errors = append(errors, "missing url")
 	} else if o.URL.Host == "" {
@@ -67,7 +186,7 @@ func (o SendHTTPRequestOpts) Validate() error {
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to validate options: ['%s']", strings.Join(errors, "', '"))
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
 	}
 	return nil
 }
@@ -77,26 +196,271 @@ func (o SendHTTPRequestOpts) Validate() error {
 func SendHTTPRequest(opts SendHTTPRequestOpts) (*http.Response, error) {
 	opts.SetDefaults()
 	if err := opts.Validate(); err != nil {
-		return nil, fmt.Errorf("failed to send http request: %s", err)
+		return nil, fmt.Errorf("failed to send http request: %w", err)
+	}
+	retry := RetryPolicy{MaxAttempts: 1}
+	if opts.Retry != nil {
+		retry = *opts.Retry
+		retry.SetDefaults()
+		if err := retry.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to send http request: %w", err)
+		}
+	}
+	if opts.Multipart != nil {
+		if err := opts.Multipart.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to send http request: %w", err)
+		}
+		multipartForm := *opts.Multipart
+		multipartForm.Files = append([]MultipartFile{}, multipartForm.Files...)
+		for index, file := range multipartForm.Files {
+			reader, err := newRewindableReader(file.Reader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to send http request: %w", err)
+			}
+			multipartForm.Files[index].Reader = reader
+		}
+		opts.Multipart = &multipartForm
+	}
+	if opts.BodyReader != nil {
+		reader, err := newRewindableReader(opts.BodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send http request: %w", err)
+		}
+		opts.BodyReader = reader
 	}
 	if opts.BasicAuth != nil {
-		opts.URL.User = url.UserPassword(opts.BasicAuth.Username, opts.BasicAuth.Password)
+		opts.Auth = *opts.BasicAuth
+	}
+	if opts.DisableRedirects {
+		client := *opts.Client
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		opts.Client = &client
+	} else if apiKey, ok := getAPIKey(opts.Auth); ok {
+		client := *opts.Client
+		checkRedirect := client.CheckRedirect
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if req.URL.Host != via[0].URL.Host {
+				apiKey.removeFrom(req)
+			}
+			if checkRedirect != nil {
+				return checkRedirect(req, via)
+			}
+			// this is the default policy of http.Client
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+		opts.Client = &client
+	}
+	if opts.RateLimiter != nil {
+		client := *opts.Client
+		client.Transport = opts.RateLimiter.wrapTransport(client.Transport)
+		opts.Client = &client
+	}
+	canRetry := retry.CanRetry(opts.Method, opts.Headers) && opts.isBodyReplayable()
+
+	for attempt := 1; ; attempt++ {
+		startedAt := time.Now()
+		res, err := sendHTTPRequestAuthenticated(opts, attempt)
+		report := HTTPAttempt{
+			Attempt:  attempt,
+			Duration: time.Since(startedAt),
+			Err:      err,
+			Response: res,
+		}
+		if canRetry && attempt < retry.MaxAttempts && opts.Context.Err() == nil {
+			if err != nil || retry.IsRetryableStatusCode(res.StatusCode) {
+				report.Backoff = retry.GetBackoff(attempt, res)
+				report.WillRetry = true
+				if deadline, ok := opts.Context.Deadline(); ok && time.Until(deadline) < report.Backoff {
+					report.WillRetry = false
+				}
+			}
+		}
+		if opts.OnAttempt != nil {
+			opts.OnAttempt(report)
+		}
+		if !report.WillRetry {
+			if err != nil {
+				if attempt > 1 {
+					return nil, fmt.Errorf("failed to start download from '%s' after %v attempts: %w", opts.URL.String(), attempt, err)
+				}
+				return nil, fmt.Errorf("failed to start download from '%s': %w", opts.URL.String(), err)
+			}
+			return res, nil
+		}
+		if res != nil {
+			// the body is drained so that the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+			res.Body.Close()
+		}
+		timer := time.NewTimer(report.Backoff)
+		select {
+		case <-timer.C:
+		case <-opts.Context.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to start download from '%s' after %v attempts: %w", opts.URL.String(), attempt, opts.Context.Err())
+		}
+	}
+}
+
+// sendHTTPRequestAuthenticated sends a single attempt of the request
+// and sends it once more with fresh credentials if it is rejected with
+// 401 Unauthorized and .Auth caches its credentials
+func sendHTTPRequestAuthenticated(opts SendHTTPRequestOpts, attempt int) (*http.Response, error) {
+	res, err := sendHTTPRequestAttempt(opts, attempt)
+	invalidator, ok := opts.Auth.(AuthenticatorInvalidator)
+	if err != nil || !ok || res.StatusCode != http.StatusUnauthorized || !opts.isBodyReplayable() {
+		return res, err
 	}
-	var body io.Reader
-	var req *http.Request
-	if opts.Body != nil {
-		body = bytes.NewReader(opts.Body)
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+	res.Body.Close()
+	invalidator.Invalidate()
+	// the body is read again as if this is the next attempt
+	return sendHTTPRequestAttempt(opts, attempt+1)
+}
+
+// sendHTTPRequestAttempt sends a single attempt (starting from 1) of
+// the request with a new body and a context limited by .Timeout
+func sendHTTPRequestAttempt(opts SendHTTPRequestOpts, attempt int) (*http.Response, error) {
+	body, bodyLength, contentType, err := opts.getBody(attempt)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil && opts.Progress != nil {
+		body = readCloser{Reader: NewProgressReader(body, bodyLength, opts.Progress), Closer: body}
 	}
-	req, err := http.NewRequest(opts.Method, opts.URL.String(), body)
+	ctx, cancel := opts.Context, context.CancelFunc(func() {})
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(opts.Context, opts.Timeout)
+	}
+	// body is assigned to an interface only if it is set so that the
+	// request is not created with a non-nil interface holding nil
+	var requestBody io.Reader
+	if body != nil {
+		requestBody = body
+	}
+	req, err := http.NewRequestWithContext(ctx, opts.Method, opts.URL.String(), requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request object: %s", err)
+		cancel()
+		if body != nil {
+			body.Close()
+		}
+		return nil, fmt.Errorf("failed to create request object: %w", err)
+	}
+	if body != nil && bodyLength >= 0 {
+		req.ContentLength = bodyLength
+		if bodyLength == 0 {
+			req.Body = http.NoBody
+			body.Close()
+		}
+	}
+	if req.Body != nil && req.Body != http.NoBody && opts.isBodyReplayable() {
+		// this allows the body to be sent again on 307 and 308 redirects
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, _, _, err := opts.getBody(attempt + 1)
+			return body, err
+		}
 	}
 	if opts.Headers != nil {
-		req.Header = opts.Headers
+		req.Header = http.Header(opts.Headers).Clone()
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if opts.Auth != nil {
+		if err := opts.Auth.Authenticate(req); err != nil {
+			cancel()
+			if body != nil {
+				body.Close()
+			}
+			return nil, err
+		}
 	}
 	res, err := opts.Client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to start download from '%s': %s", opts.URL.String(), err)
+		cancel()
+		return nil, err
 	}
+	// the timeout should only be cancelled after the body has been read
+	res.Body = &cancelOnCloseReader{ReadCloser: res.Body, cancel: cancel}
 	return res, nil
 }
+
+// getBody returns the body, content length and content type of the
+// request for the provided attempt (starting from 1). The body is nil
+// if there is no body and the content length is -1 if it is not known
+func (o SendHTTPRequestOpts) getBody(attempt int) (io.ReadCloser, int64, string, error) {
+	switch {
+	case o.Body != nil:
+		return io.NopCloser(bytes.NewReader(o.Body)), int64(len(o.Body)), "", nil
+	case o.BodyFile != "":
+		/* #nosec - this is required to upload the file */
+		fileHandle, err := os.Open(o.BodyFile)
+		if err != nil {
+			return nil, 0, "", fmt.Errorf("failed to open file at '%s': %w", o.BodyFile, err)
+		}
+		fileInfo, err := fileHandle.Stat()
+		if err != nil {
+			fileHandle.Close()
+			return nil, 0, "", fmt.Errorf("failed to access '%s': %w", o.BodyFile, err)
+		}
+		return fileHandle, fileInfo.Size(), "", nil
+	case o.BodyReader != nil:
+		reader, err := rewindReader(o.BodyReader, attempt)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		bodyLength := o.BodyLength
+		if bodyLength == 0 {
+			bodyLength = -1
+		}
+		// the reader is not closed by the client as it belongs to the caller
+		return io.NopCloser(reader), bodyLength, "", nil
+	case o.Form != nil:
+		form := o.Form.Encode()
+		return io.NopCloser(strings.NewReader(form)), int64(len(form)), DefaultFormContentType, nil
+	case o.Multipart != nil:
+		return o.Multipart.getBody(attempt)
+	}
+	return nil, 0, "", nil
+}
+
+// isBodyReplayable returns true if the body can be sent more than once
+func (o SendHTTPRequestOpts) isBodyReplayable() bool {
+	if o.BodyReader != nil {
+		return isReaderReplayable(o.BodyReader)
+	}
+	if o.Multipart != nil {
+		return o.Multipart.isReplayable()
+	}
+	return true
+}
+
+// cancelOnCloseReader cancels a context when the reader is closed
+type cancelOnCloseReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (r *cancelOnCloseReader) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
+
+// getAPIKey returns the APIKey if `auth` is one
+func getAPIKey(auth Authenticator) (APIKey, bool) {
+	switch apiKey := auth.(type) {
+	case APIKey:
+		return apiKey, true
+	case *APIKey:
+		if apiKey != nil {
+			return *apiKey, true
+		}
+	}
+	return APIKey{}, false
+}
@@ -1,12 +1,19 @@
 package devops
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -124,3 +131,251 @@ func (s SendHTTPRequestTest) TestSendHTTPRequest_noURL() {
 	s.NotNil(err)
 	s.Contains(err.Error(), "failed to send http request")
 }
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_Progress() {
+	server := httptest.NewServer(s.getHandler(s))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	reports := []Progress{}
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{
+		Body:   []byte("hello world"),
+		Method: http.MethodPost,
+		Progress: ProgressReporterFunc(func(progress Progress) {
+			reports = append(reports, progress)
+		}),
+		URL: serverURL,
+	})
+	s.Nil(err)
+	s.Equal("hello world", s.observed["body"])
+	s.NotEmpty(reports)
+	last := reports[len(reports)-1]
+	s.True(last.Done)
+	s.Equal(int64(11), last.BytesTransferred)
+	s.Equal(int64(11), last.TotalBytes)
+}
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_Retry() {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		if requests < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	attempts := []HTTPAttempt{}
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		Body:   []byte("hello"),
+		Method: http.MethodPut,
+		OnAttempt: func(attempt HTTPAttempt) {
+			attempts = append(attempts, attempt)
+		},
+		Retry: &RetryPolicy{MaxAttempts: 5},
+		URL:   serverURL,
+	})
+	s.Nil(err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	s.Nil(err)
+	s.Equal("hello", string(body), "the body should be sent again on every attempt")
+	s.Equal(3, requests)
+	s.Len(attempts, 3)
+	s.True(attempts[0].WillRetry)
+	s.Equal(http.StatusServiceUnavailable, attempts[0].Response.StatusCode)
+	s.False(attempts[2].WillRetry)
+}
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_Retry_nonIdempotent() {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		Method: http.MethodPost,
+		Retry:  &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		URL:    serverURL,
+	})
+	s.Nil(err)
+	res.Body.Close()
+	s.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	s.Equal(1, requests, "POST requests should not be retried by default")
+}
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_Timeout() {
+	blocker := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-blocker:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(blocker)
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	attempts := 0
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{
+		OnAttempt: func(HTTPAttempt) { attempts++ },
+		Retry:     &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		Timeout:   50 * time.Millisecond,
+		URL:       serverURL,
+	})
+	s.True(errors.Is(err, context.DeadlineExceeded))
+	s.Contains(err.Error(), "after 2 attempts")
+	s.Equal(2, attempts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{
+		Context: ctx,
+		Retry:   &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second},
+		URL:     serverURL,
+	})
+	s.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_Form() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		s.Nil(r.ParseForm())
+		s.Equal("value with spaces", r.PostForm.Get("field"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		Form:   url.Values{"field": {"value with spaces"}},
+		Method: http.MethodPost,
+		URL:    serverURL,
+	})
+	s.Nil(err)
+	res.Body.Close()
+
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{
+		Body: []byte("body"),
+		Form: url.Values{"field": {"value"}},
+		URL:  serverURL,
+	})
+	s.True(errors.Is(err, ErrInvalidOptions))
+}
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_BodyReader() {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		s.Equal("streamed", string(body))
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	retry := &RetryPolicy{InitialBackoff: time.Millisecond}
+
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		BodyReader: strings.NewReader("streamed"),
+		Method:     http.MethodPut,
+		Retry:      retry,
+		URL:        serverURL,
+	})
+	s.Nil(err)
+	res.Body.Close()
+	s.Equal(http.StatusOK, res.StatusCode, "seekable readers should be retried")
+	s.Equal(2, requests)
+
+	requests = 0
+	res, err = SendHTTPRequest(SendHTTPRequestOpts{
+		BodyReader: bytes.NewBufferString("streamed"),
+		Method:     http.MethodPut,
+		Retry:      retry,
+		URL:        serverURL,
+	})
+	s.Nil(err)
+	res.Body.Close()
+	s.Equal(http.StatusServiceUnavailable, res.StatusCode, "other readers cannot be retried")
+	s.Equal(1, requests)
+}
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_BodyReader_offset() {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.EqualValues(len(body), r.ContentLength)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	reader := bytes.NewReader([]byte("skipped|streamed"))
+	_, err = reader.Seek(int64(len("skipped|")), io.SeekStart)
+	s.Nil(err)
+
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		BodyLength: int64(reader.Len()),
+		BodyReader: reader,
+		Method:     http.MethodPut,
+		Retry:      &RetryPolicy{InitialBackoff: time.Millisecond},
+		URL:        serverURL,
+	})
+	s.Nil(err)
+	res.Body.Close()
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal([]string{"streamed", "streamed"}, bodies, "retries should send the body from the offset the reader started at")
+}
+
+func (s SendHTTPRequestTest) TestSendHTTPRequest_Multipart() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Nil(r.ParseMultipartForm(1 << 20))
+		s.Equal([]string{"1", "2"}, r.MultipartForm.Value["numbers"])
+		for fieldName, expected := range map[string]string{"first": "first file", "second": "second file"} {
+			file, _, err := r.FormFile(fieldName)
+			s.Nil(err)
+			content, err := ioutil.ReadAll(file)
+			s.Nil(err)
+			s.Equal(expected, string(content))
+		}
+		w.Write([]byte(strconv.FormatInt(r.ContentLength, 10)))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	s.Nil(err)
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		Method: http.MethodPost,
+		Multipart: &MultipartForm{
+			Fields: map[string][]string{"numbers": {"1", "2"}},
+			Files: []MultipartFile{
+				{FieldName: "first", FileName: "first.txt", Reader: strings.NewReader("first file"), Size: 10},
+				{FieldName: "second", FileName: "second.txt", Reader: strings.NewReader("second file"), Size: 11},
+			},
+		},
+		URL: serverURL,
+	})
+	s.Nil(err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	s.Nil(err)
+	s.NotEqual("-1", string(body), "the content length should be known")
+
+	_, err = SendHTTPRequest(SendHTTPRequestOpts{
+		Multipart: &MultipartForm{Files: []MultipartFile{{FieldName: "file", Path: "file.txt", Reader: strings.NewReader("")}}},
+		URL:       serverURL,
+	})
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "file 0 must have either a path or a reader")
+}
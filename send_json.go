@@ -0,0 +1,198 @@
+package devops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultHTTPErrorBodySize = 4096
+	DefaultJSONContentType   = "application/json"
+)
+
+// HTTPError is returned when a response has an unexpected status code
+// and matches ErrUnexpectedStatusCode
+type HTTPError struct {
+	// Method is the method of the request
+	Method string
+
+	// URL is the URL of the request with any password redacted
+	URL string
+
+	// StatusCode is the status code of the response
+	StatusCode int
+
+	// Status is the status line of the response (eg. "404 Not Found")
+	Status string
+
+	// Headers are the headers of the response
+	Headers http.Header
+
+	// Body is the body of the response truncated to at most
+	// .MaxErrorBodySize bytes of the options used
+	Body []byte
+
+	// IsBodyTruncated is true if .Body was truncated
+	IsBodyTruncated bool
+}
+
+// Error implements the error interface
+func (e HTTPError) Error() string {
+	message := fmt.Sprintf("%s (%s) from %s '%s'", ErrUnexpectedStatusCode, e.Status, e.Method, e.URL)
+	if body := strings.TrimSpace(string(e.Body)); body != "" {
+		if e.IsBodyTruncated {
+			body += "..."
+		}
+		message += fmt.Sprintf(": %s", body)
+	}
+	return message
+}
+
+// Unwrap allows the error to be matched against ErrUnexpectedStatusCode
+func (e HTTPError) Unwrap() error {
+	return ErrUnexpectedStatusCode
+}
+
+// newHTTPError returns a HTTPError for the response `res` with at
+// most `maxBodySize` bytes of its body
+func newHTTPError(res *http.Response, maxBodySize int) HTTPError {
+	httpError := HTTPError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Headers:    res.Header,
+	}
+	if res.Request != nil {
+		httpError.Method = res.Request.Method
+		httpError.URL = res.Request.URL.Redacted()
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, int64(maxBodySize)+1))
+	if len(body) > maxBodySize {
+		body = body[:maxBodySize]
+		httpError.IsBodyTruncated = true
+	}
+	httpError.Body = body
+	return httpError
+}
+
+// SendJSONOpts presents options for the SendJSON method
+type SendJSONOpts[Req any] struct {
+	// Body defines the value to be encoded as the JSON body of the
+	// request. If left nil, a request without a body will be sent
+	Body *Req
+
+	// ExpectedStatusCodes defines the status codes which are
+	// considered successful, a HTTPError is returned for any other
+	// status code. If left empty, all 2xx status codes are accepted
+	ExpectedStatusCodes []int
+
+	// HTTP defines the options used to send the request, its .Body
+	// is replaced by the encoded .Body of this object
+	HTTP SendHTTPRequestOpts
+
+	// MaxErrorBodySize defines the maximum number of bytes of the
+	// response body to include in a HTTPError
+	//
+	// Defaults to DefaultHTTPErrorBodySize if not specified
+	MaxErrorBodySize int
+}
+
+// SetDefaults sets defaults for the options object instance
+func (o *SendJSONOpts[Req]) SetDefaults() {
+	if o.MaxErrorBodySize == 0 {
+		o.MaxErrorBodySize = DefaultHTTPErrorBodySize
+	}
+	o.HTTP.SetDefaults()
+}
+
+// Validate validates the options to check if this object
+// instance is usable by SendJSON
+func (o SendJSONOpts[Req]) Validate() error {
+	errors := []string{}
+
+	for _, statusCode := range o.ExpectedStatusCodes {
+		if statusCode < 100 || statusCode > 599 {
+			errors = append(errors, fmt.Sprintf("invalid expected status code %v", statusCode))
+		}
+	}
+
+	if o.MaxErrorBodySize < 0 {
+		errors = append(errors, "max error body size cannot be negative")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// isExpectedStatusCode returns true if the status code is one of
+// .ExpectedStatusCodes or is a 2xx status code if none are specified
+func (o SendJSONOpts[Req]) isExpectedStatusCode(statusCode int) bool {
+	if len(o.ExpectedStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode <= 299
+	}
+	for _, expectedStatusCode := range o.ExpectedStatusCodes {
+		if statusCode == expectedStatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// SendJSON sends .Body encoded as JSON using SendHTTPRequest as
+// configured by the provided options object instance `opts` and
+// decodes the JSON response into a value of type Resp. The zero value
+// of Resp is returned if the response has no body. A HTTPError is
+// returned if the response status code is not expected
+func SendJSON[Req any, Resp any](opts SendJSONOpts[Req]) (Resp, error) {
+	var response Resp
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return response, fmt.Errorf("failed to send json request: %w", err)
+	}
+
+	headers := http.Header(opts.HTTP.Headers).Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if headers.Get("Accept") == "" {
+		headers.Set("Accept", DefaultJSONContentType)
+	}
+	opts.HTTP.Body = nil
+	if opts.Body != nil {
+		body, err := json.Marshal(opts.Body)
+		if err != nil {
+			return response, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		opts.HTTP.Body = body
+		if headers.Get("Content-Type") == "" {
+			headers.Set("Content-Type", DefaultJSONContentType)
+		}
+	}
+	opts.HTTP.Headers = headers
+
+	res, err := SendHTTPRequest(opts.HTTP)
+	if err != nil {
+		return response, err
+	}
+	defer res.Body.Close()
+	if !opts.isExpectedStatusCode(res.StatusCode) {
+		return response, newHTTPError(res, opts.MaxErrorBodySize)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return response, fmt.Errorf("failed to read response body from '%s': %w", opts.HTTP.URL.Redacted(), err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return response, nil
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return response, fmt.Errorf("failed to decode response from '%s' (content type '%s'): %w", opts.HTTP.URL.Redacted(), res.Header.Get("Content-Type"), err)
+	}
+	return response, nil
+}
@@ -0,0 +1,113 @@
+package devops
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SendJSONTests struct {
+	suite.Suite
+}
+
+func TestSendJSON(t *testing.T) {
+	suite.Run(t, &SendJSONTests{})
+}
+
+type sendJSONTestRequest struct {
+	Name string `json:"name"`
+}
+
+type sendJSONTestResponse struct {
+	Greeting string `json:"greeting"`
+}
+
+func (s SendJSONTests) TestSendJSON() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		s.Equal(http.MethodPost, r.Method)
+		s.Equal("application/json", r.Header.Get("Content-Type"))
+		s.Equal("application/json", r.Header.Get("Accept"))
+		s.Equal("value", r.Header.Get("X-Custom"))
+		var request sendJSONTestRequest
+		s.Nil(json.NewDecoder(r.Body).Decode(&request))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sendJSONTestResponse{Greeting: "hello " + request.Name})
+	})
+	headers := map[string][]string{"X-Custom": {"value"}}
+	response, err := SendJSON[sendJSONTestRequest, sendJSONTestResponse](SendJSONOpts[sendJSONTestRequest]{
+		Body: &sendJSONTestRequest{Name: "world"},
+		HTTP: SendHTTPRequestOpts{
+			Headers: headers,
+			Method:  http.MethodPost,
+			URL:     serverURL,
+		},
+	})
+	s.Nil(err)
+	s.Equal("hello world", response.Greeting)
+	s.Len(headers, 1, "the provided headers should not be modified")
+}
+
+func (s SendJSONTests) TestSendJSON_noContent() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.Empty(body)
+		s.Empty(r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	response, err := SendJSON[any, *sendJSONTestResponse](SendJSONOpts[any]{
+		HTTP: SendHTTPRequestOpts{Method: http.MethodDelete, URL: serverURL},
+	})
+	s.Nil(err)
+	s.Nil(response)
+}
+
+func (s SendJSONTests) TestSendJSON_unexpectedStatusCode() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(strings.Repeat("x", 100)))
+	})
+	_, err := SendJSON[any, sendJSONTestResponse](SendJSONOpts[any]{
+		ExpectedStatusCodes: []int{http.StatusCreated},
+		HTTP:                SendHTTPRequestOpts{URL: serverURL},
+		MaxErrorBodySize:    10,
+	})
+	s.True(errors.Is(err, ErrUnexpectedStatusCode))
+	var httpError HTTPError
+	s.True(errors.As(err, &httpError))
+	s.Equal(http.StatusOK, httpError.StatusCode)
+	s.Equal(http.MethodGet, httpError.Method)
+	s.Equal("abc", httpError.Headers.Get("X-Request-Id"))
+	s.Equal(strings.Repeat("x", 10), string(httpError.Body))
+	s.True(httpError.IsBodyTruncated)
+	s.Contains(err.Error(), "200 OK")
+}
+
+func (s SendJSONTests) TestSendJSON_invalidResponse() {
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	_, err := SendJSON[any, sendJSONTestResponse](SendJSONOpts[any]{
+		HTTP: SendHTTPRequestOpts{URL: serverURL},
+	})
+	s.NotNil(err)
+	s.Contains(err.Error(), "text/html")
+	var syntaxError *json.SyntaxError
+	s.True(errors.As(err, &syntaxError))
+}
+
+func (s SendJSONTests) TestSendJSONOpts_Validate() {
+	err := SendJSONOpts[any]{
+		ExpectedStatusCodes: []int{42},
+		MaxErrorBodySize:    -1,
+	}.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "invalid expected status code 42")
+	s.Contains(err.Error(), "max error body size cannot be negative")
+}
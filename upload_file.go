@@ -0,0 +1,189 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UploadFileOpts presents configuration for the
+// UploadFile method
+type UploadFileOpts struct {
+	// Auth can optionally be specified to add credentials to the
+	// request, see BasicAuth, BearerToken, APIKey and
+	// NewOAuth2ClientCredentials. If left nil, no credentials will
+	// be sent
+	Auth Authenticator
+
+	// Client defines the HTTP client to use. If left nil,
+	// defaults to http.DefaultClient
+	Client *http.Client
+
+	// ContentType defines the content type of the file
+	//
+	// Defaults to the content type of the extension of .SourcePath
+	// or DefaultMultipartContentType if not specified
+	ContentType string
+
+	// Context can optionally be specified to cancel the upload
+	Context context.Context
+
+	// FieldName when specified uploads the file as a
+	// multipart/form-data body with the file in this field, otherwise
+	// the file is sent as the body of the request
+	FieldName string
+
+	// Fields defines additional form fields to send when .FieldName
+	// is specified
+	Fields map[string][]string
+
+	// FileName defines the file name sent to the server when
+	// .FieldName is specified
+	//
+	// Defaults to the base name of .SourcePath if not specified
+	FileName string
+
+	// Headers defines the headers to be sent along with the request
+	Headers map[string][]string
+
+	// Method defines the HTTP method to use, one of http.MethodPut
+	// or http.MethodPost
+	//
+	// Defaults to http.MethodPut if not specified
+	Method string
+
+	// Progress can optionally be specified to receive progress
+	// updates, use .NewProgressBar for a ready-made progress bar
+	Progress ProgressReporter
+
+	// RateLimiter can optionally be specified to limit the rate and
+	// the number of requests in flight to the host of .URL, see
+	// NewRateLimiter. If left nil, requests are not limited
+	RateLimiter *RateLimiter
+
+	// Retry can optionally be specified to retry the upload on
+	// connection errors, 429 Too Many Requests and 5xx responses.
+	// Note that POST uploads are only retried if .RetryNonIdempotent
+	// is set or an Idempotency-Key header is specified
+	Retry *RetryPolicy
+
+	// SourcePath defines the path of the file to upload
+	SourcePath string
+
+	// URL defines the endpoint to upload the file to
+	URL *url.URL
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *UploadFileOpts) SetDefaults() {
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.ContentType == "" {
+		o.ContentType = mime.TypeByExtension(filepath.Ext(o.SourcePath))
+		if o.ContentType == "" {
+			o.ContentType = DefaultMultipartContentType
+		}
+	}
+	if o.Method == "" {
+		o.Method = http.MethodPut
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the UploadFile method
+func (o UploadFileOpts) Validate() error {
+	errors := []string{}
+
+	if o.Method != http.MethodPut && o.Method != http.MethodPost {
+		errors = append(errors, fmt.Sprintf("unsupported method '%s'", o.Method))
+	}
+
+	if o.FieldName == "" && (o.Fields != nil || o.FileName != "") {
+		errors = append(errors, "fields and file name can only be used with field name")
+	}
+
+	if o.SourcePath == "" {
+		errors = append(errors, "missing source file path")
+	}
+
+	if o.URL == nil {
+		errors = append(errors, "missing url")
+	} else if o.URL.Host == "" {
+		errors = append(errors, "missing host in url")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// UploadFile streams the file at .SourcePath to .URL as configured by
+// the options object instance `opts`. A HTTPError is returned if the
+// server does not respond with a 2xx status code
+func UploadFile(opts UploadFileOpts) error {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	sourcePath, err := NormalizeLocalPath(opts.SourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to normalize path '%s': %w", opts.SourcePath, err)
+	}
+	fileInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to access '%s': %w", sourcePath, err)
+	}
+	if fileInfo.IsDir() {
+		return fmt.Errorf("failed to upload '%s': %w", sourcePath, ErrIsDirectory)
+	}
+
+	headers := http.Header(opts.Headers).Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	requestURL := *opts.URL
+	requestOpts := SendHTTPRequestOpts{
+		Auth:        opts.Auth,
+		Client:      opts.Client,
+		Context:     opts.Context,
+		Headers:     headers,
+		Method:      opts.Method,
+		Progress:    opts.Progress,
+		RateLimiter: opts.RateLimiter,
+		Retry:       opts.Retry,
+		URL:         &requestURL,
+	}
+	if opts.FieldName != "" {
+		requestOpts.Multipart = &MultipartForm{
+			Fields: opts.Fields,
+			Files: []MultipartFile{{
+				ContentType: opts.ContentType,
+				FieldName:   opts.FieldName,
+				FileName:    opts.FileName,
+				Path:        sourcePath,
+			}},
+		}
+	} else {
+		requestOpts.BodyFile = sourcePath
+		if headers.Get("Content-Type") == "" {
+			headers.Set("Content-Type", opts.ContentType)
+		}
+	}
+
+	res, err := SendHTTPRequest(requestOpts)
+	if err != nil {
+		return fmt.Errorf("failed to upload '%s': %w", sourcePath, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to upload '%s': %w", sourcePath, newHTTPError(res, DefaultHTTPErrorBodySize))
+	}
+	return nil
+}
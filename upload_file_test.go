@@ -0,0 +1,105 @@
+package devops
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UploadFileTests struct {
+	suite.Suite
+}
+
+func TestUploadFile(t *testing.T) {
+	suite.Run(t, &UploadFileTests{})
+}
+
+func (s UploadFileTests) writeSourceFile(name, content string) string {
+	sourcePath := filepath.Join(s.T().TempDir(), name)
+	s.Nil(ioutil.WriteFile(sourcePath, []byte(content), 0644))
+	return sourcePath
+}
+
+func (s UploadFileTests) TestUploadFile() {
+	sourcePath := s.writeSourceFile("artefact.json", `{"hello":"world"}`)
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		s.Equal(http.MethodPut, r.Method)
+		s.EqualValues(17, r.ContentLength)
+		s.Equal("application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		s.Nil(err)
+		s.Equal(`{"hello":"world"}`, string(body))
+		w.WriteHeader(http.StatusCreated)
+	})
+	reports := []Progress{}
+	err := UploadFile(UploadFileOpts{
+		Progress: ProgressReporterFunc(func(progress Progress) {
+			reports = append(reports, progress)
+		}),
+		SourcePath: sourcePath,
+		URL:        serverURL,
+	})
+	s.Nil(err)
+	s.NotEmpty(reports)
+	s.True(reports[len(reports)-1].Done)
+	s.EqualValues(17, reports[len(reports)-1].BytesTransferred)
+}
+
+func (s UploadFileTests) TestUploadFile_multipart() {
+	sourcePath := s.writeSourceFile("artefact.txt", "hello world")
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		s.Equal(http.MethodPost, r.Method)
+		s.Greater(r.ContentLength, int64(0), "the content length should be known")
+		s.Nil(r.ParseMultipartForm(1 << 20))
+		s.Equal("1.0.0", r.FormValue("version"))
+		file, header, err := r.FormFile("artefact")
+		s.Nil(err)
+		defer file.Close()
+		s.Equal("renamed.txt", header.Filename)
+		s.Contains(header.Header.Get("Content-Type"), "text/plain")
+		content, err := ioutil.ReadAll(file)
+		s.Nil(err)
+		s.Equal("hello world", string(content))
+	})
+	err := UploadFile(UploadFileOpts{
+		FieldName:  "artefact",
+		Fields:     map[string][]string{"version": {"1.0.0"}},
+		FileName:   "renamed.txt",
+		Method:     http.MethodPost,
+		SourcePath: sourcePath,
+		URL:        serverURL,
+	})
+	s.Nil(err)
+}
+
+func (s UploadFileTests) TestUploadFile_unexpectedStatusCode() {
+	sourcePath := s.writeSourceFile("artefact.txt", "hello world")
+	serverURL := newTestServerURL(s.T(), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		w.Write([]byte("too large"))
+	})
+	err := UploadFile(UploadFileOpts{SourcePath: sourcePath, URL: serverURL})
+	s.True(errors.Is(err, ErrUnexpectedStatusCode))
+	var httpError HTTPError
+	s.True(errors.As(err, &httpError))
+	s.Equal("too large", string(httpError.Body))
+
+	err = UploadFile(UploadFileOpts{SourcePath: filepath.Dir(sourcePath), URL: serverURL})
+	s.True(errors.Is(err, ErrIsDirectory))
+	err = UploadFile(UploadFileOpts{SourcePath: sourcePath + ".missing", URL: serverURL})
+	s.True(errors.Is(err, os.ErrNotExist))
+}
+
+func (s UploadFileTests) TestUploadFileOpts_Validate() {
+	err := UploadFileOpts{Method: http.MethodGet, FileName: "file"}.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "unsupported method 'GET'")
+	s.Contains(err.Error(), "fields and file name can only be used with field name")
+	s.Contains(err.Error(), "missing source file path")
+	s.Contains(err.Error(), "missing url")
+}
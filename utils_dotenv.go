@@ -0,0 +1,105 @@
+package devops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var dotenvUnquotedValue = regexp.MustCompile(`^[A-Za-z0-9_./:,@+-]*$`)
+
+// dotenv holds the lines of a dotenv file so that it can be written
+// back with its comments and ordering preserved
+type dotenv struct {
+	lines  []string
+	keys   map[string]int
+	values map[string]string
+}
+
+// readDotenv reads the dotenv file at the provided path, a missing
+// file is treated as an empty one
+func readDotenv(filePath string) (*dotenv, error) {
+	env := &dotenv{keys: map[string]int{}, values: map[string]string{}}
+	/* #nosec - this is required to read the file */
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return env, nil
+		}
+		return nil, fmt.Errorf("failed to read dotenv file at '%s': %w", filePath, err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		env.lines = append(env.lines, line)
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+		trimmedLine = strings.TrimPrefix(trimmedLine, "export ")
+		keyValuePair := strings.SplitN(trimmedLine, "=", 2)
+		if len(keyValuePair) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(keyValuePair[0])
+		env.keys[key] = len(env.lines) - 1
+		env.values[key] = parseDotenvValue(strings.TrimSpace(keyValuePair[1]))
+	}
+	return env, nil
+}
+
+// Lookup implements the same signature as os.LookupEnv
+func (e *dotenv) Lookup(key string) (string, bool) {
+	value, ok := e.values[key]
+	return value, ok
+}
+
+// Set updates the line for an existing key or appends a new line
+func (e *dotenv) Set(key, value string) {
+	line := fmt.Sprintf("%s=%s", key, formatDotenvValue(value))
+	if index, ok := e.keys[key]; ok {
+		e.lines[index] = line
+	} else {
+		e.lines = append(e.lines, line)
+		e.keys[key] = len(e.lines) - 1
+	}
+	e.values[key] = value
+}
+
+// Write atomically writes the dotenv file to the provided path with
+// permissions that only allow the current user to read it since it may
+// contain secrets, the permissions of an existing file are replaced
+func (e *dotenv) Write(filePath string) error {
+	var content bytes.Buffer
+	for _, line := range e.lines {
+		content.WriteString(line + "\n")
+	}
+	if err := writeFileAtomically(filePath, content.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write dotenv file at '%s': %w", filePath, err)
+	}
+	return nil
+}
+
+func parseDotenvValue(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		replacer := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\$`, `$`)
+		return replacer.Replace(value[1 : len(value)-1])
+	}
+	if commentIndex := strings.Index(value, " #"); commentIndex >= 0 {
+		value = strings.TrimSpace(value[:commentIndex])
+	}
+	return value
+}
+
+func formatDotenvValue(value string) string {
+	if dotenvUnquotedValue.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, `$`, `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
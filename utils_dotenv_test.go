@@ -0,0 +1,51 @@
+package devops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UtilsDotenvTest struct {
+	suite.Suite
+}
+
+func TestUtilsDotenv(t *testing.T) {
+	suite.Run(t, &UtilsDotenvTest{})
+}
+
+func (s UtilsDotenvTest) Test_parseDotenvValue() {
+	s.Equal("plain", parseDotenvValue("plain"))
+	s.Equal("plain", parseDotenvValue("plain # comment"))
+	s.Equal("single $quoted", parseDotenvValue("'single $quoted'"))
+	s.Equal("double \"quoted\"\n$", parseDotenvValue(`"double \"quoted\"\n\$"`))
+}
+
+func (s UtilsDotenvTest) Test_formatDotenvValue() {
+	s.Equal("https://example.com/path", formatDotenvValue("https://example.com/path"))
+	s.Equal(`"hello world"`, formatDotenvValue("hello world"))
+	for _, value := range []string{"a \"b\"", "a\nb", "$HOME", `back\slash`} {
+		s.Equal(value, parseDotenvValue(formatDotenvValue(value)))
+	}
+}
+
+func (s UtilsDotenvTest) Test_dotenv_Write() {
+	filePath := filepath.Join(s.T().TempDir(), ".env")
+	s.Nil(os.WriteFile(filePath, []byte("# comment\nEXISTING=value\n"), 0644))
+	env, err := readDotenv(filePath)
+	s.Nil(err)
+	env.Set("SECRET", "hello world")
+	s.Nil(env.Write(filePath))
+
+	content, err := os.ReadFile(filePath)
+	s.Nil(err)
+	s.Equal("# comment\nEXISTING=value\nSECRET=\"hello world\"\n", string(content))
+	fileInfo, err := os.Stat(filePath)
+	s.Nil(err)
+	s.Equal(os.FileMode(0600), fileInfo.Mode().Perm(), "permissions of existing files should be tightened")
+	entries, err := os.ReadDir(filepath.Dir(filePath))
+	s.Nil(err)
+	s.Len(entries, 1, "temporary files should not be left behind")
+}
@@ -1,20 +1,103 @@
 package devops
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
 
+// temporaryFilePattern is appended to the base name of a file to get
+// the pattern of its temporary files
+const temporaryFilePattern = ".*.tmp"
+
+// writeTemporaryFile writes `body` to a new temporary file with `mode`
+// alongside `filePath` and returns the path of the temporary file so
+// that it can be renamed to `filePath` once it is complete. The
+// temporary file is removed if it cannot be written
+func writeTemporaryFile(filePath string, body io.Reader, mode os.FileMode) (string, error) {
+	fileHandle, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+temporaryFilePattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a temporary file for '%s': %w", filePath, err)
+	}
+	temporaryPath := fileHandle.Name()
+	if err := fileHandle.Chmod(mode); err != nil {
+		fileHandle.Close()
+		os.Remove(temporaryPath)
+		return "", fmt.Errorf("failed to set permissions of '%s': %w", temporaryPath, err)
+	}
+	if _, err := io.Copy(fileHandle, body); err != nil {
+		fileHandle.Close()
+		os.Remove(temporaryPath)
+		return "", fmt.Errorf("failed to write to file at '%s': %w", temporaryPath, err)
+	}
+	if err := fileHandle.Close(); err != nil {
+		os.Remove(temporaryPath)
+		return "", fmt.Errorf("failed to close file at '%s': %w", temporaryPath, err)
+	}
+	return temporaryPath, nil
+}
+
+// moveToTemporaryFile renames the file at `filePath` to a new
+// temporary file alongside it and returns the path of the temporary
+// file
+func moveToTemporaryFile(filePath string) (string, error) {
+	fileHandle, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+temporaryFilePattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a temporary file for '%s': %w", filePath, err)
+	}
+	temporaryPath := fileHandle.Name()
+	fileHandle.Close()
+	if err := os.Rename(filePath, temporaryPath); err != nil {
+		os.Remove(temporaryPath)
+		return "", fmt.Errorf("failed to move '%s' to '%s': %w", filePath, temporaryPath, err)
+	}
+	return temporaryPath, nil
+}
+
+// restrictDirectoryMode removes permissions not in `mode` from the
+// existing directory at `directoryPath`
+func restrictDirectoryMode(directoryPath string, mode os.FileMode) error {
+	fileInfo, err := os.Stat(directoryPath)
+	if err != nil {
+		return fmt.Errorf("failed to access path '%s': %w", directoryPath, err)
+	}
+	if fileInfo.Mode().Perm()&^mode == 0 {
+		return nil
+	}
+	if err := os.Chmod(directoryPath, fileInfo.Mode().Perm()&mode); err != nil {
+		return fmt.Errorf("failed to set permissions of '%s': %w", directoryPath, err)
+	}
+	return nil
+}
+
+// writeFileAtomically writes `data` to a temporary file with `mode`
+// alongside `filePath` and renames it to `filePath` so that a partially
+// written file is never left at `filePath`
+func writeFileAtomically(filePath string, data []byte, mode os.FileMode) error {
+	temporaryPath, err := writeTemporaryFile(filePath, bytes.NewReader(data), mode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryPath, filePath); err != nil {
+		os.Remove(temporaryPath)
+		return fmt.Errorf("failed to move '%s' to '%s': %w", temporaryPath, filePath, err)
+	}
+	return nil
+}
+
 func recursivelyGetExtensionsCount(pathToDirectory string, ignoreList ...string) (map[string]int, error) {
 	errors := []string{}
 	results := map[string]int{}
 	ignoredList := []regexp.Regexp{}
 	fileEntries, err := ioutil.ReadDir(pathToDirectory)
 	if err != nil {
-		return results, fmt.Errorf("failed to list directory contents at '%s': %s", pathToDirectory, err)
+		return results, fmt.Errorf("failed to list directory contents at '%s': %w", pathToDirectory, err)
 	}
 	for _, ignorable := range ignoreList {
 		ignoredList = append(ignoredList, *regexp.MustCompile(ignorable))
@@ -0,0 +1,21 @@
+package devops
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newTestServerURL starts a test server which is closed when the test
+// completes and returns its URL
+func newTestServerURL(t *testing.T, handler http.HandlerFunc) *url.URL {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server url '%s': %s", server.URL, err)
+	}
+	return serverURL
+}
@@ -13,7 +13,7 @@ func NormalizeLocalPath(userInputPath string) (string, error) {
 	if strings.Contains(userInputPath, "~") && userInputPath[0] == '~' {
 		homeDirectoryPath, err := os.UserHomeDir()
 		if err != nil {
-			return "", fmt.Errorf("failed to resolve ~ to user home directory: %s", err)
+			return "", fmt.Errorf("failed to resolve ~ to user home directory: %w", err)
 		}
 		pathOfInterest = path.Join(homeDirectoryPath, strings.Replace(userInputPath, "~", "", 1))
 		if strings.Contains(pathOfInterest, "~") {
@@ -24,7 +24,7 @@ func NormalizeLocalPath(userInputPath string) (string, error) {
 	if !path.IsAbs(pathOfInterest) {
 		currentWorkingDirectory, err := os.Getwd()
 		if err != nil {
-			return "", fmt.Errorf("failed to resolve . to current directory: %s", err)
+			return "", fmt.Errorf("failed to resolve . to current directory: %w", err)
 		}
 		pathOfInterest = path.Join(currentWorkingDirectory, pathOfInterest)
 	}
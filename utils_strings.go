@@ -1,5 +1,7 @@
 package devops
 
+import "unicode"
+
 func containsAllStrings(haystack []string, needles []string) bool {
 	if haystack == nil || needles == nil || len(haystack) == 0 || len(needles) == 0 {
 		return false
@@ -35,3 +37,31 @@ func containsAnyString(haystack []string, needles []string) bool {
 	}
 	return false
 }
+
+func indexOfString(haystack []string, needle string) int {
+	for index, item := range haystack {
+		if item == needle {
+			return index
+		}
+	}
+	return -1
+}
+
+// countCharacterClasses returns the number of character classes
+// (lowercase, uppercase, digits, others) found in the input
+func countCharacterClasses(input string) int {
+	hasClass := map[string]bool{}
+	for _, character := range input {
+		switch {
+		case unicode.IsLower(character):
+			hasClass["lower"] = true
+		case unicode.IsUpper(character):
+			hasClass["upper"] = true
+		case unicode.IsDigit(character):
+			hasClass["digit"] = true
+		default:
+			hasClass["other"] = true
+		}
+	}
+	return len(hasClass)
+}
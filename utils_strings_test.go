@@ -28,3 +28,17 @@ func (s UtilsStringsTest) Test_containsAnyString() {
 	s.True(containsAnyString([]string{"hola", "mundo"}, []string{"hello", "mundo"}))
 	s.True(containsAnyString([]string{"hola", "mundo"}, []string{"hola", "mundo"}))
 }
+
+func (s UtilsStringsTest) Test_indexOfString() {
+	s.Equal(-1, indexOfString(nil, "hola"))
+	s.Equal(-1, indexOfString([]string{"hola", "mundo"}, "world"))
+	s.Equal(1, indexOfString([]string{"hola", "mundo"}, "mundo"))
+}
+
+func (s UtilsStringsTest) Test_countCharacterClasses() {
+	s.Equal(0, countCharacterClasses(""))
+	s.Equal(1, countCharacterClasses("hola"))
+	s.Equal(2, countCharacterClasses("Hola"))
+	s.Equal(3, countCharacterClasses("Hola1"))
+	s.Equal(4, countCharacterClasses("Hola1!"))
+}
@@ -0,0 +1,62 @@
+package devops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// getTerminalFd returns the file descriptor of the provided stream and
+// true if the stream is a terminal, returns false otherwise
+func getTerminalFd(stream interface{}) (int, bool) {
+	file, ok := stream.(*os.File)
+	if !ok || file == nil {
+		return 0, false
+	}
+	fd := int(file.Fd())
+	return fd, term.IsTerminal(fd)
+}
+
+// isNonInteractive returns true if the provided input stream is a file
+// that is not a terminal or if the $CI environment variable is true
+func isNonInteractive(input io.Reader) bool {
+	if isCI, err := strconv.ParseBool(os.Getenv("CI")); err == nil && isCI {
+		return true
+	}
+	if _, ok := input.(*os.File); ok {
+		_, isTerminal := getTerminalFd(input)
+		return !isTerminal
+	}
+	return false
+}
+
+// readLine reads a single line from the provided input without reading
+// past the line break so that the same input can be used for subsequent
+// reads. Returns ErrNoInput only if nothing was read before the end of
+// input
+func readLine(input io.Reader) (string, error) {
+	var line strings.Builder
+	character := make([]byte, 1)
+	for {
+		n, err := input.Read(character)
+		if n > 0 {
+			if character[0] == '\n' {
+				break
+			}
+			line.WriteByte(character[0])
+		}
+		if err == io.EOF {
+			if line.Len() == 0 {
+				return "", fmt.Errorf("%w: %w", ErrNoInput, err)
+			}
+			break
+		} else if err != nil {
+			return line.String(), err
+		}
+	}
+	return strings.TrimRight(line.String(), "\r"), nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package devops
+
+import (
+	"os"
+	"time"
+)
+
+// canWaitForInput is true if waitForInput is supported
+const canWaitForInput = false
+
+// waitForInput is not supported on this platform
+func waitForInput(file *os.File, timeout time.Duration) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package devops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// canWaitForInput is true if waitForInput is supported
+const canWaitForInput = true
+
+// waitForInput waits up to `timeout` for the file to have input to be
+// read without starting a read, returns false if the timeout is reached
+func waitForInput(file *os.File, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	fds := []unix.PollFd{{Fd: int32(file.Fd()), Events: unix.POLLIN}}
+	for {
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+		count, err := unix.Poll(fds, int((remaining+time.Millisecond-1)/time.Millisecond))
+		if errors.Is(err, unix.EINTR) {
+			continue
+		} else if err != nil {
+			return false, fmt.Errorf("failed to wait for input: %w", err)
+		}
+		return count > 0, nil
+	}
+}
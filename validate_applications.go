@@ -29,6 +29,16 @@ func (e ValidateApplicationsErrors) Error() string {
 	return ""
 }
 
+// Unwrap returns an error wrapping ErrApplicationNotFound for each
+// application that was not found
+func (e ValidateApplicationsErrors) Unwrap() []error {
+	errs := []error{}
+	for _, err := range e.Errors {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrApplicationNotFound, err))
+	}
+	return errs
+}
+
 type ValidateApplicationsOpts struct {
 	Paths []string
 }
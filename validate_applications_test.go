@@ -1,6 +1,7 @@
 package devops
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -29,6 +30,8 @@ func (s ValidateApplicationsTest) TestValidateApplications() {
 	s.NotNil(err)
 	errs := err.(ValidateApplicationsErrors)
 
+	s.True(errors.Is(err, ErrApplicationNotFound))
+
 	for _, expectedFail := range expectedFailure {
 		s.Contains(errs.Errors, expectedFail)
 	}
@@ -56,7 +56,7 @@ func (o ValidateConnectionOpts) Validate() error {
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("failed to validate options: ['%s']", strings.Join(errors, "', '"))
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
 	}
 
 	return nil
@@ -65,7 +65,7 @@ func (o ValidateConnectionOpts) Validate() error {
 func ValidateConnection(opts ValidateConnectionOpts) (bool, error) {
 	opts.SetDefaults()
 	if err := opts.Validate(); err != nil {
-		return false, fmt.Errorf("failed to validate connection: %s", err)
+		return false, fmt.Errorf("failed to validate connection: %w", err)
 	}
 
 	address := net.JoinHostPort(opts.Hostname, strconv.Itoa(int(opts.Port)))
@@ -81,7 +81,7 @@ func ValidateConnection(opts ValidateConnectionOpts) (bool, error) {
 				}
 			}
 		}
-		return false, fmt.Errorf("failed to connect to '%s': %s", address, err)
+		return false, fmt.Errorf("failed to connect to '%s': %w", address, err)
 	}
 	if connection != nil {
 		defer connection.Close()
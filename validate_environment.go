@@ -46,6 +46,24 @@ type ValidateEnvironmentError struct {
 	Value        string
 }
 
+func (e ValidateEnvironmentError) Error() string {
+	if e.ExpectedType == TypeErrorUnknown {
+		return fmt.Sprintf("key[%s] has unknown type '%s'", e.Key, e.Value)
+	} else if e.ExpectedType == TypeErrorMissing {
+		return fmt.Sprintf("key[%s] does not exist", e.Key)
+	}
+	return fmt.Sprintf("key[%s]:%s was '%s'", e.Key, e.ExpectedType, e.Value)
+}
+
+// Unwrap returns ErrEnvironmentKeyMissing if the key was not defined
+// and ErrEnvironmentKeyInvalid otherwise
+func (e ValidateEnvironmentError) Unwrap() error {
+	if e.ExpectedType == TypeErrorMissing {
+		return ErrEnvironmentKeyMissing
+	}
+	return ErrEnvironmentKeyInvalid
+}
+
 type ValidateEnvironmentErrors struct {
 	Errors []ValidateEnvironmentError
 }
@@ -61,17 +79,21 @@ func (e *ValidateEnvironmentErrors) Push(err ValidateEnvironmentError) {
 func (e ValidateEnvironmentErrors) Error() string {
 	errors := []string{}
 	for _, err := range e.Errors {
-		if err.ExpectedType == TypeErrorUnknown {
-			errors = append(errors, fmt.Sprintf("key[%s] has unknown type '%s'", err.Key, err.Value))
-		} else if err.ExpectedType == TypeErrorMissing {
-			errors = append(errors, fmt.Sprintf("key[%s] does not exist", err.Key))
-		} else {
-			errors = append(errors, fmt.Sprintf("key[%s]:%s was '%s'", err.Key, err.ExpectedType, err.Value))
-		}
+		errors = append(errors, err.Error())
 	}
 	return fmt.Sprintf("failed to validate environment: ['%s']", strings.Join(errors, "', '"))
 }
 
+// Unwrap returns the individual ValidateEnvironmentError instances so
+// that `errors.Is` and `errors.As` can be used on the collection
+func (e ValidateEnvironmentErrors) Unwrap() []error {
+	errs := []error{}
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
 func ValidateEnvironment(opts ValidateEnvironmentOpts) error {
 	errors := ValidateEnvironmentErrors{}
 
@@ -1,6 +1,7 @@
 package devops
 
 import (
+	"errors"
 	"os"
 	"testing"
 
@@ -138,4 +139,6 @@ func (s ValidateEnvironmentTests) TestValidateEnvironment_parseError() {
 	s.Contains(errorKeys, s.UintKey)
 	s.Contains(errorKeys, s.FloatKey)
 	s.Contains(errorKeys, s.BoolKey)
+	s.True(errors.Is(err, ErrEnvironmentKeyMissing))
+	s.True(errors.Is(err, ErrEnvironmentKeyInvalid))
 }
@@ -0,0 +1,368 @@
+package devops
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5" // #nosec - md5 is supported for verifying legacy checksums only
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// ChecksumAlgorithm defines a hashing algorithm that can be used
+// to verify a file
+type ChecksumAlgorithm string
+
+const (
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumSHA512 ChecksumAlgorithm = "sha512"
+)
+
+const (
+	VerificationMethodChecksum  = "checksum"
+	VerificationMethodSignature = "signature"
+)
+
+// checksumBSDLine matches lines in the format output by `sha256sum --tag`
+// and the BSD `sha256` tool, eg. 'SHA256 (file.tar.gz) = abc...'
+var checksumBSDLine = regexp.MustCompile(`^[A-Za-z0-9-]+ \((.+)\) = ([A-Fa-f0-9]+)$`)
+
+// VerifyFileOpts presents options for the VerifyFile method
+type VerifyFileOpts struct {
+	// Checksum defines the expected hex-encoded digest of the file
+	Checksum string
+
+	// ChecksumAlgorithm defines the algorithm used to compute the
+	// digest of the file
+	//
+	// Defaults to the algorithm matching the length of .Checksum (or
+	// the digest found at .ChecksumURL) if not specified
+	ChecksumAlgorithm ChecksumAlgorithm
+
+	// ChecksumFileName defines the file name to look for in the
+	// checksum file at .ChecksumURL
+	//
+	// Defaults to the base name of .Path if not specified
+	ChecksumFileName string
+
+	// ChecksumURL defines the URL of a checksum file in the format
+	// output by tools like `sha256sum` (eg. a SHA256SUMS file) which
+	// contains the expected digest of the file
+	ChecksumURL *url.URL
+
+	// Client defines the HTTP client used to retrieve .ChecksumURL
+	// and .SignatureURL. If left nil, a new http.Client is used
+	Client *http.Client
+
+	// Path defines the path to the file to verify
+	Path string
+
+	// PublicKey defines the OpenPGP public key (armored or binary)
+	// used to verify the signature, this is required if .Signature
+	// or .SignatureURL is specified
+	PublicKey []byte
+
+	// Signature defines the detached OpenPGP signature (armored or
+	// binary) of the file
+	Signature []byte
+
+	// SignatureURL defines the URL of the detached OpenPGP signature
+	// (armored or binary) of the file
+	SignatureURL *url.URL
+}
+
+// SetDefaults sets defaults for this object instance
+func (o *VerifyFileOpts) SetDefaults() {
+	if o.ChecksumFileName == "" && o.Path != "" {
+		o.ChecksumFileName = path.Base(o.Path)
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{}
+	}
+}
+
+// Validate verifies that this object instance is usable
+// by the VerifyFile method
+func (o VerifyFileOpts) Validate() error {
+	errors := []string{}
+
+	if o.Path == "" {
+		errors = append(errors, "missing file path")
+	}
+
+	if o.Checksum == "" && o.ChecksumURL == nil && o.Signature == nil && o.SignatureURL == nil {
+		errors = append(errors, "missing checksum or signature to verify against")
+	}
+	if o.Checksum != "" && o.ChecksumURL != nil {
+		errors = append(errors, "only one of checksum or checksum url can be specified")
+	}
+	if o.Checksum != "" {
+		if _, err := hex.DecodeString(o.Checksum); err != nil {
+			errors = append(errors, "checksum is not hex-encoded")
+		}
+	}
+	if o.ChecksumURL != nil && o.ChecksumURL.Host == "" {
+		errors = append(errors, "missing host in checksum url")
+	}
+	switch o.ChecksumAlgorithm {
+	case "", ChecksumMD5, ChecksumSHA256, ChecksumSHA512:
+	default:
+		errors = append(errors, fmt.Sprintf("unsupported checksum algorithm '%s'", o.ChecksumAlgorithm))
+	}
+
+	if o.Signature != nil && o.SignatureURL != nil {
+		errors = append(errors, "only one of signature or signature url can be specified")
+	}
+	if o.SignatureURL != nil && o.SignatureURL.Host == "" {
+		errors = append(errors, "missing host in signature url")
+	}
+	if (o.Signature != nil || o.SignatureURL != nil) && len(o.PublicKey) == 0 {
+		errors = append(errors, "missing public key to verify the signature with")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%w: ['%s']", ErrInvalidOptions, strings.Join(errors, "', '"))
+	}
+	return nil
+}
+
+// VerificationError is returned when a file does not match its
+// expected checksum or signature and matches ErrVerificationFailed
+type VerificationError struct {
+	// Path is the path to the file that failed verification
+	Path string
+
+	// Method is one of VerificationMethodChecksum or
+	// VerificationMethodSignature
+	Method string
+
+	// Expected is the expected digest for checksum verifications
+	Expected string
+
+	// Actual is the computed digest for checksum verifications
+	Actual string
+
+	// Err is the underlying cause of the failure if any
+	Err error
+}
+
+// Error implements the error interface
+func (e VerificationError) Error() string {
+	message := fmt.Sprintf("%s of '%s'", e.Method, e.Path)
+	if e.Expected != "" || e.Actual != "" {
+		message += fmt.Sprintf(" (expected '%s' but got '%s')", e.Expected, e.Actual)
+	}
+	if e.Err != nil {
+		message += fmt.Sprintf(": %s", e.Err)
+	}
+	return fmt.Sprintf("%s: %s", ErrVerificationFailed, message)
+}
+
+// Unwrap allows the error to be matched against ErrVerificationFailed
+// and the underlying cause using errors.Is and errors.As
+func (e VerificationError) Unwrap() []error {
+	errs := []error{ErrVerificationFailed}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// VerifyFile verifies the file at .Path against the checksum and/or
+// signature defined in the options object `opts`. A VerificationError
+// is returned if the file does not match
+func VerifyFile(opts VerifyFileOpts) error {
+	opts.SetDefaults()
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("failed to verify file: %w", err)
+	}
+	filePath, err := NormalizeLocalPath(opts.Path)
+	if err != nil {
+		return fmt.Errorf("failed to normalize path '%s': %w", opts.Path, err)
+	}
+
+	expectedChecksum := strings.ToLower(opts.Checksum)
+	if opts.ChecksumURL != nil {
+		checksums, err := fetchVerificationData(opts.Client, opts.ChecksumURL)
+		if err != nil {
+			return fmt.Errorf("failed to get checksum file: %w", err)
+		}
+		var found bool
+		if expectedChecksum, found = findChecksum(checksums, opts.ChecksumFileName); !found {
+			return VerificationError{
+				Path:   filePath,
+				Method: VerificationMethodChecksum,
+				Err:    fmt.Errorf("failed to find '%s' in checksum file at '%s'", opts.ChecksumFileName, opts.ChecksumURL.String()),
+			}
+		}
+	}
+	if expectedChecksum != "" {
+		if err := verifyChecksum(filePath, expectedChecksum, opts.ChecksumAlgorithm); err != nil {
+			return err
+		}
+	}
+
+	signature := opts.Signature
+	if opts.SignatureURL != nil {
+		if signature, err = fetchVerificationData(opts.Client, opts.SignatureURL); err != nil {
+			return fmt.Errorf("failed to get signature: %w", err)
+		}
+	}
+	if signature != nil {
+		if err := verifySignature(filePath, signature, opts.PublicKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchVerificationData retrieves the body of a checksum file or
+// signature
+func fetchVerificationData(client *http.Client, from *url.URL) ([]byte, error) {
+	res, err := SendHTTPRequest(SendHTTPRequestOpts{
+		Client: client,
+		Method: http.MethodGet,
+		URL:    from,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to get '%s': %w (%s)", from.String(), ErrUnexpectedStatusCode, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from '%s': %w", from.String(), err)
+	}
+	return data, nil
+}
+
+// findChecksum returns the digest for the provided file name from a
+// checksum file in the GNU (`<digest>  <name>`) or BSD
+// (`<ALGO> (<name>) = <digest>`) formats
+func findChecksum(checksums []byte, fileName string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var digest, name string
+		if matches := checksumBSDLine.FindStringSubmatch(line); matches != nil {
+			name, digest = matches[1], matches[2]
+		} else if separator := strings.IndexAny(line, " \t"); separator > 0 {
+			// names may contain spaces so only the first whitespace run
+			// separates the digest from the name
+			digest = line[:separator]
+			name = strings.TrimPrefix(strings.TrimLeft(line[separator:], " \t"), "*")
+		} else {
+			continue
+		}
+		if name == fileName || path.Base(name) == fileName {
+			return strings.ToLower(digest), true
+		}
+	}
+	return "", false
+}
+
+// getChecksumHash returns the hash for the provided algorithm, if
+// the algorithm is not specified, it is inferred from the length of
+// the hex-encoded digest
+func getChecksumHash(algorithm ChecksumAlgorithm, digest string) (hash.Hash, error) {
+	if algorithm == "" {
+		switch len(digest) {
+		case md5.Size * 2:
+			algorithm = ChecksumMD5
+		case sha256.Size * 2:
+			algorithm = ChecksumSHA256
+		case sha512.Size * 2:
+			algorithm = ChecksumSHA512
+		default:
+			return nil, fmt.Errorf("failed to infer checksum algorithm from a digest of length %v", len(digest))
+		}
+	}
+	switch algorithm {
+	case ChecksumMD5:
+		/* #nosec - md5 is supported for verifying legacy checksums only */
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm '%s'", algorithm)
+}
+
+// verifyChecksum returns a VerificationError if the digest of the file
+// at the provided path does not match the expected digest
+func verifyChecksum(filePath, expected string, algorithm ChecksumAlgorithm) error {
+	hasher, err := getChecksumHash(algorithm, expected)
+	if err != nil {
+		return VerificationError{Path: filePath, Method: VerificationMethodChecksum, Err: err}
+	}
+	/* #nosec - this is required to read the file */
+	fileHandle, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file at '%s': %w", filePath, err)
+	}
+	defer fileHandle.Close()
+	if _, err := io.Copy(hasher, fileHandle); err != nil {
+		return fmt.Errorf("failed to read file at '%s': %w", filePath, err)
+	}
+	if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+		return VerificationError{
+			Path:     filePath,
+			Method:   VerificationMethodChecksum,
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+	return nil
+}
+
+// verifySignature returns a VerificationError if the detached signature
+// of the file at the provided path was not made by the provided public
+// key
+func verifySignature(filePath string, signature, publicKey []byte) error {
+	var keyring openpgp.EntityList
+	var err error
+	if isArmored(publicKey) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(publicKey))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(publicKey))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+	/* #nosec - this is required to read the file */
+	fileHandle, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file at '%s': %w", filePath, err)
+	}
+	defer fileHandle.Close()
+	if isArmored(signature) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, fileHandle, bytes.NewReader(signature), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, fileHandle, bytes.NewReader(signature), nil)
+	}
+	if err != nil {
+		return VerificationError{Path: filePath, Method: VerificationMethodSignature, Err: err}
+	}
+	return nil
+}
+
+// isArmored returns true if the provided OpenPGP data is ASCII-armored
+func isArmored(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP"))
+}
@@ -0,0 +1,192 @@
+package devops
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/suite"
+)
+
+type VerifyFileTests struct {
+	suite.Suite
+	Data     []byte
+	FilePath string
+}
+
+func TestVerifyFile(t *testing.T) {
+	suite.Run(t, &VerifyFileTests{
+		Data: []byte("hello world\n"),
+	})
+}
+
+func (s *VerifyFileTests) SetupTest() {
+	s.FilePath = path.Join(s.T().TempDir(), "hello.txt")
+	s.Nil(ioutil.WriteFile(s.FilePath, s.Data, 0644))
+}
+
+func (s VerifyFileTests) TestVerifyFile_Checksum() {
+	s.Nil(VerifyFile(VerifyFileOpts{
+		Path:     s.FilePath,
+		Checksum: "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+	}))
+	s.Nil(VerifyFile(VerifyFileOpts{
+		Path:     s.FilePath,
+		Checksum: "6f5902ac237024bdd0c176cb93063dc4",
+	}), "md5 should be inferred from the length of the checksum")
+	s.Nil(VerifyFile(VerifyFileOpts{
+		Path:              s.FilePath,
+		Checksum:          "DB3974A97F2407B7CAE1AE637C0030687A11913274D578492558E39C16C017DE84EACDC8C62FE34EE4E12B4B1428817F09B6A2760C3F8A664CEAE94D2434A593",
+		ChecksumAlgorithm: ChecksumSHA512,
+	}))
+}
+
+func (s VerifyFileTests) TestVerifyFile_Checksum_mismatch() {
+	err := VerifyFile(VerifyFileOpts{
+		Path:     s.FilePath,
+		Checksum: "0000000000000000000000000000000000000000000000000000000000000000",
+	})
+	s.True(errors.Is(err, ErrVerificationFailed))
+	var verificationError VerificationError
+	s.True(errors.As(err, &verificationError))
+	s.Equal(VerificationMethodChecksum, verificationError.Method)
+	s.Equal("a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447", verificationError.Actual)
+}
+
+func (s VerifyFileTests) TestVerifyFile_ChecksumURL() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("" +
+			"0000000000000000000000000000000000000000000000000000000000000000  other.txt\n" +
+			"a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447 *dist/hello.txt\n",
+		))
+	}))
+	defer server.Close()
+	checksumURL, err := url.Parse(server.URL + "/SHA256SUMS")
+	s.Nil(err)
+	s.Nil(VerifyFile(VerifyFileOpts{
+		Path:        s.FilePath,
+		ChecksumURL: checksumURL,
+	}))
+
+	err = VerifyFile(VerifyFileOpts{
+		Path:             s.FilePath,
+		ChecksumFileName: "missing.txt",
+		ChecksumURL:      checksumURL,
+	})
+	s.True(errors.Is(err, ErrVerificationFailed))
+	s.Contains(err.Error(), "failed to find 'missing.txt'")
+}
+
+func (s VerifyFileTests) TestFindChecksum() {
+	checksums := []byte("" +
+		"1111111111111111111111111111111111111111111111111111111111111111  hello world.txt\n" +
+		"2222222222222222222222222222222222222222222222222222222222222222 *dist/hello  world.bin\n" +
+		"SHA256 (my file.tar.gz) = 3333333333333333333333333333333333333333333333333333333333333333\n",
+	)
+	for fileName, expected := range map[string]string{
+		"hello world.txt":  "1111111111111111111111111111111111111111111111111111111111111111",
+		"hello  world.bin": "2222222222222222222222222222222222222222222222222222222222222222",
+		"my file.tar.gz":   "3333333333333333333333333333333333333333333333333333333333333333",
+	} {
+		digest, ok := findChecksum(checksums, fileName)
+		s.True(ok, fileName)
+		s.Equal(expected, digest)
+	}
+	_, ok := findChecksum(checksums, "world.txt")
+	s.False(ok)
+}
+
+func (s VerifyFileTests) TestVerifyFile_Signature() {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	s.Nil(err)
+	var publicKey bytes.Buffer
+	s.Nil(entity.Serialize(&publicKey))
+	var signature bytes.Buffer
+	s.Nil(openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(s.Data), nil))
+
+	s.Nil(VerifyFile(VerifyFileOpts{
+		Path:      s.FilePath,
+		PublicKey: publicKey.Bytes(),
+		Signature: signature.Bytes(),
+	}))
+
+	s.Nil(ioutil.WriteFile(s.FilePath, []byte("tampered"), 0644))
+	err = VerifyFile(VerifyFileOpts{
+		Path:      s.FilePath,
+		PublicKey: publicKey.Bytes(),
+		Signature: signature.Bytes(),
+	})
+	s.True(errors.Is(err, ErrVerificationFailed))
+	var verificationError VerificationError
+	s.True(errors.As(err, &verificationError))
+	s.Equal(VerificationMethodSignature, verificationError.Method)
+}
+
+func (s VerifyFileTests) TestVerifyFileOpts_Validate() {
+	err := VerifyFileOpts{Path: s.FilePath}.Validate()
+	s.True(errors.Is(err, ErrInvalidOptions))
+	s.Contains(err.Error(), "missing checksum or signature")
+	err = VerifyFileOpts{Path: s.FilePath, Signature: []byte("signature")}.Validate()
+	s.Contains(err.Error(), "missing public key")
+	err = VerifyFileOpts{Path: s.FilePath, Checksum: "not hex"}.Validate()
+	s.Contains(err.Error(), "checksum is not hex-encoded")
+}
+
+func (s VerifyFileTests) TestDownloadFile_Verify() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(s.Data)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL + "/hello.txt")
+	s.Nil(err)
+	destinationPath := path.Join(s.T().TempDir(), "downloaded.txt")
+
+	err = DownloadFile(DownloadFileOpts{
+		DestinationPath: destinationPath,
+		URL:             serverURL,
+		Verify: &VerifyFileOpts{
+			Checksum: "0000000000000000000000000000000000000000000000000000000000000000",
+		},
+	})
+	s.True(errors.Is(err, ErrVerificationFailed))
+	_, err = os.Lstat(destinationPath)
+	s.True(errors.Is(err, os.ErrNotExist), "file should be removed when verification fails")
+
+	s.Nil(DownloadFile(DownloadFileOpts{
+		DestinationPath: destinationPath,
+		URL:             serverURL,
+		Verify: &VerifyFileOpts{
+			Checksum: "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447",
+		},
+	}))
+
+	s.Nil(ioutil.WriteFile(destinationPath, []byte("original"), 0644))
+	for _, opts := range []DownloadFileOpts{
+		{},
+		{Resume: true},
+		{Segments: 2},
+		{Cache: &DownloadCacheOpts{Directory: s.T().TempDir()}},
+	} {
+		opts.DestinationPath = destinationPath
+		opts.Overwrite = true
+		opts.URL = serverURL
+		opts.Verify = &VerifyFileOpts{
+			Checksum: "0000000000000000000000000000000000000000000000000000000000000000",
+		}
+		err = DownloadFile(opts)
+		s.True(errors.Is(err, ErrVerificationFailed))
+		content, err := ioutil.ReadFile(destinationPath)
+		s.Nil(err)
+		s.Equal("original", string(content), "existing files should be kept when verification fails")
+		entries, err := ioutil.ReadDir(path.Dir(destinationPath))
+		s.Nil(err)
+		s.Len(entries, 1, "downloads which fail verification should be removed")
+	}
+}